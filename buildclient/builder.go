@@ -1,6 +1,7 @@
 package buildclient
 
 import (
+	"context"
 	"fmt"
 	"github.com/legosx/gopro-media-library-verifier/client"
 	"github.com/pkg/errors"
@@ -34,16 +35,19 @@ type createClientFunc func(token string, opts ...func(c *client.Client) error) (
 type promptSelectFunc func(label string, items interface{}) (int, error)
 
 type Builder struct {
+	ctx                context.Context
 	configAuthTokenKey *string
 	persistConfig      PersistConfig
 	verbose            Verbose
 	tokenPromptMethods []TokenPromptMethod
 	promptSelect       promptSelectFunc
 	createClient       createClientFunc
+	clientOptions      []func(c *client.Client) error
 }
 
 func NewBuilder(opts ...func(builder *Builder)) (builder Builder) {
 	builder = Builder{
+		ctx:          context.Background(),
 		promptSelect: PromptSelect,
 		createClient: func(token string, opts ...func(c *client.Client) error) (c *client.Client, err error) {
 			return client.NewClient(token, opts...)
@@ -57,6 +61,20 @@ func NewBuilder(opts ...func(builder *Builder)) (builder Builder) {
 	return builder
 }
 
+// WithContext sets the context of the requests checking the token
+func WithContext(ctx context.Context) func(builder *Builder) {
+	return func(builder *Builder) {
+		builder.ctx = ctx
+	}
+}
+
+// WithClientOptions sets the options every created client gets, e.g. client.WithBaseURL
+func WithClientOptions(opts ...func(c *client.Client) error) func(builder *Builder) {
+	return func(builder *Builder) {
+		builder.clientOptions = append(builder.clientOptions, opts...)
+	}
+}
+
 func WithConfigAuthTokenKey(key string) func(builder *Builder) {
 	return func(builder *Builder) {
 		builder.configAuthTokenKey = &key
@@ -155,14 +173,15 @@ func (b Builder) fromConfigAuthToken() (c *client.Client, err error) {
 
 	b.print("Token found in config")
 
-	if c, err = b.createClient(authToken, client.WithAuthCheck()); err == nil {
+	if c, err = b.createClient(authToken, b.getClientOptions()...); err == nil {
 		b.print("Using stored token")
 		b.handleValidToken(authToken)
 
 		return c, nil
 	}
 
-	if errors.As(err, &client.ErrorResponse{}) {
+	// Only an auth error tells the token is not valid, the API or the network can fail with a valid token too
+	if errors.Is(err, client.ErrUnauthorized) || errors.Is(err, client.ErrForbidden) {
 		b.printErr(err, "stored token is not valid")
 
 		return nil, nil
@@ -180,7 +199,11 @@ func (b Builder) fromUserPrompt(tokenPromptMethod TokenPromptMethod) (c *client.
 			return nil, errors.Wrap(err, "token prompt failed")
 		}
 
-		if c, err = b.createClient(tokenValue, client.WithAuthCheck()); err != nil {
+		if c, err = b.createClient(tokenValue, b.getClientOptions()...); err != nil {
+			if ctxErr := b.ctx.Err(); ctxErr != nil {
+				return nil, errors.Wrap(ctxErr, "error checking client")
+			}
+
 			b.printErr(err, "error checking client")
 		} else {
 			b.handleValidToken(tokenValue)
@@ -191,6 +214,13 @@ func (b Builder) fromUserPrompt(tokenPromptMethod TokenPromptMethod) (c *client.
 	return c, nil
 }
 
+// getClientOptions returns the client options followed by the auth check, so the check uses them too
+func (b Builder) getClientOptions() (opts []func(c *client.Client) error) {
+	opts = append(opts, b.clientOptions...)
+
+	return append(opts, client.WithAuthCheck(b.ctx))
+}
+
 func (b Builder) print(a any) {
 	if b.verbose == VerboseNone {
 		return
@@ -8,6 +8,7 @@ import (
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	"net/http"
 	"os"
 	"path/filepath"
 	"testing"
@@ -88,6 +89,46 @@ func TestBuilder_Build(t *testing.T) {
 				err: errors.Wrap(assert.AnError, "failed to get token from config"),
 			},
 		},
+		{
+			name: "sad path, stored token is rejected by the API",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(builder *buildclient.Builder) {
+					tokenKey := setRandomViperKey("invalid")
+
+					createClient := func(token string, opts ...func(c *client.Client) error) (c *client.Client, err error) {
+						return nil, client.NewErrorResponse(&http.Response{StatusCode: http.StatusUnauthorized})
+					}
+
+					return []func(builder *buildclient.Builder){
+						buildclient.WithConfigAuthTokenKey(tokenKey),
+						buildclient.WithCreateClient(createClient),
+					}
+				},
+			},
+			want: want{
+				err: errors.New("no client created"),
+			},
+		},
+		{
+			name: "sad path, API fails while checking the stored token",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(builder *buildclient.Builder) {
+					tokenKey := setRandomViperKey("valid")
+
+					createClient := func(token string, opts ...func(c *client.Client) error) (c *client.Client, err error) {
+						return nil, client.NewErrorResponse(&http.Response{StatusCode: http.StatusServiceUnavailable})
+					}
+
+					return []func(builder *buildclient.Builder){
+						buildclient.WithConfigAuthTokenKey(tokenKey),
+						buildclient.WithCreateClient(createClient),
+					}
+				},
+			},
+			want: want{
+				err: errors.New("failed to get token from config: 503 Service Unavailable"),
+			},
+		},
 		{
 			name: "happy path, token is valid",
 			fields: fields{
@@ -105,6 +146,26 @@ func TestBuilder_Build(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "happy path, client options are passed along with the auth check",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(builder *buildclient.Builder) {
+					tokenKey := setRandomViperKey("valid")
+
+					createClient := func(token string, opts ...func(c *client.Client) error) (c *client.Client, err error) {
+						assert.Len(t, opts, 2)
+
+						return &client.Client{}, nil
+					}
+
+					return []func(builder *buildclient.Builder){
+						buildclient.WithConfigAuthTokenKey(tokenKey),
+						buildclient.WithCreateClient(createClient),
+						buildclient.WithClientOptions(client.WithBaseURL("http://localhost:8080")),
+					}
+				},
+			},
+		},
 		{
 			name: "happy path, persist config if changed",
 			fields: fields{
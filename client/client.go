@@ -1,19 +1,26 @@
 package client
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"github.com/pkg/errors"
 	"go.uber.org/multierr"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
 	url_endpoint       = "https://api.gopro.com/"
 	path_notifications = "notification_center/notifications"
 	path_media_search  = "media/search"
+	path_media         = "media"
+	path_derivatives   = "derivatives"
+	path_user_uploads  = "user-uploads"
 )
 
 type HTTPClient interface {
@@ -21,20 +28,26 @@ type HTTPClient interface {
 }
 
 type HTTPRequester interface {
-	NewRequest(method, url string, body io.Reader) (*http.Request, error)
+	NewRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error)
 }
 
 type Client struct {
 	token         string
+	baseURL       string
 	httpClient    HTTPClient
 	httpRequester HTTPRequester
+	retryPolicy   RetryPolicy
+	sleep         func(ctx context.Context, d time.Duration) error
 }
 
 func NewClient(token string, opts ...func(client *Client) error) (client *Client, err error) {
 	client = &Client{
 		token:         token,
+		baseURL:       url_endpoint,
 		httpClient:    &http.Client{},
 		httpRequester: NewHTTPWrapper(),
+		retryPolicy:   DefaultRetryPolicy(),
+		sleep:         sleepContext,
 	}
 
 	for _, opt := range opts {
@@ -46,9 +59,27 @@ func NewClient(token string, opts ...func(client *Client) error) (client *Client
 	return client, nil
 }
 
-func WithAuthCheck() func(c *Client) error {
+func WithAuthCheck(ctx context.Context) func(c *Client) error {
 	return func(c *Client) error {
-		return c.AuthCheck()
+		return c.AuthCheck(ctx)
+	}
+}
+
+// WithBaseURL points the client to another API server, e.g. a local fake server or a recording proxy
+func WithBaseURL(baseURL string) func(c *Client) error {
+	return func(c *Client) error {
+		parsedURL, err := url.Parse(baseURL)
+		if err != nil {
+			return errors.Wrap(err, "invalid base URL")
+		}
+
+		if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" || parsedURL.Host == "" {
+			return errors.Errorf("invalid base URL: %s", baseURL)
+		}
+
+		c.baseURL = strings.TrimSuffix(baseURL, "/") + "/"
+
+		return nil
 	}
 }
 
@@ -68,8 +99,8 @@ func WithHTTPRequester(httpRequester HTTPRequester) func(c *Client) error {
 	}
 }
 
-func (c Client) AuthCheck() (err error) {
-	if _, err = c.get(path_notifications, map[string]string{}); err != nil {
+func (c Client) AuthCheck(ctx context.Context) (err error) {
+	if _, err = c.get(ctx, path_notifications, map[string]string{}); err != nil {
 		return errors.Wrap(err, "error checking authentication")
 	}
 
@@ -80,8 +111,27 @@ func (c Client) GetAllowedExtensions() []string {
 	return []string{".mp4", ".mov", ".360", ".heic", ".jpg", ".jpeg", ".png"}
 }
 
-func (c Client) GetPage(pageNumber, perPage int) (page Page, err error) {
-	response, err := c.getPageWithRetry(pageNumber, perPage, 10)
+// CapturedRange limits the media search to the medias captured between From and To, both inclusive
+type CapturedRange struct {
+	From time.Time
+	To   time.Time
+}
+
+func (r CapturedRange) IsZero() bool {
+	return r.From.IsZero() && r.To.IsZero()
+}
+
+func (r CapturedRange) String() string {
+	return r.From.UTC().Format(time.RFC3339) + "," + r.To.UTC().Format(time.RFC3339)
+}
+
+func (c Client) GetPage(ctx context.Context, pageNumber, perPage int) (page Page, err error) {
+	return c.GetPageInRange(ctx, CapturedRange{}, pageNumber, perPage)
+}
+
+// GetPageInRange returns a page of the medias captured in the given range, the pages and totals only count these medias
+func (c Client) GetPageInRange(ctx context.Context, capturedRange CapturedRange, pageNumber, perPage int) (page Page, err error) {
+	response, err := c.getPageWithRetry(ctx, capturedRange, pageNumber, perPage, 10)
 	if err != nil {
 		return Page{}, errors.Wrap(err, "error getting page")
 	}
@@ -89,16 +139,17 @@ func (c Client) GetPage(pageNumber, perPage int) (page Page, err error) {
 	var medias []Media
 
 	for _, media := range response.Embedded.Media {
-		medias = append(medias, NewMedia(media.FileName, media.FileSize))
+		medias = append(medias, media.toMedia())
 	}
 
-	return NewPage(response.Pages.TotalPages, medias), nil
+	return NewPage(response.Pages.TotalPages, medias, WithPageTotalItems(response.Pages.TotalItems)), nil
 }
 
-// Sometimes it doesn't return all items from the first try
-func (c Client) getPageWithRetry(pageNumber, perPage, maxRetries int) (page *page, err error) {
+// Sometimes it doesn't return all items from the first try.
+// The page is returned as is when it's still short after all retries, the fetcher compares the total count afterwards.
+func (c Client) getPageWithRetry(ctx context.Context, capturedRange CapturedRange, pageNumber, perPage, maxRetries int) (page *page, err error) {
 	for retry := 0; retry < maxRetries; retry++ {
-		if page, err = c.getPage(pageNumber, perPage); err != nil {
+		if page, err = c.getPage(ctx, capturedRange, pageNumber, perPage); err != nil {
 			return nil, errors.Wrap(err, "error getting page with retry")
 		}
 
@@ -117,54 +168,92 @@ func (c Client) getPageWithRetry(pageNumber, perPage, maxRetries int) (page *pag
 	return page, nil
 }
 
-func (c Client) getPage(pageNumber, perPage int) (page *page, err error) {
-	body, err := c.get(path_media_search, map[string]string{
+func (c Client) getPage(ctx context.Context, capturedRange CapturedRange, pageNumber, perPage int) (page *page, err error) {
+	queryParameters := map[string]string{
 		"fields":            strings.Join(c.getDefaultFields(), ","),
 		"processing_states": strings.Join(c.getDefaultProcessingStates(), ","),
 		"order_by":          "captured_at",
 		"per_page":          strconv.Itoa(perPage),
 		"page":              strconv.Itoa(pageNumber),
 		"type":              strings.Join(c.getDefaultTypes(), ","),
-	})
+	}
+
+	if !capturedRange.IsZero() {
+		queryParameters["captured_range"] = capturedRange.String()
+	}
+
+	body, err := c.get(ctx, path_media_search, queryParameters)
 	if err != nil {
 		return nil, errors.Wrap(err, "error getting data from client")
 	}
 
 	if err = json.Unmarshal(body, &page); err != nil {
-		return nil, errors.Wrapf(err, "error decoding JSON: %+v, json: %s", err, string(body))
+		return nil, newMalformedResponseError(errors.Wrapf(err, "error decoding JSON: %+v, json: %s", err, string(body)))
+	}
+
+	if len(page.Embedded.Errors) > 0 {
+		return nil, newEmbeddedErrorResponse("/"+path_media_search, page.Embedded.Errors)
 	}
 
 	if page.Pages.CurrentPage == 0 {
-		return nil, errors.Errorf("unexpected response: %s", string(body))
+		return nil, newMalformedResponseError(errors.Errorf("unexpected response: %s", string(body)))
 	}
 
 	return page, nil
 }
 
-func (c Client) get(path string, queryParameters map[string]string) (body []byte, err error) {
+func (c Client) get(ctx context.Context, path string, queryParameters map[string]string) (body []byte, err error) {
+	return c.do(ctx, http.MethodGet, path, queryParameters, nil)
+}
+
+func (c Client) send(ctx context.Context, method, path string, payload interface{}) (body []byte, err error) {
+	requestBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "error encoding JSON")
+	}
+
+	return c.do(ctx, method, path, map[string]string{}, requestBody)
+}
+
+func (c Client) do(ctx context.Context, method, path string, queryParameters map[string]string, requestBody []byte) (body []byte, err error) {
 	headers := map[string]string{
 		"Authority":     "api.gopro.com",
 		"Accept":        "application/vnd.gopro.jk.media+json; version=2.0.0",
 		"Authorization": "Bearer " + c.token,
 	}
 
-	url := url_endpoint + path
+	requestURL := c.baseURL + path
 
 	delimiter := "?"
 	for key, value := range queryParameters {
-		url = url + delimiter + key + "=" + value
+		requestURL = requestURL + delimiter + key + "=" + value
 		delimiter = "&"
 	}
 
-	// Create an HTTP request with the specified URL and headers
-	req, err := c.httpRequester.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, errors.Wrap(err, "error creating HTTP request")
-	}
-	for key, value := range headers {
-		req.Header.Set(key, value)
+	if requestBody != nil {
+		headers["Content-Type"] = "application/json"
 	}
 
+	return c.withRetry(ctx, method, func() ([]byte, error) {
+		var reqBody io.Reader
+		if requestBody != nil {
+			reqBody = bytes.NewReader(requestBody)
+		}
+
+		// Create an HTTP request with the specified URL and headers
+		req, err := c.httpRequester.NewRequest(ctx, method, requestURL, reqBody)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating HTTP request")
+		}
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		return c.perform(req)
+	})
+}
+
+func (c Client) perform(req *http.Request) (body []byte, err error) {
 	// Perform the HTTP request
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -178,7 +267,8 @@ func (c Client) get(path string, queryParameters map[string]string) (body []byte
 		}
 	}()
 
-	if resp.StatusCode != http.StatusOK {
+	// The creating requests answer 201 Created
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
 		return nil, NewErrorResponse(resp)
 	}
 
@@ -201,7 +291,21 @@ func (c Client) getDefaultProcessingStates() []string {
 }
 
 func (c Client) getDefaultFields() []string {
-	return []string{"filename", "file_size"}
+	return []string{
+		"id",
+		"filename",
+		"file_size",
+		"captured_at",
+		"created_at",
+		"ready_to_view",
+		"type",
+		"content_title",
+		"camera_model",
+		"source_duration",
+		"width",
+		"height",
+		"gopro_user_id",
+	}
 }
 
 func (c Client) getDefaultTypes() []string {
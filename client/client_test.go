@@ -2,6 +2,7 @@ package client_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/legosx/gopro-media-library-verifier/client"
 	"github.com/legosx/gopro-media-library-verifier/client/mocks"
@@ -13,6 +14,7 @@ import (
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 )
 
 //go:generate mockgen -destination=./mocks/http_client.go -package=mocks github.com/legosx/gopro-media-library-verifier/client HTTPClient
@@ -63,11 +65,62 @@ func TestNewClient(t *testing.T) {
 
 					return []func(client *client.Client) error{
 						client.WithHTTPClient(httpClientMock),
-						client.WithAuthCheck(),
+						client.WithAuthCheck(context.Background()),
 					}
 				},
 			},
 		},
+		{
+			name: "happy path, with base URL",
+			fields: fields{
+				token: "token",
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "http://localhost:8080/api/notification_center/notifications", req.URL.String())
+
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(`{}`)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithBaseURL("http://localhost:8080/api"),
+						client.WithHTTPClient(httpClientMock),
+						client.WithAuthCheck(context.Background()),
+					}
+				},
+			},
+		},
+		{
+			name: "sad path, base URL without scheme",
+			fields: fields{
+				token: "token",
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					return []func(client *client.Client) error{
+						client.WithBaseURL("localhost:8080"),
+					}
+				},
+			},
+			want: want{
+				err: errors.New("invalid base URL: localhost:8080"),
+			},
+		},
+		{
+			name: "sad path, base URL can't be parsed",
+			fields: fields{
+				token: "token",
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					return []func(client *client.Client) error{
+						client.WithBaseURL("http://local host"),
+					}
+				},
+			},
+			want: want{
+				err: errors.New("invalid base URL: parse \"http://local host\": invalid character \" \" in host name"),
+			},
+		},
 		{
 			name: "sad path, auth check failed",
 			fields: fields{
@@ -89,7 +142,7 @@ func TestNewClient(t *testing.T) {
 
 					return []func(client *client.Client) error{
 						client.WithHTTPClient(httpClientMock),
-						client.WithAuthCheck(),
+						client.WithAuthCheck(context.Background()),
 					}
 				},
 			},
@@ -203,7 +256,7 @@ func TestClient_AuthCheck(t *testing.T) {
 			c, err := client.NewClient(tt.fields.token, tt.fields.opts(mockCtrl)...)
 			assert.NoError(t, err)
 
-			err = c.AuthCheck()
+			err = c.AuthCheck(context.Background())
 			if tt.want.err == nil {
 				assert.NoError(t, err)
 			} else {
@@ -308,13 +361,13 @@ func TestClient_GetPage(t *testing.T) {
 						assert.Equal(t, "2", q.Get("per_page"))
 						assert.Equal(t, "captured_at", q.Get("order_by"))
 						assert.Equal(t, "Burst,BurstVideo,Continuous,LoopedVideo,Photo,TimeLapse,TimeLapseVideo,Video,MultiClipEdit", q.Get("type"))
-						assert.Equal(t, "filename,file_size", q.Get("fields"))
+						assert.Equal(t, "id,filename,file_size,captured_at,created_at,ready_to_view,type,content_title,camera_model,source_duration,width,height,gopro_user_id", q.Get("fields"))
 						assert.Equal(t, "registered,rendering,pretranscoding,transcoding,failure,ready", q.Get("processing_states"))
 						assert.Equal(t, "1", q.Get("page"))
 
 						medias := []string{
-							`{"filename": "file1.mp4","file_size": 10}`,
-							`{"filename": "file2.jpg","file_size": 20}`,
+							`{"id": "id1","filename": "file1.mp4","file_size": 10,"captured_at": "2023-06-10T12:34:56Z","ready_to_view": "ready","type": "Video","camera_model": "HERO11 Black","created_at": "2023-06-11T08:00:00Z","content_title": "Ride","source_duration": "63063","width": 3840,"height": 2160,"gopro_user_id": "user1"}`,
+							`{"id": "id2","filename": "file2.jpg","file_size": 20,"source_duration": 1500}`,
 						}
 
 						return &http.Response{
@@ -333,9 +386,21 @@ func TestClient_GetPage(t *testing.T) {
 				page: client.NewPage(
 					3,
 					[]client.Media{
-						client.NewMedia("file1.mp4", 10),
-						client.NewMedia("file2.jpg", 20),
+						client.NewMedia("file1.mp4", 10,
+							client.WithMediaID("id1"),
+							client.WithMediaCapturedAt(time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)),
+							client.WithMediaProcessingState("ready"),
+							client.WithMediaType("Video"),
+							client.WithMediaCameraModel("HERO11 Black"),
+							client.WithMediaCreatedAt(time.Date(2023, 6, 11, 8, 0, 0, 0, time.UTC)),
+							client.WithMediaContentTitle("Ride"),
+							client.WithMediaSourceDuration(63063*time.Millisecond),
+							client.WithMediaDimensions(3840, 2160),
+							client.WithMediaGoProUserID("user1"),
+						),
+						client.NewMedia("file2.jpg", 20, client.WithMediaID("id2"), client.WithMediaSourceDuration(1500*time.Millisecond)),
 					},
+					client.WithPageTotalItems(5),
 				),
 			},
 		},
@@ -383,6 +448,7 @@ func TestClient_GetPage(t *testing.T) {
 						client.NewMedia("file3.mp4", 30),
 						client.NewMedia("file4.jpg", 40),
 					},
+					client.WithPageTotalItems(5),
 				),
 			},
 		},
@@ -430,6 +496,7 @@ func TestClient_GetPage(t *testing.T) {
 						client.NewMedia("file7.mp4", 70),
 						client.NewMedia("file8.jpg", 80),
 					},
+					client.WithPageTotalItems(8),
 				),
 			},
 		},
@@ -440,7 +507,7 @@ func TestClient_GetPage(t *testing.T) {
 				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
 
 					httpRequesterMock := mocks.NewMockHTTPRequester(mockCtrl)
-					httpRequesterMock.EXPECT().NewRequest(gomock.Any(), gomock.Any(), gomock.Any()).
+					httpRequesterMock.EXPECT().NewRequest(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
 						Times(1).
 						Return(nil, assert.AnError)
 
@@ -670,7 +737,7 @@ func TestClient_GetPage(t *testing.T) {
 			c, err := client.NewClient(tt.fields.token, tt.fields.opts(mockCtrl)...)
 			assert.NoError(t, err)
 
-			got, err := c.GetPage(tt.args.pageNumber, tt.args.perPage)
+			got, err := c.GetPage(context.Background(), tt.args.pageNumber, tt.args.perPage)
 			if tt.want.err == nil {
 				assert.Equal(t, tt.want.page, got)
 				assert.NoError(t, err)
@@ -706,3 +773,35 @@ func (er *errorReader) Read(p []byte) (n int, err error) {
 func (erc *errorReader) Close() error {
 	return nil
 }
+
+func TestClient_GetPageInRange(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+	httpClientMock.EXPECT().Do(gomock.Any()).Times(1).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+		q := req.URL.Query()
+		assert.Equal(t, "2023-01-01T00:00:00Z,2023-01-31T23:59:59Z", q.Get("captured_range"))
+		assert.Equal(t, "1", q.Get("page"))
+		assert.Equal(t, "2", q.Get("per_page"))
+
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       getBody(1, 2, 1, 1, []string{`{"id": "id1","filename": "file1.mp4","file_size": 10}`}),
+		}, nil
+	})
+
+	c, err := client.NewClient("token", client.WithHTTPClient(httpClientMock))
+	assert.NoError(t, err)
+
+	capturedRange := client.CapturedRange{
+		From: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2023, 1, 31, 23, 59, 59, 0, time.UTC),
+	}
+
+	got, err := c.GetPageInRange(context.Background(), capturedRange, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, client.NewPage(1, []client.Media{client.NewMedia("file1.mp4", 10, client.WithMediaID("id1"))}, client.WithPageTotalItems(1)), got)
+}
@@ -0,0 +1,108 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type MediaDownload struct {
+	fileName string
+	url      string
+}
+
+func NewMediaDownload(fileName, url string) MediaDownload {
+	return MediaDownload{
+		fileName: fileName,
+		url:      url,
+	}
+}
+
+func (d MediaDownload) FileName() string {
+	return d.fileName
+}
+
+func (d MediaDownload) URL() string {
+	return d.url
+}
+
+// GetMedia returns the details of a single media item.
+func (c Client) GetMedia(ctx context.Context, mediaID string) (m Media, err error) {
+	body, err := c.get(ctx, path_media+"/"+mediaID, map[string]string{
+		"fields": strings.Join(c.getDefaultFields(), ","),
+	})
+	if err != nil {
+		return Media{}, errors.Wrap(err, "error getting media")
+	}
+
+	var response media
+	if err = json.Unmarshal(body, &response); err != nil {
+		return Media{}, newMalformedResponseError(errors.Wrapf(err, "error decoding JSON: %+v, json: %s", err, string(body)))
+	}
+
+	if response.ID == "" {
+		return Media{}, newMalformedResponseError(errors.Errorf("unexpected response: %s", string(body)))
+	}
+
+	return response.toMedia(), nil
+}
+
+// GetMediaDownload returns the URL of the original source file of the media.
+func (c Client) GetMediaDownload(ctx context.Context, mediaID string) (download MediaDownload, err error) {
+	body, err := c.get(ctx, path_media+"/"+mediaID+"/download", map[string]string{})
+	if err != nil {
+		return MediaDownload{}, errors.Wrap(err, "error getting media download")
+	}
+
+	var response mediaDownload
+	if err = json.Unmarshal(body, &response); err != nil {
+		return MediaDownload{}, newMalformedResponseError(errors.Wrapf(err, "error decoding JSON: %+v, json: %s", err, string(body)))
+	}
+
+	for _, file := range response.Embedded.Files {
+		if file.Available && file.CameraPosition == uploadCameraPosition && file.URL != "" {
+			return NewMediaDownload(response.FileName, file.URL), nil
+		}
+	}
+
+	return MediaDownload{}, errors.Errorf("no source file available for download: %s", string(body))
+}
+
+// OpenDownload starts downloading the file from the given offset.
+// The returned offset is where the body actually starts: 0 when the server ignores the range request.
+func (c Client) OpenDownload(ctx context.Context, url string, offset int64) (body io.ReadCloser, startOffset int64, err error) {
+	req, err := c.httpRequester.NewRequest(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "error creating HTTP request")
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "error performing HTTP request")
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, 0, nil
+	case http.StatusPartialContent:
+		return resp.Body, offset, nil
+	}
+
+	err = NewErrorResponse(resp)
+
+	if resp.Body != nil {
+		if innerErr := resp.Body.Close(); innerErr != nil {
+			return nil, 0, errors.Wrap(innerErr, "error closing HTTP response body")
+		}
+	}
+
+	return nil, 0, err
+}
@@ -0,0 +1,469 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/client/mocks"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestMediaDownload(t *testing.T) {
+	got := client.NewMediaDownload("file1.mp4", "https://storage/file1.mp4")
+
+	assert.Equal(t, "file1.mp4", got.FileName())
+	assert.Equal(t, "https://storage/file1.mp4", got.URL())
+}
+
+func TestClient_GetMedia(t *testing.T) {
+	type fields struct {
+		opts func(mockCtrl *gomock.Controller) []func(client *client.Client) error
+	}
+
+	type want struct {
+		media client.Media
+		err   error
+	}
+
+	tests := []struct {
+		name string
+		fields
+		want
+	}{
+		{
+			name: "happy path",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "GET", req.Method)
+						assert.Equal(t, "/media/id1", req.URL.Path)
+						assert.Equal(t, "id,filename,file_size,captured_at,created_at,ready_to_view,type,content_title,camera_model,source_duration,width,height,gopro_user_id", req.URL.Query().Get("fields"))
+
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(`{"id": "id1", "filename": "file1.mp4", "file_size": 10}`)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				media: client.NewMedia("file1.mp4", 10, client.WithMediaID("id1")),
+			},
+		},
+		{
+			name: "sad path, error response",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{
+						StatusCode: http.StatusNotFound,
+						Status:     "404 Not Found",
+					}, nil)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.New("error getting media: 404 Not Found"),
+			},
+		},
+		{
+			name: "sad path, invalid json",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewBufferString(`{invalid}`)),
+					}, nil)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.New("error decoding JSON: " +
+					"invalid character 'i' looking for beginning of object key string, json:" +
+					" {invalid}: invalid character 'i' looking for beginning of object key string"),
+			},
+		},
+		{
+			name: "sad path, unexpected response",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewBufferString(`{}`)),
+					}, nil)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.New("unexpected response: {}"),
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			c, err := client.NewClient("token", tt.fields.opts(mockCtrl)...)
+			assert.NoError(t, err)
+
+			got, err := c.GetMedia(context.Background(), "id1")
+			if tt.want.err == nil {
+				assert.Equal(t, tt.want.media, got)
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err.Error())
+			}
+		})
+	}
+}
+
+func TestClient_GetMediaDownload(t *testing.T) {
+	type fields struct {
+		opts func(mockCtrl *gomock.Controller) []func(client *client.Client) error
+	}
+
+	type want struct {
+		download client.MediaDownload
+		err      error
+	}
+
+	tests := []struct {
+		name string
+		fields
+		want
+	}{
+		{
+			name: "happy path",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "GET", req.Method)
+						assert.Equal(t, "/media/id1/download", req.URL.Path)
+						assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(bytes.NewBufferString(`{"filename": "file1.mp4", "_embedded": {"files": [` +
+								`{"url": "https://storage/pending", "camera_position": "default", "item_number": 1, "available": false},` +
+								`{"url": "https://storage/file1.mp4", "camera_position": "default", "item_number": 1, "available": true}` +
+								`]}}`)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				download: client.NewMediaDownload("file1.mp4", "https://storage/file1.mp4"),
+			},
+		},
+		{
+			name: "sad path, no source file available",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewBufferString(`{"filename": "file1.mp4", "_embedded": {"files": []}}`)),
+					}, nil)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.New(`no source file available for download: {"filename": "file1.mp4", "_embedded": {"files": []}}`),
+			},
+		},
+		{
+			name: "sad path, error response",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{
+						StatusCode: http.StatusNotFound,
+						Status:     "404 Not Found",
+					}, nil)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.New("error getting media download: 404 Not Found"),
+			},
+		},
+		{
+			name: "sad path, invalid json",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewBufferString(`{invalid}`)),
+					}, nil)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.New("error decoding JSON: " +
+					"invalid character 'i' looking for beginning of object key string, json:" +
+					" {invalid}: invalid character 'i' looking for beginning of object key string"),
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			c, err := client.NewClient("token", tt.fields.opts(mockCtrl)...)
+			assert.NoError(t, err)
+
+			got, err := c.GetMediaDownload(context.Background(), "id1")
+			if tt.want.err == nil {
+				assert.Equal(t, tt.want.download, got)
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err.Error())
+			}
+		})
+	}
+}
+
+func TestClient_OpenDownload(t *testing.T) {
+	type args struct {
+		offset int64
+	}
+
+	type fields struct {
+		opts func(mockCtrl *gomock.Controller) []func(client *client.Client) error
+	}
+
+	type want struct {
+		body        string
+		startOffset int64
+		err         error
+	}
+
+	tests := []struct {
+		name string
+		fields
+		args
+		want
+	}{
+		{
+			name: "happy path, from the beginning",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "https://storage/file1.mp4", req.URL.String())
+						assert.Empty(t, req.Header.Get("Range"))
+						assert.Empty(t, req.Header.Get("Authorization"))
+
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(`0123456789`)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				body: "0123456789",
+			},
+		},
+		{
+			name: "happy path, resumed",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "bytes=4-", req.Header.Get("Range"))
+
+						return &http.Response{
+							StatusCode: http.StatusPartialContent,
+							Body:       io.NopCloser(bytes.NewBufferString(`456789`)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			args: args{offset: 4},
+			want: want{
+				body:        "456789",
+				startOffset: 4,
+			},
+		},
+		{
+			name: "happy path, range is ignored by the server",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewBufferString(`0123456789`)),
+					}, nil)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			args: args{offset: 4},
+			want: want{
+				body: "0123456789",
+			},
+		},
+		{
+			name: "sad path, cannot create http request",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpRequesterMock := mocks.NewMockHTTPRequester(mockCtrl)
+					httpRequesterMock.EXPECT().NewRequest(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, assert.AnError)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(mocks.NewMockHTTPClient(mockCtrl)),
+						client.WithHTTPRequester(httpRequesterMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.Wrap(assert.AnError, "error creating HTTP request"),
+			},
+		},
+		{
+			name: "sad path, error from client",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(nil, assert.AnError)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.Wrap(assert.AnError, "error performing HTTP request"),
+			},
+		},
+		{
+			name: "sad path, error response",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{
+						StatusCode: http.StatusForbidden,
+						Status:     "403 Forbidden",
+						Body:       io.NopCloser(bytes.NewBufferString(`denied`)),
+					}, nil)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.New("403 Forbidden"),
+			},
+		},
+		{
+			name: "sad path, error response, can not close the body",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{
+						StatusCode: http.StatusForbidden,
+						Status:     "403 Forbidden",
+						Body:       &errorReadCloser{Reader: bytes.NewBufferString(`denied`)},
+					}, nil)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.New("error closing HTTP response body: Close error"),
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			c, err := client.NewClient("token", tt.fields.opts(mockCtrl)...)
+			assert.NoError(t, err)
+
+			body, startOffset, err := c.OpenDownload(context.Background(), "https://storage/file1.mp4", tt.args.offset)
+			if tt.want.err == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.startOffset, startOffset)
+
+				got, err := io.ReadAll(body)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.body, string(got))
+				assert.NoError(t, body.Close())
+			} else {
+				assert.EqualError(t, err, tt.want.err.Error())
+			}
+		})
+	}
+}
@@ -1,15 +1,217 @@
 package client
 
-import "net/http"
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"strings"
+)
 
+// Classification of the errors returned by the client, check them with errors.Is
+var (
+	ErrUnauthorized      = errors.New("unauthorized")
+	ErrForbidden         = errors.New("forbidden")
+	ErrRateLimited       = errors.New("rate limited")
+	ErrServer            = errors.New("server error")
+	ErrMalformedResponse = errors.New("malformed response")
+)
+
+// maxErrorBodySize limits how much of an error response is kept, it's only needed for the message
+const maxErrorBodySize = 64 << 10
+
+// APIError is a single error reported by Gopro Media Library API in the response body
+type APIError struct {
+	Code        string
+	Reason      string
+	Description string
+}
+
+func (e *APIError) UnmarshalJSON(data []byte) error {
+	var payload struct {
+		Code        json.RawMessage `json:"code"`
+		Reason      string          `json:"reason"`
+		Description string          `json:"description"`
+		Message     string          `json:"message"`
+	}
+
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+
+	// The code comes either as a number or as a string
+	if code := string(payload.Code); code != "null" {
+		e.Code = strings.Trim(code, `"`)
+	}
+	e.Reason = payload.Reason
+	e.Description = payload.Description
+	if e.Description == "" {
+		e.Description = payload.Message
+	}
+
+	return nil
+}
+
+func (e APIError) String() string {
+	switch {
+	case e.Description != "":
+		return e.Description
+	case e.Reason != "":
+		return e.Reason
+	default:
+		return e.Code
+	}
+}
+
+// ErrorResponse is returned when the API responds with an unexpected status code
 type ErrorResponse struct {
-	resp *http.Response
+	statusCode int
+	status     string
+	path       string
+	header     http.Header
+	body       []byte
+	apiErrors  []APIError
 }
 
+// NewErrorResponse reads the error details from the response, the caller still has to close the body
 func NewErrorResponse(resp *http.Response) error {
-	return ErrorResponse{resp: resp}
+	e := ErrorResponse{
+		statusCode: resp.StatusCode,
+		status:     resp.Status,
+		header:     resp.Header,
+	}
+
+	if e.status == "" {
+		e.status = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	if resp.Request != nil && resp.Request.URL != nil {
+		e.path = resp.Request.URL.Path
+	}
+
+	if resp.Body != nil {
+		// The body is only needed for the details, the status code is enough when it can't be read
+		e.body, _ = io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		e.apiErrors = parseAPIErrors(e.body)
+	}
+
+	return e
+}
+
+// newEmbeddedErrorResponse is returned when a successful response carries errors in the body instead of the data
+func newEmbeddedErrorResponse(path string, apiErrors []APIError) error {
+	return ErrorResponse{
+		statusCode: http.StatusOK,
+		status:     "200 OK",
+		path:       path,
+		apiErrors:  apiErrors,
+	}
 }
 
 func (e ErrorResponse) Error() string {
-	return e.resp.Status
+	message := e.status
+	if e.path != "" {
+		message = message + " for " + e.path
+	}
+
+	if len(e.apiErrors) > 0 {
+		descriptions := make([]string, 0, len(e.apiErrors))
+		for _, apiError := range e.apiErrors {
+			descriptions = append(descriptions, apiError.String())
+		}
+
+		message = message + ": " + strings.Join(descriptions, "; ")
+	}
+
+	return message
+}
+
+// Is tells which classification the response falls into
+func (e ErrorResponse) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.statusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.statusCode == http.StatusForbidden
+	case ErrRateLimited:
+		return e.statusCode == http.StatusTooManyRequests
+	case ErrServer:
+		// Errors embedded into a successful response are reported by the server as well
+		return e.statusCode >= http.StatusInternalServerError || e.statusCode == http.StatusOK && len(e.apiErrors) > 0
+	default:
+		return false
+	}
+}
+
+func (e ErrorResponse) StatusCode() int {
+	return e.statusCode
+}
+
+// Path is the path of the request, e.g. /media/search
+func (e ErrorResponse) Path() string {
+	return e.path
+}
+
+func (e ErrorResponse) Header() http.Header {
+	return e.header
+}
+
+// Body is the raw response body, it's cut when it's too long
+func (e ErrorResponse) Body() []byte {
+	return e.body
+}
+
+// APIErrors are the errors the API reported in the response body
+func (e ErrorResponse) APIErrors() []APIError {
+	return e.apiErrors
+}
+
+func parseAPIErrors(body []byte) (apiErrors []APIError) {
+	var payload struct {
+		Errors         []APIError `json:"_errors"`
+		PlainErrors    []APIError `json:"errors"`
+		EmbeddedErrors struct {
+			Errors []APIError `json:"errors"`
+		} `json:"_embedded"`
+	}
+
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil
+	}
+
+	apiErrors = append(apiErrors, payload.Errors...)
+	apiErrors = append(apiErrors, payload.PlainErrors...)
+	apiErrors = append(apiErrors, payload.EmbeddedErrors.Errors...)
+
+	if len(apiErrors) == 0 {
+		// Some endpoints report a single error at the top level
+		var apiError APIError
+		if err := json.Unmarshal(body, &apiError); err == nil && apiError.String() != "" {
+			apiErrors = append(apiErrors, apiError)
+		}
+	}
+
+	return apiErrors
+}
+
+// MalformedResponseError is returned when a successful response can't be understood
+type MalformedResponseError struct {
+	err error
+}
+
+func newMalformedResponseError(err error) error {
+	return MalformedResponseError{err: err}
+}
+
+func (e MalformedResponseError) Error() string {
+	return e.err.Error()
+}
+
+func (e MalformedResponseError) Unwrap() error {
+	return e.err
+}
+
+func (e MalformedResponseError) Is(target error) bool {
+	return target == ErrMalformedResponse
 }
@@ -0,0 +1,195 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/client/mocks"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestNewErrorResponse(t *testing.T) {
+	type want struct {
+		message   string
+		apiErrors []client.APIError
+		is        []error
+		isNot     []error
+	}
+
+	classifications := []error{
+		client.ErrUnauthorized,
+		client.ErrForbidden,
+		client.ErrRateLimited,
+		client.ErrServer,
+		client.ErrMalformedResponse,
+	}
+
+	except := func(err error) (others []error) {
+		for _, classification := range classifications {
+			if classification != err {
+				others = append(others, classification)
+			}
+		}
+
+		return others
+	}
+
+	tests := []struct {
+		name       string
+		statusCode int
+		path       string
+		body       string
+		want
+	}{
+		{
+			name:       "unauthorized with the API errors",
+			statusCode: http.StatusUnauthorized,
+			path:       "/media/search",
+			body:       `{"_errors": [{"code": 401, "description": "invalid token"}]}`,
+			want: want{
+				message:   "401 Unauthorized for /media/search: invalid token",
+				apiErrors: []client.APIError{{Code: "401", Description: "invalid token"}},
+				is:        []error{client.ErrUnauthorized},
+				isNot:     except(client.ErrUnauthorized),
+			},
+		},
+		{
+			name:       "forbidden with a string code",
+			statusCode: http.StatusForbidden,
+			body:       `{"errors": [{"code": "forbidden", "reason": "no access"}]}`,
+			want: want{
+				message:   "403 Forbidden: no access",
+				apiErrors: []client.APIError{{Code: "forbidden", Reason: "no access"}},
+				is:        []error{client.ErrForbidden},
+				isNot:     except(client.ErrForbidden),
+			},
+		},
+		{
+			name:       "rate limited with a top level message",
+			statusCode: http.StatusTooManyRequests,
+			body:       `{"message": "slow down"}`,
+			want: want{
+				message:   "429 Too Many Requests: slow down",
+				apiErrors: []client.APIError{{Description: "slow down"}},
+				is:        []error{client.ErrRateLimited},
+				isNot:     except(client.ErrRateLimited),
+			},
+		},
+		{
+			name:       "server error without JSON body",
+			statusCode: http.StatusBadGateway,
+			body:       `<html>bad gateway</html>`,
+			want: want{
+				message: "502 Bad Gateway",
+				is:      []error{client.ErrServer},
+				isNot:   except(client.ErrServer),
+			},
+		},
+		{
+			name:       "not found is not classified",
+			statusCode: http.StatusNotFound,
+			body:       `{}`,
+			want: want{
+				message: "404 Not Found",
+				isNot:   classifications,
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			resp := &http.Response{
+				StatusCode: tt.statusCode,
+				Body:       io.NopCloser(bytes.NewBufferString(tt.body)),
+			}
+			if tt.path != "" {
+				resp.Request = &http.Request{URL: &url.URL{Path: tt.path}}
+			}
+
+			err := errors.Wrap(client.NewErrorResponse(resp), "error getting data from client")
+
+			errorResponse := client.ErrorResponse{}
+			assert.True(t, errors.As(err, &errorResponse))
+			assert.Equal(t, tt.want.message, errorResponse.Error())
+			assert.Equal(t, tt.statusCode, errorResponse.StatusCode())
+			assert.Equal(t, tt.path, errorResponse.Path())
+			assert.Equal(t, tt.body, string(errorResponse.Body()))
+			assert.Equal(t, tt.want.apiErrors, errorResponse.APIErrors())
+
+			for _, classification := range tt.want.is {
+				assert.ErrorIs(t, err, classification)
+			}
+			for _, classification := range tt.want.isNot {
+				assert.NotErrorIs(t, err, classification)
+			}
+		})
+	}
+}
+
+func TestClient_GetPage_Errors(t *testing.T) {
+	type want struct {
+		err            string
+		classification error
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want
+	}{
+		{
+			name: "errors embedded into the search result",
+			body: `{"_pages": {"current_page": 1}, "_embedded": {"errors": [{"code": 500, "description": "search is unavailable"}], "media": []}}`,
+			want: want{
+				err:            "error getting page: error getting page with retry: 200 OK for /media/search: search is unavailable",
+				classification: client.ErrServer,
+			},
+		},
+		{
+			name: "malformed response",
+			body: `{"error": "message"}`,
+			want: want{
+				err:            `error getting page: error getting page with retry: unexpected response: {"error": "message"}`,
+				classification: client.ErrMalformedResponse,
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+			httpClientMock.EXPECT().Do(gomock.Any()).Times(1).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewBufferString(tt.body)),
+				}, nil
+			})
+
+			c, err := client.NewClient("token", client.WithHTTPClient(httpClientMock))
+			assert.NoError(t, err)
+
+			_, err = c.GetPage(context.Background(), 1, 1)
+			assert.EqualError(t, err, tt.want.err)
+			assert.ErrorIs(t, err, tt.want.classification)
+		})
+	}
+}
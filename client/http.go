@@ -1,6 +1,7 @@
 package client
 
 import (
+	"context"
 	"io"
 	"net/http"
 )
@@ -11,6 +12,6 @@ func NewHTTPWrapper() HTTPWrapper {
 	return HTTPWrapper{}
 }
 
-func (h HTTPWrapper) NewRequest(method, url string, body io.Reader) (*http.Request, error) {
-	return http.NewRequest(method, url, body)
+func (h HTTPWrapper) NewRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, method, url, body)
 }
@@ -1,6 +1,7 @@
 package client_test
 
 import (
+	"context"
 	"github.com/legosx/gopro-media-library-verifier/client"
 	"github.com/stretchr/testify/assert"
 	"io"
@@ -40,7 +41,7 @@ func TestHttpWrapper_NewRequest(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			got, err := client.NewHTTPWrapper().NewRequest(tt.args.method, tt.args.url, tt.args.body)
+			got, err := client.NewHTTPWrapper().NewRequest(context.Background(), tt.args.method, tt.args.url, tt.args.body)
 			if tt.want.err == nil {
 				assert.NotNil(t, got)
 				assert.NoError(t, err)
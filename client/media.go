@@ -1,15 +1,105 @@
 package client
 
+import "time"
+
 type Media struct {
-	fileName string
-	fileSize int64
+	id              string
+	fileName        string
+	fileSize        int64
+	capturedAt      time.Time
+	processingState string
+	mediaType       string
+	cameraModel     string
+	createdAt       time.Time
+	contentTitle    string
+	sourceDuration  time.Duration
+	width           int
+	height          int
+	goproUserID     string
 }
 
-func NewMedia(fileName string, fileSize int64) Media {
-	return Media{
+func NewMedia(fileName string, fileSize int64, opts ...func(media *Media)) Media {
+	m := Media{
 		fileName: fileName,
 		fileSize: fileSize,
 	}
+
+	for _, opt := range opts {
+		opt(&m)
+	}
+
+	return m
+}
+
+func WithMediaID(id string) func(m *Media) {
+	return func(m *Media) {
+		m.id = id
+	}
+}
+
+func WithMediaCapturedAt(capturedAt time.Time) func(m *Media) {
+	return func(m *Media) {
+		m.capturedAt = capturedAt
+	}
+}
+
+// WithMediaProcessingState sets the processing state reported by Gopro Media Library, e.g. ready or transcoding
+func WithMediaProcessingState(processingState string) func(m *Media) {
+	return func(m *Media) {
+		m.processingState = processingState
+	}
+}
+
+// WithMediaType sets the type of the media, e.g. Video, Photo or TimeLapse
+func WithMediaType(mediaType string) func(m *Media) {
+	return func(m *Media) {
+		m.mediaType = mediaType
+	}
+}
+
+func WithMediaCameraModel(cameraModel string) func(m *Media) {
+	return func(m *Media) {
+		m.cameraModel = cameraModel
+	}
+}
+
+// WithMediaCreatedAt sets when the media was added to Gopro Media Library
+func WithMediaCreatedAt(createdAt time.Time) func(m *Media) {
+	return func(m *Media) {
+		m.createdAt = createdAt
+	}
+}
+
+func WithMediaContentTitle(contentTitle string) func(m *Media) {
+	return func(m *Media) {
+		m.contentTitle = contentTitle
+	}
+}
+
+// WithMediaSourceDuration sets the duration of a video, it's zero for photos
+func WithMediaSourceDuration(sourceDuration time.Duration) func(m *Media) {
+	return func(m *Media) {
+		m.sourceDuration = sourceDuration
+	}
+}
+
+// WithMediaDimensions sets the width and the height of the media in pixels
+func WithMediaDimensions(width, height int) func(m *Media) {
+	return func(m *Media) {
+		m.width = width
+		m.height = height
+	}
+}
+
+// WithMediaGoProUserID sets the GoPro account the media belongs to
+func WithMediaGoProUserID(goproUserID string) func(m *Media) {
+	return func(m *Media) {
+		m.goproUserID = goproUserID
+	}
+}
+
+func (m Media) ID() string {
+	return m.id
 }
 
 func (m Media) FileName() string {
@@ -19,3 +109,43 @@ func (m Media) FileName() string {
 func (m Media) FileSize() int64 {
 	return m.fileSize
 }
+
+func (m Media) CapturedAt() time.Time {
+	return m.capturedAt
+}
+
+func (m Media) ProcessingState() string {
+	return m.processingState
+}
+
+func (m Media) Type() string {
+	return m.mediaType
+}
+
+func (m Media) CameraModel() string {
+	return m.cameraModel
+}
+
+func (m Media) CreatedAt() time.Time {
+	return m.createdAt
+}
+
+func (m Media) ContentTitle() string {
+	return m.contentTitle
+}
+
+func (m Media) SourceDuration() time.Duration {
+	return m.sourceDuration
+}
+
+func (m Media) Width() int {
+	return m.width
+}
+
+func (m Media) Height() int {
+	return m.height
+}
+
+func (m Media) GoProUserID() string {
+	return m.goproUserID
+}
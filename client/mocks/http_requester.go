@@ -10,6 +10,7 @@
 package mocks
 
 import (
+	context "context"
 	io "io"
 	http "net/http"
 	reflect "reflect"
@@ -41,16 +42,16 @@ func (m *MockHTTPRequester) EXPECT() *MockHTTPRequesterMockRecorder {
 }
 
 // NewRequest mocks base method.
-func (m *MockHTTPRequester) NewRequest(arg0, arg1 string, arg2 io.Reader) (*http.Request, error) {
+func (m *MockHTTPRequester) NewRequest(arg0 context.Context, arg1, arg2 string, arg3 io.Reader) (*http.Request, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "NewRequest", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "NewRequest", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].(*http.Request)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // NewRequest indicates an expected call of NewRequest.
-func (mr *MockHTTPRequesterMockRecorder) NewRequest(arg0, arg1, arg2 any) *gomock.Call {
+func (mr *MockHTTPRequesterMockRecorder) NewRequest(arg0, arg1, arg2, arg3 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewRequest", reflect.TypeOf((*MockHTTPRequester)(nil).NewRequest), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewRequest", reflect.TypeOf((*MockHTTPRequester)(nil).NewRequest), arg0, arg1, arg2, arg3)
 }
@@ -2,20 +2,40 @@ package client
 
 type Page struct {
 	totalPages int
+	totalItems int
 	medias     []Media
 }
 
-func NewPage(totalPages int, medias []Media) Page {
-	return Page{
+func NewPage(totalPages int, medias []Media, opts ...func(page *Page)) Page {
+	p := Page{
 		totalPages: totalPages,
+		totalItems: -1,
 		medias:     medias,
 	}
+
+	for _, opt := range opts {
+		opt(&p)
+	}
+
+	return p
+}
+
+// WithPageTotalItems sets the number of medias in the whole library as reported by the API
+func WithPageTotalItems(totalItems int) func(p *Page) {
+	return func(p *Page) {
+		p.totalItems = totalItems
+	}
 }
 
 func (p Page) TotalPages() int {
 	return p.totalPages
 }
 
+// TotalItems is the number of medias in the whole library, it's -1 when unknown
+func (p Page) TotalItems() int {
+	return p.totalItems
+}
+
 func (p Page) Medias() []Media {
 	return p.medias
 }
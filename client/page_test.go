@@ -10,11 +10,13 @@ func TestPage(t *testing.T) {
 	type fields struct {
 		totalPages int
 		medias     []client.Media
+		opts       []func(page *client.Page)
 	}
 
 	type want struct {
 		page       client.Page
 		totalPages int
+		totalItems int
 		medias     []client.Media
 	}
 
@@ -32,6 +34,21 @@ func TestPage(t *testing.T) {
 			want: want{
 				page:       client.NewPage(1, []client.Media{client.NewMedia("file1.mp4", 10)}),
 				totalPages: 1,
+				totalItems: -1,
+				medias:     []client.Media{client.NewMedia("file1.mp4", 10)},
+			},
+		},
+		{
+			name: "happy path, with total items",
+			fields: fields{
+				totalPages: 2,
+				medias:     []client.Media{client.NewMedia("file1.mp4", 10)},
+				opts:       []func(page *client.Page){client.WithPageTotalItems(2)},
+			},
+			want: want{
+				page:       client.NewPage(2, []client.Media{client.NewMedia("file1.mp4", 10)}, client.WithPageTotalItems(2)),
+				totalPages: 2,
+				totalItems: 2,
 				medias:     []client.Media{client.NewMedia("file1.mp4", 10)},
 			},
 		},
@@ -44,9 +61,10 @@ func TestPage(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			got := client.NewPage(tt.fields.totalPages, tt.fields.medias)
+			got := client.NewPage(tt.fields.totalPages, tt.fields.medias, tt.fields.opts...)
 			assert.Equal(t, tt.want.page, got)
 			assert.Equal(t, tt.want.totalPages, got.TotalPages())
+			assert.Equal(t, tt.want.totalItems, got.TotalItems())
 			assert.Equal(t, tt.want.medias, got.Medias())
 		})
 	}
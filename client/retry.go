@@ -0,0 +1,181 @@
+package client
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy tells how the API requests failed with a transient error are repeated.
+// Rate limits, server errors and network errors are transient, authentication errors are not.
+type RetryPolicy struct {
+	// MaxAttempts is how many times a request is sent at most, including the first attempt
+	MaxAttempts int
+	// InitialBackoff is the wait before the second attempt, it doubles with every next attempt
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between two attempts
+	MaxBackoff time.Duration
+	// Budget caps the total time spent on a request with its retries, zero means no limit
+	Budget time.Duration
+}
+
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     30 * time.Second,
+		Budget:         2 * time.Minute,
+	}
+}
+
+// WithRetryPolicy replaces the default retry policy, MaxAttempts of 1 turns the retries off
+func WithRetryPolicy(policy RetryPolicy) func(c *Client) error {
+	return func(c *Client) error {
+		if policy.MaxAttempts < 1 {
+			return errors.Errorf("invalid max attempts: %d", policy.MaxAttempts)
+		}
+
+		if policy.InitialBackoff < 0 || policy.MaxBackoff < 0 || policy.Budget < 0 {
+			return errors.New("invalid retry policy: durations can't be negative")
+		}
+
+		c.retryPolicy = policy
+
+		return nil
+	}
+}
+
+// WithSleep replaces the way the client waits between attempts, it should return early when the context is done
+func WithSleep(sleep func(ctx context.Context, d time.Duration) error) func(c *Client) error {
+	return func(c *Client) error {
+		c.sleep = sleep
+
+		return nil
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// withRetry calls the attempt until it succeeds, fails with a permanent error or the policy gives up
+func (c Client) withRetry(ctx context.Context, method string, attempt func() ([]byte, error)) (body []byte, err error) {
+	startedAt := time.Now()
+
+	for attemptNumber := 1; ; attemptNumber++ {
+		if body, err = attempt(); err == nil {
+			return body, nil
+		}
+
+		if ctx.Err() != nil || !isRetryable(method, err) {
+			return nil, err
+		}
+
+		if attemptNumber >= c.retryPolicy.MaxAttempts {
+			if attemptNumber == 1 {
+				return nil, err
+			}
+
+			return nil, errors.Wrapf(err, "giving up after %d attempts", attemptNumber)
+		}
+
+		wait := c.retryPolicy.backoff(attemptNumber)
+		if retryAfter, ok := getRetryAfter(err, time.Now()); ok {
+			wait = retryAfter
+		}
+
+		if budget := c.retryPolicy.Budget; budget > 0 && time.Since(startedAt)+wait > budget {
+			return nil, errors.Wrapf(err, "giving up, retry budget of %s exceeded", budget)
+		}
+
+		if sleepErr := c.sleep(ctx, wait); sleepErr != nil {
+			return nil, errors.Wrap(multierr.Append(err, sleepErr), "retry stopped")
+		}
+	}
+}
+
+// backoff returns the exponential wait before the next attempt with a random jitter,
+// so the concurrent page requests don't come back all at once
+func (p RetryPolicy) backoff(attemptNumber int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attemptNumber && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+
+	if backoff <= 0 {
+		return 0
+	}
+
+	half := backoff / 2
+
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+func isRetryable(method string, err error) bool {
+	switch {
+	case errors.Is(err, ErrRateLimited):
+		// The request was rejected before it was handled, so it's safe to send again
+		return true
+	case errors.Is(err, ErrServer):
+		return isIdempotent(method)
+	case errors.Is(err, ErrUnauthorized), errors.Is(err, ErrForbidden), errors.Is(err, ErrMalformedResponse):
+		return false
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return isIdempotent(method)
+	}
+
+	return false
+}
+
+// isIdempotent tells whether the request can be repeated after it might have been handled.
+// Repeating a POST could create the media twice.
+func isIdempotent(method string) bool {
+	return method != http.MethodPost
+}
+
+// getRetryAfter reads the Retry-After header, either in seconds or as an HTTP date
+func getRetryAfter(err error, now time.Time) (retryAfter time.Duration, ok bool) {
+	var errorResponse ErrorResponse
+	if !errors.As(err, &errorResponse) || errorResponse.Header() == nil {
+		return 0, false
+	}
+
+	value := errorResponse.Header().Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, parseErr := strconv.Atoi(value); parseErr == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, parseErr := http.ParseTime(value); parseErr == nil {
+		if retryAfter = date.Sub(now); retryAfter < 0 {
+			retryAfter = 0
+		}
+
+		return retryAfter, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,314 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/client/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClient_Retry(t *testing.T) {
+	type fields struct {
+		responses []func() (*http.Response, error)
+		policy    client.RetryPolicy
+		sleepErr  error
+	}
+
+	type want struct {
+		attempts int
+		waits    []time.Duration
+		err      string
+	}
+
+	response := func(statusCode int, header http.Header) func() (*http.Response, error) {
+		return func() (*http.Response, error) {
+			return &http.Response{
+				StatusCode: statusCode,
+				Status:     http.StatusText(statusCode),
+				Header:     header,
+				Body:       io.NopCloser(bytes.NewBufferString(`{}`)),
+			}, nil
+		}
+	}
+
+	networkError := func() (*http.Response, error) {
+		return nil, &net.OpError{Op: "read", Net: "tcp", Err: assert.AnError}
+	}
+
+	policy := client.RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Budget:         time.Minute,
+	}
+
+	tests := []struct {
+		name string
+		fields
+		want
+	}{
+		{
+			name: "happy path, no retry needed",
+			fields: fields{
+				responses: []func() (*http.Response, error){response(http.StatusOK, nil)},
+				policy:    policy,
+			},
+			want: want{
+				attempts: 1,
+				waits:    []time.Duration{},
+			},
+		},
+		{
+			name: "happy path, server error is retried",
+			fields: fields{
+				responses: []func() (*http.Response, error){
+					response(http.StatusServiceUnavailable, nil),
+					response(http.StatusBadGateway, nil),
+					response(http.StatusOK, nil),
+				},
+				policy: policy,
+			},
+			want: want{
+				attempts: 3,
+				waits:    []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+			},
+		},
+		{
+			name: "happy path, network error is retried",
+			fields: fields{
+				responses: []func() (*http.Response, error){networkError, response(http.StatusOK, nil)},
+				policy:    policy,
+			},
+			want: want{
+				attempts: 2,
+				waits:    []time.Duration{100 * time.Millisecond},
+			},
+		},
+		{
+			name: "happy path, Retry-After in seconds is honoured",
+			fields: fields{
+				responses: []func() (*http.Response, error){
+					response(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"7"}}),
+					response(http.StatusOK, nil),
+				},
+				policy: policy,
+			},
+			want: want{
+				attempts: 2,
+				waits:    []time.Duration{7 * time.Second},
+			},
+		},
+		{
+			name: "happy path, Retry-After in the past means no wait",
+			fields: fields{
+				responses: []func() (*http.Response, error){
+					response(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"Mon, 02 Jan 2006 15:04:05 GMT"}}),
+					response(http.StatusOK, nil),
+				},
+				policy: policy,
+			},
+			want: want{
+				attempts: 2,
+				waits:    []time.Duration{0},
+			},
+		},
+		{
+			name: "sad path, max attempts reached",
+			fields: fields{
+				responses: []func() (*http.Response, error){
+					response(http.StatusInternalServerError, nil),
+					response(http.StatusInternalServerError, nil),
+					response(http.StatusInternalServerError, nil),
+				},
+				policy: policy,
+			},
+			want: want{
+				attempts: 3,
+				waits:    []time.Duration{100 * time.Millisecond, 200 * time.Millisecond},
+				err:      "error checking authentication: giving up after 3 attempts: Internal Server Error",
+			},
+		},
+		{
+			name: "sad path, Retry-After exceeds the budget",
+			fields: fields{
+				responses: []func() (*http.Response, error){
+					response(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"120"}}),
+				},
+				policy: policy,
+			},
+			want: want{
+				attempts: 1,
+				waits:    []time.Duration{},
+				err:      "error checking authentication: giving up, retry budget of 1m0s exceeded: Too Many Requests",
+			},
+		},
+		{
+			name: "sad path, unauthorized fails fast",
+			fields: fields{
+				responses: []func() (*http.Response, error){response(http.StatusUnauthorized, nil)},
+				policy:    policy,
+			},
+			want: want{
+				attempts: 1,
+				waits:    []time.Duration{},
+				err:      "error checking authentication: Unauthorized",
+			},
+		},
+		{
+			name: "sad path, forbidden fails fast",
+			fields: fields{
+				responses: []func() (*http.Response, error){response(http.StatusForbidden, nil)},
+				policy:    policy,
+			},
+			want: want{
+				attempts: 1,
+				waits:    []time.Duration{},
+				err:      "error checking authentication: Forbidden",
+			},
+		},
+		{
+			name: "sad path, retries are off",
+			fields: fields{
+				responses: []func() (*http.Response, error){response(http.StatusServiceUnavailable, nil)},
+				policy:    client.RetryPolicy{MaxAttempts: 1},
+			},
+			want: want{
+				attempts: 1,
+				waits:    []time.Duration{},
+				err:      "error checking authentication: Service Unavailable",
+			},
+		},
+		{
+			name: "sad path, wait is interrupted",
+			fields: fields{
+				responses: []func() (*http.Response, error){response(http.StatusServiceUnavailable, nil)},
+				policy:    policy,
+				sleepErr:  context.Canceled,
+			},
+			want: want{
+				attempts: 1,
+				waits:    []time.Duration{100 * time.Millisecond},
+				err:      "error checking authentication: retry stopped: Service Unavailable; context canceled",
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			attempts := 0
+			httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+			httpClientMock.EXPECT().Do(gomock.Any()).Times(tt.want.attempts).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+				attempts++
+
+				return tt.fields.responses[attempts-1]()
+			})
+
+			waits := []time.Duration{}
+			sleep := func(ctx context.Context, d time.Duration) error {
+				waits = append(waits, d)
+
+				return tt.fields.sleepErr
+			}
+
+			c, err := client.NewClient("token",
+				client.WithHTTPClient(httpClientMock),
+				client.WithRetryPolicy(tt.fields.policy),
+				client.WithSleep(sleep),
+			)
+			assert.NoError(t, err)
+
+			err = c.AuthCheck(context.Background())
+			if tt.want.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err)
+			}
+
+			assert.Len(t, waits, len(tt.want.waits))
+			for i, wait := range tt.want.waits {
+				// The jitter keeps the wait between the half and the full backoff
+				assert.LessOrEqual(t, waits[i], wait)
+				assert.GreaterOrEqual(t, waits[i], wait/2)
+			}
+		})
+	}
+}
+
+func TestClient_Retry_NotIdempotent(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+	httpClientMock.EXPECT().Do(gomock.Any()).Times(1).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+		assert.Equal(t, http.MethodPost, req.Method)
+
+		return &http.Response{
+			StatusCode: http.StatusInternalServerError,
+			Status:     "500 Internal Server Error",
+			Body:       io.NopCloser(bytes.NewBufferString(`{}`)),
+		}, nil
+	})
+
+	c, err := client.NewClient("token", client.WithHTTPClient(httpClientMock))
+	assert.NoError(t, err)
+
+	// The media might have been created already, so the POST isn't sent again
+	_, err = c.CreateUpload(context.Background(), "file.mp4", 10)
+	assert.EqualError(t, err, "error creating media: 500 Internal Server Error")
+}
+
+func TestWithRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy client.RetryPolicy
+		err    string
+	}{
+		{
+			name:   "happy path",
+			policy: client.DefaultRetryPolicy(),
+		},
+		{
+			name:   "sad path, no attempts",
+			policy: client.RetryPolicy{},
+			err:    "invalid max attempts: 0",
+		},
+		{
+			name:   "sad path, negative budget",
+			policy: client.RetryPolicy{MaxAttempts: 1, Budget: -time.Second},
+			err:    "invalid retry policy: durations can't be negative",
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := client.NewClient("token", client.WithRetryPolicy(tt.policy))
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
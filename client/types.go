@@ -1,5 +1,12 @@
 package client
 
+import (
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
 type page struct {
 	Pages    pages    `json:"_pages"`
 	Embedded embedded `json:"_embedded"`
@@ -13,11 +20,93 @@ type pages struct {
 }
 
 type embedded struct {
-	Errors []interface{} `json:"errors"`
-	Media  []media       `json:"media"`
+	Errors []APIError `json:"errors"`
+	Media  []media    `json:"media"`
 }
 
 type media struct {
-	FileName string `json:"filename"`
-	FileSize int64  `json:"file_size"`
+	ID             string         `json:"id"`
+	FileName       string         `json:"filename"`
+	FileSize       int64          `json:"file_size"`
+	CapturedAt     time.Time      `json:"captured_at"`
+	ReadyToView    string         `json:"ready_to_view"`
+	Type           string         `json:"type"`
+	CameraModel    string         `json:"camera_model"`
+	CreatedAt      time.Time      `json:"created_at"`
+	ContentTitle   string         `json:"content_title"`
+	SourceDuration sourceDuration `json:"source_duration"`
+	Width          int            `json:"width"`
+	Height         int            `json:"height"`
+	GoProUserID    string         `json:"gopro_user_id"`
+}
+
+// sourceDuration is the duration of a video in milliseconds, the API sends it either as a number or as a string
+type sourceDuration time.Duration
+
+func (d *sourceDuration) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	if value == "" || value == "null" {
+		*d = 0
+
+		return nil
+	}
+
+	milliseconds, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return errors.Wrapf(err, "invalid source duration: %s", data)
+	}
+
+	*d = sourceDuration(time.Duration(milliseconds * float64(time.Millisecond)))
+
+	return nil
+}
+
+func (m media) toMedia() Media {
+	return NewMedia(
+		m.FileName,
+		m.FileSize,
+		WithMediaID(m.ID),
+		WithMediaCapturedAt(m.CapturedAt),
+		WithMediaProcessingState(m.ReadyToView),
+		WithMediaType(m.Type),
+		WithMediaCameraModel(m.CameraModel),
+		WithMediaCreatedAt(m.CreatedAt),
+		WithMediaContentTitle(m.ContentTitle),
+		WithMediaSourceDuration(time.Duration(m.SourceDuration)),
+		WithMediaDimensions(m.Width, m.Height),
+		WithMediaGoProUserID(m.GoProUserID),
+	)
+}
+
+type mediaDownload struct {
+	FileName string                `json:"filename"`
+	Embedded mediaDownloadEmbedded `json:"_embedded"`
+}
+
+type mediaDownloadEmbedded struct {
+	Files []mediaDownloadFile `json:"files"`
+}
+
+type mediaDownloadFile struct {
+	URL            string `json:"url"`
+	CameraPosition string `json:"camera_position"`
+	ItemNumber     int    `json:"item_number"`
+	Available      bool   `json:"available"`
+}
+
+type createdResource struct {
+	ID string `json:"id"`
+}
+
+type uploadParts struct {
+	Embedded uploadPartsEmbedded `json:"_embedded"`
+}
+
+type uploadPartsEmbedded struct {
+	Authorizations []uploadPartAuthorization `json:"authorizations"`
+}
+
+type uploadPartAuthorization struct {
+	Part int    `json:"part"`
+	URL  string `json:"url"`
 }
@@ -0,0 +1,221 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	uploadCameraPosition = "default"
+	uploadItemNumber     = 1
+)
+
+type Upload struct {
+	mediaID      string
+	derivativeID string
+	uploadID     string
+}
+
+func NewUpload(mediaID, derivativeID, uploadID string) Upload {
+	return Upload{
+		mediaID:      mediaID,
+		derivativeID: derivativeID,
+		uploadID:     uploadID,
+	}
+}
+
+func (u Upload) MediaID() string {
+	return u.mediaID
+}
+
+func (u Upload) DerivativeID() string {
+	return u.derivativeID
+}
+
+func (u Upload) UploadID() string {
+	return u.uploadID
+}
+
+type UploadPart struct {
+	number int
+	url    string
+}
+
+func NewUploadPart(number int, url string) UploadPart {
+	return UploadPart{
+		number: number,
+		url:    url,
+	}
+}
+
+func (p UploadPart) Number() int {
+	return p.number
+}
+
+func (p UploadPart) URL() string {
+	return p.url
+}
+
+// CreateUpload registers a new media item and opens an upload session for its source file.
+// It follows the same steps as the web media library: create media, create source derivative, create user upload.
+func (c Client) CreateUpload(ctx context.Context, fileName string, fileSize int64) (upload Upload, err error) {
+	extension := strings.ToUpper(strings.TrimPrefix(filepath.Ext(fileName), "."))
+	mediaType := c.getUploadMediaType(extension)
+
+	media, err := c.create(ctx, path_media, map[string]interface{}{
+		"filename":          fileName,
+		"file_extension":    extension,
+		"type":              mediaType,
+		"on_public_profile": false,
+		"content_source":    "web_media_library",
+	})
+	if err != nil {
+		return Upload{}, errors.Wrap(err, "error creating media")
+	}
+
+	derivative, err := c.create(ctx, path_derivatives, map[string]interface{}{
+		"medium_id":         media.ID,
+		"file_extension":    extension,
+		"type":              "Source" + mediaType,
+		"label":             "Source",
+		"available":         false,
+		"item_count":        1,
+		"camera_positions":  uploadCameraPosition,
+		"on_public_profile": false,
+	})
+	if err != nil {
+		return Upload{}, errors.Wrap(err, "error creating derivative")
+	}
+
+	userUpload, err := c.create(ctx, path_user_uploads, map[string]interface{}{
+		"derivative_id":   derivative.ID,
+		"camera_position": uploadCameraPosition,
+		"item_number":     uploadItemNumber,
+		"file_size":       fileSize,
+	})
+	if err != nil {
+		return Upload{}, errors.Wrap(err, "error creating user upload")
+	}
+
+	return NewUpload(media.ID, derivative.ID, userUpload.ID), nil
+}
+
+// GetUploadParts requests pre-signed URLs for every part of the file.
+// The URLs expire, so they should be requested again when an upload is resumed.
+func (c Client) GetUploadParts(ctx context.Context, upload Upload, fileSize, partSize int64) (parts []UploadPart, err error) {
+	if partSize <= 0 {
+		return []UploadPart{}, errors.Errorf("invalid part size: %d", partSize)
+	}
+
+	partCount := (fileSize + partSize - 1) / partSize
+	if partCount == 0 {
+		partCount = 1
+	}
+
+	body, err := c.get(ctx, path_user_uploads+"/"+upload.DerivativeID(), map[string]string{
+		"id":              upload.UploadID(),
+		"item_number":     strconv.Itoa(uploadItemNumber),
+		"camera_position": uploadCameraPosition,
+		"file_size":       strconv.FormatInt(fileSize, 10),
+		"part_size":       strconv.FormatInt(partSize, 10),
+		"page":            "1",
+		"per_page":        strconv.FormatInt(partCount, 10),
+	})
+	if err != nil {
+		return []UploadPart{}, errors.Wrap(err, "error getting upload parts")
+	}
+
+	var response uploadParts
+	if err = json.Unmarshal(body, &response); err != nil {
+		return []UploadPart{}, newMalformedResponseError(errors.Wrapf(err, "error decoding JSON: %+v, json: %s", err, string(body)))
+	}
+
+	if int64(len(response.Embedded.Authorizations)) != partCount {
+		return []UploadPart{}, errors.Errorf(
+			"unexpected number of upload parts: expected %d, got %d",
+			partCount, len(response.Embedded.Authorizations),
+		)
+	}
+
+	parts = []UploadPart{}
+	for _, authorization := range response.Embedded.Authorizations {
+		parts = append(parts, NewUploadPart(authorization.Part, authorization.URL))
+	}
+
+	return parts, nil
+}
+
+// UploadPart sends a single chunk of the file to its pre-signed URL.
+func (c Client) UploadPart(ctx context.Context, part UploadPart, body io.Reader, size int64) (err error) {
+	req, err := c.httpRequester.NewRequest(ctx, http.MethodPut, part.URL(), body)
+	if err != nil {
+		return errors.Wrap(err, "error creating HTTP request")
+	}
+	req.ContentLength = size
+
+	if _, err = c.perform(req); err != nil {
+		return errors.Wrapf(err, "error uploading part %d", part.Number())
+	}
+
+	return nil
+}
+
+// CompleteUpload marks the upload session as complete and makes the media available in the library.
+func (c Client) CompleteUpload(ctx context.Context, upload Upload, fileSize int64) (err error) {
+	if _, err = c.send(ctx, http.MethodPut, path_user_uploads+"/"+upload.DerivativeID(), map[string]interface{}{
+		"id":              upload.UploadID(),
+		"item_number":     uploadItemNumber,
+		"camera_position": uploadCameraPosition,
+		"file_size":       fileSize,
+		"complete":        true,
+	}); err != nil {
+		return errors.Wrap(err, "error completing user upload")
+	}
+
+	if _, err = c.send(ctx, http.MethodPut, path_derivatives+"/"+upload.DerivativeID(), map[string]interface{}{
+		"available": true,
+	}); err != nil {
+		return errors.Wrap(err, "error completing derivative")
+	}
+
+	if _, err = c.send(ctx, http.MethodPut, path_media+"/"+upload.MediaID(), map[string]interface{}{
+		"upload_completed_at": time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		return errors.Wrap(err, "error completing media")
+	}
+
+	return nil
+}
+
+func (c Client) create(ctx context.Context, path string, payload map[string]interface{}) (resource createdResource, err error) {
+	body, err := c.send(ctx, http.MethodPost, path, payload)
+	if err != nil {
+		return createdResource{}, err
+	}
+
+	if err = json.Unmarshal(body, &resource); err != nil {
+		return createdResource{}, newMalformedResponseError(errors.Wrapf(err, "error decoding JSON: %+v, json: %s", err, string(body)))
+	}
+
+	if resource.ID == "" {
+		return createdResource{}, newMalformedResponseError(errors.Errorf("unexpected response: %s", string(body)))
+	}
+
+	return resource, nil
+}
+
+func (c Client) getUploadMediaType(extension string) string {
+	switch strings.ToLower(extension) {
+	case "jpg", "jpeg", "heic", "png":
+		return "Photo"
+	default:
+		return "Video"
+	}
+}
@@ -0,0 +1,662 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/client/mocks"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestUpload(t *testing.T) {
+	got := client.NewUpload("media", "derivative", "upload")
+
+	assert.Equal(t, "media", got.MediaID())
+	assert.Equal(t, "derivative", got.DerivativeID())
+	assert.Equal(t, "upload", got.UploadID())
+}
+
+func TestUploadPart(t *testing.T) {
+	got := client.NewUploadPart(2, "https://storage/part2")
+
+	assert.Equal(t, 2, got.Number())
+	assert.Equal(t, "https://storage/part2", got.URL())
+}
+
+func TestClient_CreateUpload(t *testing.T) {
+	type args struct {
+		fileName string
+		fileSize int64
+	}
+
+	type fields struct {
+		opts func(mockCtrl *gomock.Controller) []func(client *client.Client) error
+	}
+
+	type want struct {
+		upload client.Upload
+		err    error
+	}
+
+	tests := []struct {
+		name string
+		fields
+		args
+		want
+	}{
+		{
+			name: "happy path, video",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(3).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "POST", req.Method)
+						assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+						assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+						payload := map[string]interface{}{}
+						assert.NoError(t, json.NewDecoder(req.Body).Decode(&payload))
+
+						var response string
+
+						switch req.URL.String() {
+						case "https://api.gopro.com/media":
+							assert.Equal(t, "GX010001.MP4", payload["filename"])
+							assert.Equal(t, "MP4", payload["file_extension"])
+							assert.Equal(t, "Video", payload["type"])
+							response = `{"id": "media1"}`
+						case "https://api.gopro.com/derivatives":
+							assert.Equal(t, "media1", payload["medium_id"])
+							assert.Equal(t, "SourceVideo", payload["type"])
+							response = `{"id": "derivative1"}`
+						case "https://api.gopro.com/user-uploads":
+							assert.Equal(t, "derivative1", payload["derivative_id"])
+							assert.Equal(t, float64(100), payload["file_size"])
+							response = `{"id": "upload1"}`
+						default:
+							t.Errorf("unexpected URL: %s", req.URL)
+						}
+
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(response)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			args: args{fileName: "GX010001.MP4", fileSize: 100},
+			want: want{
+				upload: client.NewUpload("media1", "derivative1", "upload1"),
+			},
+		},
+		{
+			name: "happy path, photo",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(3).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						payload := map[string]interface{}{}
+						assert.NoError(t, json.NewDecoder(req.Body).Decode(&payload))
+
+						if req.URL.Path == "/media" {
+							assert.Equal(t, "Photo", payload["type"])
+							assert.Equal(t, "JPG", payload["file_extension"])
+						}
+
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(`{"id": "id"}`)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			args: args{fileName: "photo.jpg", fileSize: 100},
+			want: want{
+				upload: client.NewUpload("id", "id", "id"),
+			},
+		},
+		{
+			name: "happy path, resources answered with 201 Created",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(3).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						return &http.Response{
+							StatusCode: http.StatusCreated,
+							Body:       io.NopCloser(bytes.NewBufferString(`{"id": "id"}`)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			args: args{fileName: "GX010001.MP4", fileSize: 100},
+			want: want{
+				upload: client.NewUpload("id", "id", "id"),
+			},
+		},
+		{
+			name: "sad path, error creating media",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{}, assert.AnError)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			args: args{fileName: "GX010001.MP4", fileSize: 100},
+			want: want{
+				err: errors.Wrap(assert.AnError, "error creating media: error performing HTTP request"),
+			},
+		},
+		{
+			name: "sad path, error creating derivative",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(2).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						body := `{"id": "media1"}`
+						if req.URL.Path == "/derivatives" {
+							body = `{invalid}`
+						}
+
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(body)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			args: args{fileName: "GX010001.MP4", fileSize: 100},
+			want: want{
+				err: errors.New("error creating derivative: " +
+					"error decoding JSON: " +
+					"invalid character 'i' looking for beginning of object key string, json:" +
+					" {invalid}: invalid character 'i' looking for beginning of object key string"),
+			},
+		},
+		{
+			name: "sad path, error creating user upload",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(3).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						body := `{"id": "id"}`
+						if req.URL.Path == "/user-uploads" {
+							body = `{}`
+						}
+
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(body)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			args: args{fileName: "GX010001.MP4", fileSize: 100},
+			want: want{
+				err: errors.New("error creating user upload: unexpected response: {}"),
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			c, err := client.NewClient("token", tt.fields.opts(mockCtrl)...)
+			assert.NoError(t, err)
+
+			got, err := c.CreateUpload(context.Background(), tt.args.fileName, tt.args.fileSize)
+			if tt.want.err == nil {
+				assert.Equal(t, tt.want.upload, got)
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err.Error())
+			}
+		})
+	}
+}
+
+func TestClient_GetUploadParts(t *testing.T) {
+	type args struct {
+		fileSize int64
+		partSize int64
+	}
+
+	type fields struct {
+		opts func(mockCtrl *gomock.Controller) []func(client *client.Client) error
+	}
+
+	type want struct {
+		parts []client.UploadPart
+		err   error
+	}
+
+	tests := []struct {
+		name string
+		fields
+		args
+		want
+	}{
+		{
+			name: "happy path",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "GET", req.Method)
+						assert.Equal(t, "/user-uploads/derivative1", req.URL.Path)
+
+						q := req.URL.Query()
+						assert.Equal(t, "upload1", q.Get("id"))
+						assert.Equal(t, "25", q.Get("file_size"))
+						assert.Equal(t, "10", q.Get("part_size"))
+						assert.Equal(t, "3", q.Get("per_page"))
+
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body: io.NopCloser(bytes.NewBufferString(`{"_embedded": {"authorizations": [` +
+								`{"part": 1, "url": "https://storage/1"},` +
+								`{"part": 2, "url": "https://storage/2"},` +
+								`{"part": 3, "url": "https://storage/3"}` +
+								`]}}`)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			args: args{fileSize: 25, partSize: 10},
+			want: want{
+				parts: []client.UploadPart{
+					client.NewUploadPart(1, "https://storage/1"),
+					client.NewUploadPart(2, "https://storage/2"),
+					client.NewUploadPart(3, "https://storage/3"),
+				},
+			},
+		},
+		{
+			name: "sad path, invalid part size",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(mocks.NewMockHTTPClient(mockCtrl)),
+					}
+				},
+			},
+			args: args{fileSize: 25, partSize: 0},
+			want: want{
+				err: errors.New("invalid part size: 0"),
+			},
+		},
+		{
+			name: "sad path, unexpected number of parts",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewBufferString(`{"_embedded": {"authorizations": []}}`)),
+					}, nil)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			args: args{fileSize: 25, partSize: 10},
+			want: want{
+				err: errors.New("unexpected number of upload parts: expected 3, got 0"),
+			},
+		},
+		{
+			name: "sad path, error response",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{
+						StatusCode: http.StatusNotFound,
+						Status:     "404 Not Found",
+					}, nil)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			args: args{fileSize: 25, partSize: 10},
+			want: want{
+				err: errors.New("error getting upload parts: 404 Not Found"),
+			},
+		},
+		{
+			name: "sad path, invalid json",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{
+						StatusCode: http.StatusOK,
+						Body:       io.NopCloser(bytes.NewBufferString(`{invalid}`)),
+					}, nil)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			args: args{fileSize: 25, partSize: 10},
+			want: want{
+				err: errors.New("error decoding JSON: " +
+					"invalid character 'i' looking for beginning of object key string, json:" +
+					" {invalid}: invalid character 'i' looking for beginning of object key string"),
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			c, err := client.NewClient("token", tt.fields.opts(mockCtrl)...)
+			assert.NoError(t, err)
+
+			got, err := c.GetUploadParts(context.Background(), client.NewUpload("media1", "derivative1", "upload1"), tt.args.fileSize, tt.args.partSize)
+			if tt.want.err == nil {
+				assert.Equal(t, tt.want.parts, got)
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err.Error())
+			}
+		})
+	}
+}
+
+func TestClient_UploadPart(t *testing.T) {
+	type fields struct {
+		opts func(mockCtrl *gomock.Controller) []func(client *client.Client) error
+	}
+
+	type want struct {
+		err error
+	}
+
+	tests := []struct {
+		name string
+		fields
+		want
+	}{
+		{
+			name: "happy path",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "PUT", req.Method)
+						assert.Equal(t, "https://storage/2", req.URL.String())
+						assert.Empty(t, req.Header.Get("Authorization"))
+						assert.Equal(t, int64(5), req.ContentLength)
+
+						body, err := io.ReadAll(req.Body)
+						assert.NoError(t, err)
+						assert.Equal(t, "chunk", string(body))
+
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(``)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+		},
+		{
+			name: "sad path, cannot create http request",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpRequesterMock := mocks.NewMockHTTPRequester(mockCtrl)
+					httpRequesterMock.EXPECT().NewRequest(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, assert.AnError)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(mocks.NewMockHTTPClient(mockCtrl)),
+						client.WithHTTPRequester(httpRequesterMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.Wrap(assert.AnError, "error creating HTTP request"),
+			},
+		},
+		{
+			name: "sad path, error response",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{
+						StatusCode: http.StatusForbidden,
+						Status:     "403 Forbidden",
+					}, nil)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.New("error uploading part 2: 403 Forbidden"),
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			c, err := client.NewClient("token", tt.fields.opts(mockCtrl)...)
+			assert.NoError(t, err)
+
+			err = c.UploadPart(context.Background(), client.NewUploadPart(2, "https://storage/2"), strings.NewReader("chunk"), 5)
+			if tt.want.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err.Error())
+			}
+		})
+	}
+}
+
+func TestClient_CompleteUpload(t *testing.T) {
+	type fields struct {
+		opts func(mockCtrl *gomock.Controller) []func(client *client.Client) error
+	}
+
+	type want struct {
+		err error
+	}
+
+	tests := []struct {
+		name string
+		fields
+		want
+	}{
+		{
+			name: "happy path",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					var paths []string
+
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(3).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						assert.Equal(t, "PUT", req.Method)
+
+						payload := map[string]interface{}{}
+						assert.NoError(t, json.NewDecoder(req.Body).Decode(&payload))
+
+						switch req.URL.Path {
+						case "/user-uploads/derivative1":
+							assert.Equal(t, "upload1", payload["id"])
+							assert.Equal(t, true, payload["complete"])
+						case "/derivatives/derivative1":
+							assert.Equal(t, true, payload["available"])
+						case "/media/media1":
+							assert.NotEmpty(t, payload["upload_completed_at"])
+						}
+
+						paths = append(paths, req.URL.Path)
+						if len(paths) == 3 {
+							assert.Equal(t, []string{"/user-uploads/derivative1", "/derivatives/derivative1", "/media/media1"}, paths)
+						}
+
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(`{}`)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+		},
+		{
+			name: "sad path, error completing user upload",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(1).Return(&http.Response{}, assert.AnError)
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.Wrap(assert.AnError, "error completing user upload: error performing HTTP request"),
+			},
+		},
+		{
+			name: "sad path, error completing derivative",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(2).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						if req.URL.Path == "/derivatives/derivative1" {
+							return &http.Response{}, assert.AnError
+						}
+
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(`{}`)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.Wrap(assert.AnError, "error completing derivative: error performing HTTP request"),
+			},
+		},
+		{
+			name: "sad path, error completing media",
+			fields: fields{
+				opts: func(mockCtrl *gomock.Controller) []func(client *client.Client) error {
+					httpClientMock := mocks.NewMockHTTPClient(mockCtrl)
+					httpClientMock.EXPECT().Do(gomock.Any()).Times(3).DoAndReturn(func(req *http.Request) (*http.Response, error) {
+						if req.URL.Path == "/media/media1" {
+							return &http.Response{}, assert.AnError
+						}
+
+						return &http.Response{
+							StatusCode: http.StatusOK,
+							Body:       io.NopCloser(bytes.NewBufferString(`{}`)),
+						}, nil
+					})
+
+					return []func(client *client.Client) error{
+						client.WithHTTPClient(httpClientMock),
+					}
+				},
+			},
+			want: want{
+				err: errors.Wrap(assert.AnError, "error completing media: error performing HTTP request"),
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			c, err := client.NewClient("token", tt.fields.opts(mockCtrl)...)
+			assert.NoError(t, err)
+
+			err = c.CompleteUpload(context.Background(), client.NewUpload("media1", "derivative1", "upload1"), 25)
+			if tt.want.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/downloadrun"
+	"github.com/legosx/gopro-media-library-verifier/verifyrun"
+	"github.com/spf13/cobra"
+	"strconv"
+)
+
+// downloadCmd represents the download command
+var downloadCmd = &cobra.Command{
+	Use:   "download",
+	Short: "Downloads files that exist only in Gopro Media Library",
+	Long: `Download
+
+This command downloads the files from Gopro Media Library that don't exist in the specified local directory.
+Files are matched by name and size the same way as the verify command does.
+Interrupted downloads are resumed when the command is run again.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		concurrency, err := strconv.Atoi(cmd.Flag("concurrency").Value.String())
+		cobra.CheckErr(err)
+
+		runner := downloadrun.NewRunner(
+			cmd.Flag("path").Value.String(),
+			cmd.Flag("outputDirPath").Value.String(),
+			concurrency,
+			verifyrun.TokenPromptMethod(cmd.Flag("tokenPromptMethod").Value.String()),
+		)
+
+		ctx, cancel := newContext(cmd)
+		err = runner.Run(ctx)
+		cancel()
+
+		checkErr(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(downloadCmd)
+
+	cobra.CheckErr(downloadrun.Init(downloadCmd))
+}
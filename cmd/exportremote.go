@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/exportrun"
+	"github.com/legosx/gopro-media-library-verifier/verifyrun"
+	"github.com/spf13/cobra"
+)
+
+// exportRemoteCmd represents the export-remote command
+var exportRemoteCmd = &cobra.Command{
+	Use:   "export-remote",
+	Short: "Exports the listing of Gopro Media Library",
+	Long: `Export remote
+
+This command lists every media of Gopro Media Library with its id, file name, file size, type, capture date,
+processing state and camera model, and writes them as JSON, CSV or NDJSON.
+The JSON export can be used by the verify command with --manifest to verify offline.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runner := exportrun.NewRunner(
+			cmd.Flag("outputFilePath").Value.String(),
+			verifyrun.Format(cmd.Flag("format").Value.String()),
+			verifyrun.TokenPromptMethod(cmd.Flag("tokenPromptMethod").Value.String()),
+		)
+
+		ctx, cancel := newContext(cmd)
+		err := runner.Run(ctx)
+		cancel()
+
+		checkErr(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(exportRemoteCmd)
+
+	cobra.CheckErr(exportrun.Init(exportRemoteCmd))
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/legosx/gopro-media-library-verifier/fakeapi"
+	"github.com/spf13/cobra"
+	"os"
+)
+
+// fakeServerCmd represents the fake-server command
+var fakeServerCmd = &cobra.Command{
+	Use:    "fake-server",
+	Short:  "Serves a fake Gopro Media Library API for testing",
+	Hidden: true,
+	Long: `Fake server
+
+This command serves the media search and notifications endpoints of Gopro Media Library API
+from a JSON fixture or a generated library, so the other commands can be tested end to end with --apiBaseUrl.
+Faults can be injected into a running server with a POST request to ` + fakeapi.PathFaults + `, e.g.
+{"status_code": 429, "times": 2, "retry_after": 1, "path": "/media/search"}
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		library := fakeapi.GenerateLibrary(mustGetInt(cmd, "generate"))
+
+		if fixturePath := cmd.Flag("fixture").Value.String(); fixturePath != "" {
+			var err error
+			library, err = fakeapi.LoadLibrary(fixturePath)
+			cobra.CheckErr(err)
+		}
+
+		server := fakeapi.NewServer(
+			library,
+			fakeapi.WithToken(cmd.Flag("token").Value.String()),
+			fakeapi.WithIncompletePages(mustGetInt(cmd, "incompletePages")),
+		)
+
+		address := cmd.Flag("address").Value.String()
+		fmt.Fprintf(os.Stderr, "Serving %d medias on http://%s\n", len(library.Medias()), address)
+
+		ctx, cancel := newContext(cmd)
+		err := server.ListenAndServe(ctx, address)
+		cancel()
+
+		checkErr(err)
+	},
+}
+
+func mustGetInt(cmd *cobra.Command, name string) int {
+	value, err := cmd.Flags().GetInt(name)
+	cobra.CheckErr(err)
+
+	return value
+}
+
+func init() {
+	rootCmd.AddCommand(fakeServerCmd)
+
+	fakeServerCmd.Flags().String("address", "localhost:8080", "address to listen on")
+	fakeServerCmd.Flags().String("fixture", "", "path to a JSON file with the list of medias to serve")
+	fakeServerCmd.Flags().Int("generate", 100, "number of medias to generate when no fixture is given")
+	fakeServerCmd.Flags().String("token", fakeapi.DefaultToken, "the only bearer token accepted")
+	fakeServerCmd.Flags().Int("incompletePages", 0, "how many times every page is returned with a media missing before the full page")
+}
@@ -1,11 +1,17 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/legosx/gopro-media-library-verifier/verifyrun"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"os"
+	"os/signal"
+	"syscall"
 )
 
 var (
@@ -25,10 +31,55 @@ func Execute() {
 	cobra.CheckErr(rootCmd.Execute())
 }
 
+// newContext returns the context of a command run.
+// It's cancelled on SIGINT or SIGTERM and after the timeout, so the requests and directory walks in flight stop.
+func newContext(cmd *cobra.Command) (ctx context.Context, cancel context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	timeout, err := cmd.Flags().GetDuration("timeout")
+	cobra.CheckErr(err)
+
+	if timeout <= 0 {
+		return ctx, stop
+	}
+
+	var cancelTimeout context.CancelFunc
+	ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+
+	return ctx, func() {
+		cancelTimeout()
+		stop()
+	}
+}
+
+// checkErr finishes the command with the exit code matching the error.
+// Missing files are an expected result rather than a failure, so they aren't printed as an error.
+func checkErr(err error) {
+	if err == nil {
+		return
+	}
+
+	if !errors.Is(err, verifyrun.ErrFilesMissing) {
+		fmt.Fprintln(os.Stderr, "Error:", err)
+	}
+
+	os.Exit(verifyrun.GetExitCode(err))
+}
+
 func init() {
 	cobra.OnInitialize(initConfig)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.gopro-media-library-verifier.json)")
+	rootCmd.PersistentFlags().Duration("timeout", 0, "stop the command after this duration, e.g. 30m (default is no timeout)")
+	rootCmd.PersistentFlags().String("apiBaseUrl", "", "base URL of the Gopro Media Library API (default is https://api.gopro.com/)")
+	rootCmd.PersistentFlags().Int("retryMaxAttempts", client.DefaultRetryPolicy().MaxAttempts, "how many times an API request failed with a transient error is sent at most")
+	rootCmd.PersistentFlags().Duration("retryBudget", client.DefaultRetryPolicy().Budget, "total time an API request can take with its retries, 0 means no limit")
+	rootCmd.PersistentFlags().String("fetchStrategy", string(fetch.StrategyPages), fmt.Sprintf("how Gopro Media Library is listed: %s (page by page) or %s (by captured_at windows, for large libraries)", fetch.StrategyPages, fetch.StrategyWindows))
+
+	cobra.CheckErr(viper.BindPFlag("api.baseUrl", rootCmd.PersistentFlags().Lookup("apiBaseUrl")))
+	cobra.CheckErr(viper.BindPFlag("api.retry.maxAttempts", rootCmd.PersistentFlags().Lookup("retryMaxAttempts")))
+	cobra.CheckErr(viper.BindPFlag("api.retry.budget", rootCmd.PersistentFlags().Lookup("retryBudget")))
+	cobra.CheckErr(viper.BindPFlag("fetch.strategy", rootCmd.PersistentFlags().Lookup("fetchStrategy")))
 }
 
 func initConfig() {
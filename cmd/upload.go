@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/uploadrun"
+	"github.com/legosx/gopro-media-library-verifier/verifyrun"
+	"github.com/spf13/cobra"
+	"strconv"
+)
+
+// uploadCmd represents the upload command
+var uploadCmd = &cobra.Command{
+	Use:   "upload",
+	Short: "Uploads missing files to Gopro Media Library",
+	Long: `Upload
+
+This command goes over the specified local directory recursively and uploads the files that are not yet uploaded to Gopro Media Library.
+Interrupted or failed uploads are resumed from the last uploaded part when the command is run again.
+`,
+	Run: func(cmd *cobra.Command, args []string) {
+		partSize, err := strconv.ParseInt(cmd.Flag("partSize").Value.String(), 10, 64)
+		cobra.CheckErr(err)
+
+		runner := uploadrun.NewRunner(
+			cmd.Flag("path").Value.String(),
+			cmd.Flag("stateFilePath").Value.String(),
+			partSize,
+			verifyrun.TokenPromptMethod(cmd.Flag("tokenPromptMethod").Value.String()),
+		)
+
+		ctx, cancel := newContext(cmd)
+		err = runner.Run(ctx)
+		cancel()
+
+		checkErr(err)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(uploadCmd)
+
+	cobra.CheckErr(uploadrun.Init(uploadCmd))
+}
@@ -1,6 +1,9 @@
 package cmd
 
 import (
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/legosx/gopro-media-library-verifier/indexcache"
+	"github.com/legosx/gopro-media-library-verifier/verify"
 	"github.com/legosx/gopro-media-library-verifier/verifyrun"
 	"github.com/spf13/cobra"
 )
@@ -12,15 +15,65 @@ var verifyCmd = &cobra.Command{
 	Long: `Verify
 
 This command goes over the specified local directory recursively and outputs the files that are not yet uploaded to Gopro Media Library.
+With --direction remote or both it also outputs the files from Gopro Media Library that are missing in the local directory.
+
+Exit codes: 0 all files are in sync, 1 other error, 2 files are missing, 3 authentication failure, 4 API or network failure, 5 local scan failure.
 `,
 	Run: func(cmd *cobra.Command, args []string) {
+		strict, err := cmd.Flags().GetBool("strict")
+		cobra.CheckErr(err)
+
+		refresh, err := cmd.Flags().GetBool("refresh")
+		cobra.CheckErr(err)
+
+		offline, err := cmd.Flags().GetBool("offline")
+		cobra.CheckErr(err)
+
+		safeStates, err := cmd.Flags().GetStringSlice("safeStates")
+		cobra.CheckErr(err)
+
+		safeCategories := make([]fetch.ProcessingCategory, 0, len(safeStates))
+		for _, safeState := range safeStates {
+			safeCategories = append(safeCategories, fetch.ProcessingCategory(safeState))
+		}
+
+		captureTolerance, err := cmd.Flags().GetDuration("captureTolerance")
+		cobra.CheckErr(err)
+
+		matcher, err := verify.ParseMatcher(cmd.Flag("match").Value.String(), verify.WithCaptureTolerance(captureTolerance))
+		checkErr(err)
+
+		cacheMode := indexcache.ModeAuto
+		if refresh {
+			cacheMode = indexcache.ModeRefresh
+		}
+
+		opts := []func(*verifyrun.Runner){
+			verifyrun.WithStrict(strict),
+			verifyrun.WithSafeProcessingCategories(safeCategories...),
+			verifyrun.WithMatcher(matcher),
+			verifyrun.WithCacheMode(cacheMode),
+		}
+
+		// A manifest is only read offline, so it implies --offline
+		if manifestPath := cmd.Flag("manifest").Value.String(); offline || manifestPath != "" {
+			opts = append(opts, verifyrun.WithOffline(manifestPath))
+		}
+
 		runner := verifyrun.NewRunner(
 			cmd.Flag("path").Value.String(),
 			cmd.Flag("outputFilePath").Value.String(),
+			verify.Direction(cmd.Flag("direction").Value.String()),
+			verifyrun.Format(cmd.Flag("format").Value.String()),
 			verifyrun.TokenPromptMethod(cmd.Flag("tokenPromptMethod").Value.String()),
+			opts...,
 		)
 
-		cobra.CheckErr(runner.Run())
+		ctx, cancel := newContext(cmd)
+		err = runner.Run(ctx)
+		cancel()
+
+		checkErr(err)
 	},
 }
 
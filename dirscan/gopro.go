@@ -0,0 +1,139 @@
+package dirscan
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// GoProName is a file name that follows the GoPro convention, e.g. GX020123.MP4 is the second chapter of the recording 0123
+type GoProName struct {
+	// Prefix is GH, GX or GP, GOPR names have the GP prefix as their next chapters do
+	Prefix string
+	// Chapter starts from 1
+	Chapter    int
+	FileNumber string
+	Extension  string
+}
+
+// ParseGoProName tells whether the file name follows the GoPro convention.
+// HERO6 and later name the videos GHccnnnn (AVC) or GXccnnnn (HEVC) with the chapter first,
+// the older cameras name the first chapter GOPRnnnn and the next ones GPccnnnn.
+func ParseGoProName(fileName string) (name GoProName, ok bool) {
+	extension := filepath.Ext(fileName)
+	base := strings.ToUpper(strings.TrimSuffix(fileName, extension))
+
+	if len(base) != 8 || !isDigits(base[4:]) {
+		return GoProName{}, false
+	}
+
+	name = GoProName{FileNumber: base[4:], Extension: strings.ToUpper(extension)}
+
+	if base[:4] == "GOPR" {
+		name.Prefix, name.Chapter = "GP", 1
+
+		return name, true
+	}
+
+	name.Prefix = base[:2]
+	if (name.Prefix != "GH" && name.Prefix != "GX" && name.Prefix != "GP") || !isDigits(base[2:4]) {
+		return GoProName{}, false
+	}
+
+	name.Chapter = int(base[2]-'0')*10 + int(base[3]-'0')
+	if name.Chapter == 0 {
+		return GoProName{}, false
+	}
+
+	// GP01 follows GOPR, so it's the second chapter
+	if name.Prefix == "GP" {
+		name.Chapter++
+	}
+
+	return name, true
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FirstChapterName is the name of the first chapter of the recording, e.g. GX010123.MP4 or GOPR0123.MP4
+func (n GoProName) FirstChapterName() string {
+	if n.Prefix == "GP" {
+		return "GOPR" + n.FileNumber + n.Extension
+	}
+
+	return fmt.Sprintf("%s01%s%s", n.Prefix, n.FileNumber, n.Extension)
+}
+
+// Recording is a long video the camera split into chapters
+type Recording struct {
+	// Name is the name of the first chapter, the cloud names the merged recording after it
+	Name     string
+	Chapters []File
+	// Size is the sum of the sizes of the chapters
+	Size int64
+}
+
+// GroupChapters groups the chapters of the same recording in the same directory.
+// Only the recordings of more than one chapter are returned, ordered by the path of the first chapter.
+func GroupChapters(files []File) (recordings []Recording) {
+	type chapter struct {
+		number int
+		file   File
+	}
+
+	type recordingKey struct {
+		dir  string
+		name GoProName
+	}
+
+	groups := map[recordingKey][]chapter{}
+
+	for _, file := range files {
+		name, ok := ParseGoProName(file.Name)
+		if !ok {
+			continue
+		}
+
+		chapterNumber := name.Chapter
+		name.Chapter = 0
+
+		key := recordingKey{dir: path.Dir(file.Path), name: name}
+		groups[key] = append(groups[key], chapter{number: chapterNumber, file: file})
+	}
+
+	recordings = []Recording{}
+
+	for key, chapters := range groups {
+		if len(chapters) < 2 {
+			continue
+		}
+
+		sort.Slice(chapters, func(i, j int) bool {
+			return chapters[i].number < chapters[j].number
+		})
+
+		recording := Recording{Name: key.name.FirstChapterName()}
+		for _, chapter := range chapters {
+			recording.Chapters = append(recording.Chapters, chapter.file)
+			recording.Size += chapter.file.Size
+		}
+
+		recordings = append(recordings, recording)
+	}
+
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].Chapters[0].Path < recordings[j].Chapters[0].Path
+	})
+
+	return recordings
+}
@@ -0,0 +1,112 @@
+package dirscan_test
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/dirscan"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseGoProName(t *testing.T) {
+	type want struct {
+		name             dirscan.GoProName
+		firstChapterName string
+		ok               bool
+	}
+
+	tests := []struct {
+		name     string
+		fileName string
+		want
+	}{
+		{
+			name:     "happy path, first chapter of a HEVC recording",
+			fileName: "GX010123.MP4",
+			want: want{
+				name:             dirscan.GoProName{Prefix: "GX", Chapter: 1, FileNumber: "0123", Extension: ".MP4"},
+				firstChapterName: "GX010123.MP4",
+				ok:               true,
+			},
+		},
+		{
+			name:     "happy path, second chapter of an AVC recording in lower case",
+			fileName: "gh020123.mp4",
+			want: want{
+				name:             dirscan.GoProName{Prefix: "GH", Chapter: 2, FileNumber: "0123", Extension: ".MP4"},
+				firstChapterName: "GH010123.MP4",
+				ok:               true,
+			},
+		},
+		{
+			name:     "happy path, first chapter of an older camera",
+			fileName: "GOPR0123.MP4",
+			want: want{
+				name:             dirscan.GoProName{Prefix: "GP", Chapter: 1, FileNumber: "0123", Extension: ".MP4"},
+				firstChapterName: "GOPR0123.MP4",
+				ok:               true,
+			},
+		},
+		{
+			name:     "happy path, GP01 is the second chapter of an older camera",
+			fileName: "GP010123.MP4",
+			want: want{
+				name:             dirscan.GoProName{Prefix: "GP", Chapter: 2, FileNumber: "0123", Extension: ".MP4"},
+				firstChapterName: "GOPR0123.MP4",
+				ok:               true,
+			},
+		},
+		{
+			name:     "sad path, not a GoPro name",
+			fileName: "ride.mp4",
+		},
+		{
+			name:     "sad path, chapter 00",
+			fileName: "GX000123.MP4",
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := dirscan.ParseGoProName(tt.fileName)
+			assert.Equal(t, tt.want.ok, ok)
+
+			if tt.want.ok {
+				assert.Equal(t, tt.want.name, got)
+				assert.Equal(t, tt.want.firstChapterName, got.FirstChapterName())
+			}
+		})
+	}
+}
+
+func TestGroupChapters(t *testing.T) {
+	t.Parallel()
+
+	files := []dirscan.File{
+		{Name: "GX020123.MP4", Path: "/a/GX020123.MP4", Size: 20},
+		{Name: "GX010123.MP4", Path: "/a/GX010123.MP4", Size: 10},
+		{Name: "GX030123.MP4", Path: "/a/GX030123.MP4", Size: 30},
+		// The same recording number in another directory is another recording
+		{Name: "GX010123.MP4", Path: "/b/GX010123.MP4", Size: 10},
+		{Name: "GP010456.MP4", Path: "/b/GP010456.MP4", Size: 5},
+		{Name: "GOPR0456.MP4", Path: "/b/GOPR0456.MP4", Size: 4},
+		{Name: "GX010789.MP4", Path: "/b/GX010789.MP4", Size: 1},
+		{Name: "ride.mp4", Path: "/b/ride.mp4", Size: 1},
+	}
+
+	assert.Equal(t, []dirscan.Recording{
+		{
+			Name:     "GX010123.MP4",
+			Chapters: []dirscan.File{files[1], files[0], files[2]},
+			Size:     60,
+		},
+		{
+			Name:     "GOPR0456.MP4",
+			Chapters: []dirscan.File{files[5], files[4]},
+			Size:     9,
+		},
+	}, dirscan.GroupChapters(files))
+}
@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/legosx/gopro-media-library-verifier/dirscan (interfaces: MetadataReader)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/metadatareader.go -package=mocks github.com/legosx/gopro-media-library-verifier/dirscan MetadataReader
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	mediameta "github.com/legosx/gopro-media-library-verifier/mediameta"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMetadataReader is a mock of MetadataReader interface.
+type MockMetadataReader struct {
+	ctrl     *gomock.Controller
+	recorder *MockMetadataReaderMockRecorder
+}
+
+// MockMetadataReaderMockRecorder is the mock recorder for MockMetadataReader.
+type MockMetadataReaderMockRecorder struct {
+	mock *MockMetadataReader
+}
+
+// NewMockMetadataReader creates a new mock instance.
+func NewMockMetadataReader(ctrl *gomock.Controller) *MockMetadataReader {
+	mock := &MockMetadataReader{ctrl: ctrl}
+	mock.recorder = &MockMetadataReaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMetadataReader) EXPECT() *MockMetadataReaderMockRecorder {
+	return m.recorder
+}
+
+// Read mocks base method.
+func (m *MockMetadataReader) Read(arg0 string) (mediameta.Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Read", arg0)
+	ret0, _ := ret[0].(mediameta.Metadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Read indicates an expected call of Read.
+func (mr *MockMetadataReaderMockRecorder) Read(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Read", reflect.TypeOf((*MockMetadataReader)(nil).Read), arg0)
+}
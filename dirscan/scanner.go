@@ -1,17 +1,21 @@
 package dirscan
 
 import (
+	"context"
+	"github.com/legosx/gopro-media-library-verifier/mediameta"
 	"github.com/pkg/errors"
 	"go.uber.org/multierr"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 type Scanner struct {
 	allowedExtensions []string
 	os                OS
+	metadataReader    MetadataReader
 }
 
 func NewScanner(allowedExtensions []string, opts ...func(scanner *Scanner)) (scanner Scanner) {
@@ -39,13 +43,44 @@ func WithOS(os OS) func(s *Scanner) {
 	}
 }
 
+type MetadataReader interface {
+	Read(path string) (metadata mediameta.Metadata, err error)
+}
+
+// WithMetadataReader makes the scanner read the metadata the camera recorded in the files, e.g. the capture time.
+// The metadata is best effort, a file whose metadata can't be read is still listed without it.
+func WithMetadataReader(metadataReader MetadataReader) func(s *Scanner) {
+	return func(s *Scanner) {
+		s.metadataReader = metadataReader
+	}
+}
+
 type File struct {
 	Name string
 	Size int64
 	Path string
+	// ModTime is when the file was last modified, cameras set it to the capture time
+	ModTime time.Time
+	// Metadata is only read when the scanner has a metadata reader
+	mediameta.Metadata
 }
 
-func (s Scanner) GetFileList(dirPath string) (list []File, err error) {
+// CaptureTime is the capture time from the metadata, or the modification time when it's unknown
+func (f File) CaptureTime() time.Time {
+	if !f.CapturedAt.IsZero() {
+		return f.CapturedAt
+	}
+
+	return f.ModTime
+}
+
+// GetFileList walks the directory recursively and returns the files with allowed extensions.
+// The walk stops before the next directory once the context is done.
+func (s Scanner) GetFileList(ctx context.Context, dirPath string) (list []File, err error) {
+	if err = ctx.Err(); err != nil {
+		return []File{}, errors.Wrap(err, "directory scan stopped")
+	}
+
 	if _, err := s.os.Stat(dirPath); err != nil {
 		if s.os.IsNotExist(err) {
 			return []File{}, errors.Wrap(err, "path does not exist")
@@ -76,7 +111,7 @@ func (s Scanner) GetFileList(dirPath string) (list []File, err error) {
 	// Iterate through the files
 	for _, fileInfo := range fileInfos {
 		if fileInfo.IsDir() {
-			innerList, err := s.GetFileList(path.Join(dirPath, fileInfo.Name()))
+			innerList, err := s.GetFileList(ctx, path.Join(dirPath, fileInfo.Name()))
 			if err != nil {
 				return []File{}, errors.Wrap(err, "error getting file list recursively")
 			}
@@ -93,11 +128,20 @@ func (s Scanner) GetFileList(dirPath string) (list []File, err error) {
 			continue
 		}
 
-		list = append(list, File{
-			Name: fileInfo.Name(),
-			Size: fileInfo.Size(),
-			Path: path.Join(dirPath, fileInfo.Name()),
-		})
+		file := File{
+			Name:    fileInfo.Name(),
+			Size:    fileInfo.Size(),
+			Path:    path.Join(dirPath, fileInfo.Name()),
+			ModTime: fileInfo.ModTime(),
+		}
+
+		if s.metadataReader != nil {
+			if metadata, err := s.metadataReader.Read(file.Path); err == nil {
+				file.Metadata = metadata
+			}
+		}
+
+		list = append(list, file)
 	}
 
 	return list, nil
@@ -1,12 +1,15 @@
 package dirscan_test
 
 import (
+	"context"
 	"github.com/legosx/gopro-media-library-verifier/dirscan"
 	"github.com/legosx/gopro-media-library-verifier/dirscan/mocks"
+	"github.com/legosx/gopro-media-library-verifier/mediameta"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 	"time"
@@ -14,6 +17,7 @@ import (
 
 //go:generate mockgen -destination=./mocks/os.go -package=mocks github.com/legosx/gopro-media-library-verifier/dirscan OS
 //go:generate mockgen -destination=./mocks/osfile.go -package=mocks github.com/legosx/gopro-media-library-verifier/dirscan OSFile
+//go:generate mockgen -destination=./mocks/metadatareader.go -package=mocks github.com/legosx/gopro-media-library-verifier/dirscan MetadataReader
 
 func TestScanner_GetFileList(t *testing.T) {
 	type fields struct {
@@ -22,6 +26,7 @@ func TestScanner_GetFileList(t *testing.T) {
 	}
 
 	type args struct {
+		ctx     func() context.Context
 		dirPath string
 	}
 
@@ -36,6 +41,27 @@ func TestScanner_GetFileList(t *testing.T) {
 		args
 		want
 	}{
+		{
+			name: "sad path, context cancelled",
+			fields: fields{
+				allowedExtensions: []string{".mp4", ".jpg"},
+				os: func(mockCtrl *gomock.Controller) dirscan.OS {
+					return mocks.NewMockOS(mockCtrl)
+				},
+			},
+			args: args{
+				ctx: func() context.Context {
+					ctx, cancel := context.WithCancel(context.Background())
+					cancel()
+
+					return ctx
+				},
+				dirPath: "/data",
+			},
+			want: want{
+				err: errors.Wrap(context.Canceled, "directory scan stopped"),
+			},
+		},
 		{
 			name: "happy path",
 			fields: fields{
@@ -239,7 +265,12 @@ func TestScanner_GetFileList(t *testing.T) {
 				dirscan.WithOS(tt.fields.os(mockCtrl)),
 			)
 
-			got, err := scanner.GetFileList(tt.args.dirPath)
+			ctx := context.Background()
+			if tt.args.ctx != nil {
+				ctx = tt.args.ctx()
+			}
+
+			got, err := scanner.GetFileList(ctx, tt.args.dirPath)
 			if tt.want.err == nil {
 				assert.Equal(t, sortList(tt.want.list), sortList(got))
 				assert.NoError(t, err)
@@ -258,6 +289,40 @@ func sortList(list []dirscan.File) []dirscan.File {
 	return list
 }
 
+func TestScanner_GetFileList_Metadata(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "GX010001.MP4"), []byte("video"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "broken.mp4"), []byte("broken"), 0o600))
+
+	capturedAt := time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)
+
+	metadataReader := mocks.NewMockMetadataReader(mockCtrl)
+	metadataReader.EXPECT().Read(filepath.Join(dir, "GX010001.MP4")).Return(mediameta.Metadata{CapturedAt: capturedAt, CameraModel: "HERO9 Black"}, nil)
+	metadataReader.EXPECT().Read(filepath.Join(dir, "broken.mp4")).Return(mediameta.Metadata{}, mediameta.ErrNoMetadata)
+
+	list, err := dirscan.NewScanner([]string{".mp4"}, dirscan.WithMetadataReader(metadataReader)).GetFileList(context.Background(), dir)
+	assert.NoError(t, err)
+	assert.Len(t, list, 2)
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+
+	// A file whose metadata can't be read is still listed
+	assert.Equal(t, "GX010001.MP4", list[0].Name)
+	assert.Equal(t, capturedAt, list[0].CapturedAt)
+	assert.Equal(t, capturedAt, list[0].CaptureTime())
+	assert.Equal(t, "HERO9 Black", list[0].CameraModel)
+	assert.Equal(t, "broken.mp4", list[1].Name)
+	assert.True(t, list[1].CapturedAt.IsZero())
+	assert.Equal(t, list[1].ModTime, list[1].CaptureTime())
+}
+
 type fakeFile struct {
 	name  string
 	size  int64
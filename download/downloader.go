@@ -0,0 +1,280 @@
+package download
+
+import (
+	"context"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const partFileExtension = ".part"
+
+type Client interface {
+	GetMedia(ctx context.Context, mediaID string) (m client.Media, err error)
+	GetMediaDownload(ctx context.Context, mediaID string) (download client.MediaDownload, err error)
+	OpenDownload(ctx context.Context, url string, offset int64) (body io.ReadCloser, startOffset int64, err error)
+}
+
+type Downloader struct {
+	client             Client
+	maxConcurrentCalls int
+	onDone             func(media fetch.Media, err error)
+}
+
+func NewDownloader(client Client, opts ...func(downloader *Downloader)) Downloader {
+	d := Downloader{
+		client:             client,
+		maxConcurrentCalls: 4,
+		onDone:             func(media fetch.Media, err error) {},
+	}
+
+	for _, opt := range opts {
+		opt(&d)
+	}
+
+	return d
+}
+
+func WithMaxConcurrentCalls(maxConcurrentCalls int) func(d *Downloader) {
+	return func(d *Downloader) {
+		d.maxConcurrentCalls = maxConcurrentCalls
+	}
+}
+
+// WithOnDone sets a callback that is called once per media when its download is finished or failed
+func WithOnDone(onDone func(media fetch.Media, err error)) func(d *Downloader) {
+	return func(d *Downloader) {
+		d.onDone = onDone
+	}
+}
+
+// DownloadMedias downloads the medias to the directory in parallel.
+// A failed media doesn't stop the others, all the errors are returned together.
+func (d Downloader) DownloadMedias(ctx context.Context, medias []fetch.Media, dirPath string) (err error) {
+	if err = os.MkdirAll(dirPath, 0755); err != nil {
+		return errors.Wrap(err, "error creating download directory")
+	}
+
+	maxConcurrentCalls := d.maxConcurrentCalls
+	if maxConcurrentCalls < 1 {
+		maxConcurrentCalls = 1
+	}
+
+	resultCh := make(chan error, len(medias))
+	sem := make(chan struct{}, maxConcurrentCalls)
+	fileNames := d.getFileNames(medias)
+
+	for i, media := range medias {
+		go func(media fetch.Media, fileName string) {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			downloadErr := d.downloadMediaAs(ctx, media, filepath.Join(dirPath, fileName))
+			d.onDone(media, downloadErr)
+
+			if downloadErr != nil {
+				downloadErr = errors.Wrapf(downloadErr, "error downloading %s", media.FileName())
+			}
+
+			resultCh <- downloadErr
+		}(media, fileNames[i])
+	}
+
+	for range medias {
+		err = multierr.Append(err, <-resultCh)
+	}
+
+	return err
+}
+
+// getFileNames names the downloads of the medias by their position.
+// GoPro file numbers repeat across cameras, so the medias that share a name get their ID appended,
+// e.g. GX010001_id1.MP4, instead of writing into the same ".part" file at the same time.
+func (d Downloader) getFileNames(medias []fetch.Media) (fileNames []string) {
+	counts := map[string]int{}
+	for _, media := range medias {
+		counts[strings.ToLower(filepath.Base(media.FileName()))]++
+	}
+
+	fileNames = make([]string, 0, len(medias))
+
+	for _, media := range medias {
+		fileName := filepath.Base(media.FileName())
+
+		if counts[strings.ToLower(fileName)] > 1 {
+			fileName = getIDFileName(media)
+		}
+
+		fileNames = append(fileNames, fileName)
+	}
+
+	return fileNames
+}
+
+func getIDFileName(media fetch.Media) string {
+	fileName := filepath.Base(media.FileName())
+	extension := filepath.Ext(fileName)
+
+	return strings.TrimSuffix(fileName, extension) + "_" + filepath.Base(media.ID()) + extension
+}
+
+// DownloadMedia downloads a single media into a ".part" file next to its final location.
+// An existing ".part" file is continued, and the file is only moved in place when its size matches the library.
+// A file of the same size is already downloaded, while a different file with the same name makes the media saved with its ID appended.
+func (d Downloader) DownloadMedia(ctx context.Context, media fetch.Media, dirPath string) (err error) {
+	return d.downloadMediaAs(ctx, media, filepath.Join(dirPath, filepath.Base(media.FileName())))
+}
+
+func (d Downloader) downloadMediaAs(ctx context.Context, media fetch.Media, filePath string) (err error) {
+	if media.ID() == "" {
+		return errors.New("media has no id")
+	}
+
+	// The listing may be stale, e.g. served from the cache, so the download is verified against the current size
+	current, err := d.client.GetMedia(ctx, media.ID())
+	if err != nil {
+		return errors.Wrap(err, "error refreshing media")
+	}
+
+	fileSize := current.FileSize()
+	if fileSize <= 0 {
+		return errors.New("media has no file size to verify the download against")
+	}
+
+	filePath, downloaded, err := d.resolveFilePath(media, filePath, fileSize)
+	if err != nil || downloaded {
+		return err
+	}
+
+	partFilePath := filePath + partFileExtension
+
+	offset, err := d.getPartFileSize(partFilePath)
+	if err != nil {
+		return err
+	}
+
+	// A part file bigger than the media can't be continued
+	if offset > fileSize {
+		offset = 0
+	}
+
+	if offset < fileSize {
+		if err = d.downloadToPartFile(ctx, media, partFilePath, offset); err != nil {
+			return err
+		}
+	}
+
+	if err = d.verifySize(fileSize, partFilePath); err != nil {
+		return err
+	}
+
+	if err = os.Rename(partFilePath, filePath); err != nil {
+		return errors.Wrap(err, "error moving downloaded file")
+	}
+
+	return nil
+}
+
+// resolveFilePath checks the file the media is downloaded to, and falls back to the name with the media ID when it's taken by another file
+func (d Downloader) resolveFilePath(media fetch.Media, filePath string, fileSize int64) (resolvedPath string, downloaded bool, err error) {
+	idFilePath := filepath.Join(filepath.Dir(filePath), getIDFileName(media))
+
+	for _, candidate := range []string{filePath, idFilePath} {
+		fileInfo, err := os.Stat(candidate)
+		if os.IsNotExist(err) {
+			return candidate, false, nil
+		}
+		if err != nil {
+			return "", false, errors.Wrap(err, "can't stat the file")
+		}
+
+		if fileInfo.Size() == fileSize {
+			return candidate, true, nil
+		}
+	}
+
+	return "", false, errors.Errorf("file already exists: %s", idFilePath)
+}
+
+func (d Downloader) downloadToPartFile(ctx context.Context, media fetch.Media, partFilePath string, offset int64) (err error) {
+	mediaDownload, err := d.client.GetMediaDownload(ctx, media.ID())
+	if err != nil {
+		return errors.Wrap(err, "error getting download URL")
+	}
+
+	body, startOffset, err := d.client.OpenDownload(ctx, mediaDownload.URL(), offset)
+	if err != nil {
+		return errors.Wrap(err, "error starting download")
+	}
+	defer func() {
+		if innerErr := body.Close(); innerErr != nil {
+			err = errors.Wrap(multierr.Append(err, innerErr), "error closing download")
+		}
+	}()
+
+	file, err := os.OpenFile(partFilePath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return errors.Wrap(err, "error opening part file")
+	}
+	defer func() {
+		if innerErr := file.Close(); innerErr != nil {
+			err = errors.Wrap(multierr.Append(err, innerErr), "error closing part file")
+		}
+	}()
+
+	// The server may ignore the range request and send the whole file again
+	if err = file.Truncate(startOffset); err != nil {
+		return errors.Wrap(err, "error truncating part file")
+	}
+
+	if _, err = file.Seek(startOffset, io.SeekStart); err != nil {
+		return errors.Wrap(err, "error seeking part file")
+	}
+
+	if _, err = io.Copy(file, body); err != nil {
+		return errors.Wrap(err, "error writing part file")
+	}
+
+	return nil
+}
+
+func (d Downloader) verifySize(fileSize int64, partFilePath string) (err error) {
+	size, err := d.getPartFileSize(partFilePath)
+	if err != nil {
+		return err
+	}
+
+	if size == fileSize {
+		return nil
+	}
+
+	// A smaller part file is kept, so the download can be continued by the next run
+	if size < fileSize {
+		return errors.Errorf("incomplete download: got %d of %d bytes", size, fileSize)
+	}
+
+	// The downloaded data doesn't match the library, so it's removed to start from scratch next time
+	if err = os.Remove(partFilePath); err != nil {
+		return errors.Wrap(err, "error removing part file")
+	}
+
+	return errors.Errorf("size mismatch: expected %d bytes, got %d bytes", fileSize, size)
+}
+
+func (d Downloader) getPartFileSize(partFilePath string) (size int64, err error) {
+	fileInfo, err := os.Stat(partFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+
+		return 0, errors.Wrap(err, "can't stat the part file")
+	}
+
+	return fileInfo.Size(), nil
+}
@@ -0,0 +1,538 @@
+package download_test
+
+import (
+	"context"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/download"
+	"github.com/legosx/gopro-media-library-verifier/download/mocks"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+//go:generate mockgen -destination=./mocks/client.go -package=mocks github.com/legosx/gopro-media-library-verifier/download Client
+
+func TestDownloader_DownloadMedia(t *testing.T) {
+	type fields struct {
+		client func(mockCtrl *gomock.Controller) download.Client
+	}
+
+	type args struct {
+		media fetch.Media
+		setup func(dir string)
+	}
+
+	type want struct {
+		content     string
+		idContent   string
+		partContent *string
+		err         func(dir string) error
+	}
+
+	media := fetch.NewMedia("file1.mp4", 10, fetch.WithMediaID("id1"))
+	current := client.NewMedia("file1.mp4", 10, client.WithMediaID("id1"))
+	mediaDownload := client.NewMediaDownload("file1.mp4", "https://storage/file1.mp4")
+	stringPtr := func(s string) *string { return &s }
+
+	tests := []struct {
+		name string
+		fields
+		args
+		want
+	}{
+		{
+			name: "happy path",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(current, nil)
+					mock.EXPECT().GetMediaDownload(gomock.Any(), "id1").Return(mediaDownload, nil)
+					mock.EXPECT().OpenDownload(gomock.Any(), "https://storage/file1.mp4", int64(0)).Return(io.NopCloser(strings.NewReader("0123456789")), int64(0), nil)
+
+					return mock
+				},
+			},
+			args: args{media: media},
+			want: want{content: "0123456789"},
+		},
+		{
+			name: "happy path, part file is continued",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(current, nil)
+					mock.EXPECT().GetMediaDownload(gomock.Any(), "id1").Return(mediaDownload, nil)
+					mock.EXPECT().OpenDownload(gomock.Any(), "https://storage/file1.mp4", int64(4)).Return(io.NopCloser(strings.NewReader("456789")), int64(4), nil)
+
+					return mock
+				},
+			},
+			args: args{
+				media: media,
+				setup: func(dir string) {
+					assert.NoError(t, os.WriteFile(filepath.Join(dir, "file1.mp4.part"), []byte("0123"), 0644))
+				},
+			},
+			want: want{content: "0123456789"},
+		},
+		{
+			name: "happy path, range is ignored by the server",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(current, nil)
+					mock.EXPECT().GetMediaDownload(gomock.Any(), "id1").Return(mediaDownload, nil)
+					mock.EXPECT().OpenDownload(gomock.Any(), "https://storage/file1.mp4", int64(4)).Return(io.NopCloser(strings.NewReader("0123456789")), int64(0), nil)
+
+					return mock
+				},
+			},
+			args: args{
+				media: media,
+				setup: func(dir string) {
+					assert.NoError(t, os.WriteFile(filepath.Join(dir, "file1.mp4.part"), []byte("xxxx"), 0644))
+				},
+			},
+			want: want{content: "0123456789"},
+		},
+		{
+			name: "happy path, part file is already complete",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(current, nil)
+
+					return mock
+				},
+			},
+			args: args{
+				media: media,
+				setup: func(dir string) {
+					assert.NoError(t, os.WriteFile(filepath.Join(dir, "file1.mp4.part"), []byte("0123456789"), 0644))
+				},
+			},
+			want: want{content: "0123456789"},
+		},
+		{
+			name: "happy path, part file is bigger than the media, download starts over",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(current, nil)
+					mock.EXPECT().GetMediaDownload(gomock.Any(), "id1").Return(mediaDownload, nil)
+					mock.EXPECT().OpenDownload(gomock.Any(), "https://storage/file1.mp4", int64(0)).Return(io.NopCloser(strings.NewReader("0123456789")), int64(0), nil)
+
+					return mock
+				},
+			},
+			args: args{
+				media: media,
+				setup: func(dir string) {
+					assert.NoError(t, os.WriteFile(filepath.Join(dir, "file1.mp4.part"), []byte("0123456789abc"), 0644))
+				},
+			},
+			want: want{content: "0123456789"},
+		},
+		{
+			name: "happy path, stale listing, download is verified against the current size",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(current, nil)
+					mock.EXPECT().GetMediaDownload(gomock.Any(), "id1").Return(mediaDownload, nil)
+					mock.EXPECT().OpenDownload(gomock.Any(), "https://storage/file1.mp4", int64(0)).Return(io.NopCloser(strings.NewReader("0123456789")), int64(0), nil)
+
+					return mock
+				},
+			},
+			args: args{media: fetch.NewMedia("file1.mp4", 6, fetch.WithMediaID("id1"))},
+			want: want{content: "0123456789"},
+		},
+		{
+			name: "sad path, media without id",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					return mocks.NewMockClient(mockCtrl)
+				},
+			},
+			args: args{media: fetch.NewMedia("file1.mp4", 10)},
+			want: want{
+				err: func(dir string) error { return errors.New("media has no id") },
+			},
+		},
+		{
+			name: "sad path, media without size",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(client.NewMedia("file1.mp4", 0, client.WithMediaID("id1")), nil)
+
+					return mock
+				},
+			},
+			args: args{media: fetch.NewMedia("file1.mp4", 0, fetch.WithMediaID("id1"))},
+			want: want{
+				err: func(dir string) error { return errors.New("media has no file size to verify the download against") },
+			},
+		},
+		{
+			name: "happy path, file is already downloaded",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(current, nil)
+
+					return mock
+				},
+			},
+			args: args{
+				media: media,
+				setup: func(dir string) {
+					assert.NoError(t, os.WriteFile(filepath.Join(dir, "file1.mp4"), []byte("0123456789"), 0644))
+				},
+			},
+			want: want{content: "0123456789"},
+		},
+		{
+			name: "happy path, name is taken by another file, media ID is appended",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(current, nil)
+					mock.EXPECT().GetMediaDownload(gomock.Any(), "id1").Return(mediaDownload, nil)
+					mock.EXPECT().OpenDownload(gomock.Any(), "https://storage/file1.mp4", int64(0)).Return(io.NopCloser(strings.NewReader("0123456789")), int64(0), nil)
+
+					return mock
+				},
+			},
+			args: args{
+				media: media,
+				setup: func(dir string) {
+					assert.NoError(t, os.WriteFile(filepath.Join(dir, "file1.mp4"), []byte("other"), 0644))
+				},
+			},
+			want: want{
+				content:   "other",
+				idContent: "0123456789",
+			},
+		},
+		{
+			name: "happy path, file is already downloaded with media ID appended",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(current, nil)
+
+					return mock
+				},
+			},
+			args: args{
+				media: media,
+				setup: func(dir string) {
+					assert.NoError(t, os.WriteFile(filepath.Join(dir, "file1.mp4"), []byte("other"), 0644))
+					assert.NoError(t, os.WriteFile(filepath.Join(dir, "file1_id1.mp4"), []byte("0123456789"), 0644))
+				},
+			},
+			want: want{
+				content:   "other",
+				idContent: "0123456789",
+			},
+		},
+		{
+			name: "sad path, both names are taken by other files",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(current, nil)
+
+					return mock
+				},
+			},
+			args: args{
+				media: media,
+				setup: func(dir string) {
+					assert.NoError(t, os.WriteFile(filepath.Join(dir, "file1.mp4"), []byte("other"), 0644))
+					assert.NoError(t, os.WriteFile(filepath.Join(dir, "file1_id1.mp4"), []byte("another"), 0644))
+				},
+			},
+			want: want{
+				content:   "other",
+				idContent: "another",
+				err: func(dir string) error {
+					return errors.Errorf("file already exists: %s", filepath.Join(dir, "file1_id1.mp4"))
+				},
+			},
+		},
+		{
+			name: "sad path, get media error",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(client.Media{}, assert.AnError)
+
+					return mock
+				},
+			},
+			args: args{media: media},
+			want: want{
+				err: func(dir string) error { return errors.Wrap(assert.AnError, "error refreshing media") },
+			},
+		},
+		{
+			name: "sad path, get media download error",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(current, nil)
+					mock.EXPECT().GetMediaDownload(gomock.Any(), "id1").Return(client.MediaDownload{}, assert.AnError)
+
+					return mock
+				},
+			},
+			args: args{media: media},
+			want: want{
+				err: func(dir string) error { return errors.Wrap(assert.AnError, "error getting download URL") },
+			},
+		},
+		{
+			name: "sad path, open download error",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(current, nil)
+					mock.EXPECT().GetMediaDownload(gomock.Any(), "id1").Return(mediaDownload, nil)
+					mock.EXPECT().OpenDownload(gomock.Any(), "https://storage/file1.mp4", int64(0)).Return(nil, int64(0), assert.AnError)
+
+					return mock
+				},
+			},
+			args: args{media: media},
+			want: want{
+				err: func(dir string) error { return errors.Wrap(assert.AnError, "error starting download") },
+			},
+		},
+		{
+			name: "sad path, connection dropped, part file is kept",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(current, nil)
+					mock.EXPECT().GetMediaDownload(gomock.Any(), "id1").Return(mediaDownload, nil)
+					mock.EXPECT().OpenDownload(gomock.Any(), "https://storage/file1.mp4", int64(0)).Return(io.NopCloser(strings.NewReader("012345")), int64(0), nil)
+
+					return mock
+				},
+			},
+			args: args{media: media},
+			want: want{
+				partContent: stringPtr("012345"),
+				err:         func(dir string) error { return errors.New("incomplete download: got 6 of 10 bytes") },
+			},
+		},
+		{
+			name: "sad path, downloaded more than expected, part file is removed",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) download.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetMedia(gomock.Any(), "id1").Return(current, nil)
+					mock.EXPECT().GetMediaDownload(gomock.Any(), "id1").Return(mediaDownload, nil)
+					mock.EXPECT().OpenDownload(gomock.Any(), "https://storage/file1.mp4", int64(0)).Return(io.NopCloser(strings.NewReader("0123456789abc")), int64(0), nil)
+
+					return mock
+				},
+			},
+			args: args{media: media},
+			want: want{
+				err: func(dir string) error { return errors.New("size mismatch: expected 10 bytes, got 13 bytes") },
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			dir := t.TempDir()
+			if tt.args.setup != nil {
+				tt.args.setup(dir)
+			}
+
+			err := download.NewDownloader(tt.fields.client(mockCtrl)).DownloadMedia(context.Background(), tt.args.media, dir)
+			if tt.want.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err(dir).Error())
+			}
+
+			content, readErr := os.ReadFile(filepath.Join(dir, "file1.mp4"))
+			if tt.want.content == "" {
+				assert.True(t, os.IsNotExist(readErr))
+			} else {
+				assert.NoError(t, readErr)
+				assert.Equal(t, tt.want.content, string(content))
+			}
+
+			idContent, readErr := os.ReadFile(filepath.Join(dir, "file1_id1.mp4"))
+			if tt.want.idContent == "" {
+				assert.True(t, os.IsNotExist(readErr))
+			} else {
+				assert.NoError(t, readErr)
+				assert.Equal(t, tt.want.idContent, string(idContent))
+			}
+
+			partContent, readErr := os.ReadFile(filepath.Join(dir, "file1.mp4.part"))
+			if tt.want.partContent == nil {
+				assert.True(t, os.IsNotExist(readErr))
+			} else {
+				assert.NoError(t, readErr)
+				assert.Equal(t, *tt.want.partContent, string(partContent))
+			}
+		})
+	}
+}
+
+func TestDownloader_DownloadMedias(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	dir := filepath.Join(t.TempDir(), "downloads")
+
+	mock := mocks.NewMockClient(mockCtrl)
+	mock.EXPECT().GetMedia(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(func(ctx context.Context, mediaID string) (client.Media, error) {
+		return client.NewMedia(mediaID, 3, client.WithMediaID(mediaID)), nil
+	})
+	mock.EXPECT().GetMediaDownload(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(func(ctx context.Context, mediaID string) (client.MediaDownload, error) {
+		if mediaID == "id3" {
+			return client.MediaDownload{}, assert.AnError
+		}
+
+		return client.NewMediaDownload(mediaID, "https://storage/"+mediaID), nil
+	})
+	mock.EXPECT().OpenDownload(gomock.Any(), gomock.Any(), int64(0)).Times(2).DoAndReturn(func(ctx context.Context, url string, offset int64) (io.ReadCloser, int64, error) {
+		return io.NopCloser(strings.NewReader(strings.TrimPrefix(url, "https://storage/"))), 0, nil
+	})
+
+	var (
+		mu   sync.Mutex
+		done []string
+	)
+
+	downloader := download.NewDownloader(
+		mock,
+		download.WithMaxConcurrentCalls(2),
+		download.WithOnDone(func(media fetch.Media, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			done = append(done, media.ID())
+		}),
+	)
+
+	err := downloader.DownloadMedias(context.Background(), []fetch.Media{
+		fetch.NewMedia("file1.mp4", 3, fetch.WithMediaID("id1")),
+		fetch.NewMedia("file2.mp4", 3, fetch.WithMediaID("id2")),
+		fetch.NewMedia("file3.mp4", 3, fetch.WithMediaID("id3")),
+	}, dir)
+
+	assert.EqualError(t, err, "error downloading file3.mp4: error getting download URL: "+assert.AnError.Error())
+	assert.ElementsMatch(t, []string{"id1", "id2", "id3"}, done)
+
+	for name, want := range map[string]string{"file1.mp4": "id1", "file2.mp4": "id2"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(content))
+	}
+}
+
+func TestDownloader_DownloadMedias_SameName(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	dir := t.TempDir()
+
+	mock := mocks.NewMockClient(mockCtrl)
+	mock.EXPECT().GetMedia(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(func(ctx context.Context, mediaID string) (client.Media, error) {
+		return client.NewMedia(mediaID, 3, client.WithMediaID(mediaID)), nil
+	})
+	mock.EXPECT().GetMediaDownload(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(func(ctx context.Context, mediaID string) (client.MediaDownload, error) {
+		return client.NewMediaDownload(mediaID, "https://storage/"+mediaID), nil
+	})
+	mock.EXPECT().OpenDownload(gomock.Any(), gomock.Any(), int64(0)).Times(3).DoAndReturn(func(ctx context.Context, url string, offset int64) (io.ReadCloser, int64, error) {
+		return io.NopCloser(strings.NewReader(strings.TrimPrefix(url, "https://storage/"))), 0, nil
+	})
+
+	// Two cameras numbered their recordings the same way
+	err := download.NewDownloader(mock, download.WithMaxConcurrentCalls(3)).DownloadMedias(context.Background(), []fetch.Media{
+		fetch.NewMedia("GX010001.MP4", 3, fetch.WithMediaID("id1")),
+		fetch.NewMedia("GX010001.MP4", 3, fetch.WithMediaID("id2")),
+		fetch.NewMedia("GX010002.MP4", 3, fetch.WithMediaID("id3")),
+	}, dir)
+	assert.NoError(t, err)
+
+	for name, want := range map[string]string{"GX010001_id1.MP4": "id1", "GX010001_id2.MP4": "id2", "GX010002.MP4": "id3"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(content))
+	}
+
+	_, err = os.Stat(filepath.Join(dir, "GX010001.MP4"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDownloader_DownloadMedias_Twice(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	dir := t.TempDir()
+
+	// A file of another camera took the name before the first run
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "GX010002.MP4"), []byte("other"), 0644))
+
+	mock := mocks.NewMockClient(mockCtrl)
+	mock.EXPECT().GetMedia(gomock.Any(), gomock.Any()).Times(6).DoAndReturn(func(ctx context.Context, mediaID string) (client.Media, error) {
+		return client.NewMedia(mediaID, 3, client.WithMediaID(mediaID)), nil
+	})
+	// Only the first run downloads the files
+	mock.EXPECT().GetMediaDownload(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(func(ctx context.Context, mediaID string) (client.MediaDownload, error) {
+		return client.NewMediaDownload(mediaID, "https://storage/"+mediaID), nil
+	})
+	mock.EXPECT().OpenDownload(gomock.Any(), gomock.Any(), int64(0)).Times(3).DoAndReturn(func(ctx context.Context, url string, offset int64) (io.ReadCloser, int64, error) {
+		return io.NopCloser(strings.NewReader(strings.TrimPrefix(url, "https://storage/"))), 0, nil
+	})
+
+	medias := []fetch.Media{
+		fetch.NewMedia("GX010001.MP4", 3, fetch.WithMediaID("id1")),
+		fetch.NewMedia("GX010001.MP4", 3, fetch.WithMediaID("id2")),
+		fetch.NewMedia("GX010002.MP4", 3, fetch.WithMediaID("id3")),
+	}
+
+	downloader := download.NewDownloader(mock, download.WithMaxConcurrentCalls(3))
+
+	assert.NoError(t, downloader.DownloadMedias(context.Background(), medias, dir))
+	assert.NoError(t, downloader.DownloadMedias(context.Background(), medias, dir))
+
+	for name, want := range map[string]string{"GX010001_id1.MP4": "id1", "GX010001_id2.MP4": "id2", "GX010002.MP4": "other", "GX010002_id3.MP4": "id3"} {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		assert.NoError(t, err)
+		assert.Equal(t, want, string(content))
+	}
+}
@@ -0,0 +1,88 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/legosx/gopro-media-library-verifier/download (interfaces: Client)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/client.go -package=mocks github.com/legosx/gopro-media-library-verifier/download Client
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	client "github.com/legosx/gopro-media-library-verifier/client"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockClient is a mock of Client interface.
+type MockClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientMockRecorder
+}
+
+// MockClientMockRecorder is the mock recorder for MockClient.
+type MockClientMockRecorder struct {
+	mock *MockClient
+}
+
+// NewMockClient creates a new mock instance.
+func NewMockClient(ctrl *gomock.Controller) *MockClient {
+	mock := &MockClient{ctrl: ctrl}
+	mock.recorder = &MockClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClient) EXPECT() *MockClientMockRecorder {
+	return m.recorder
+}
+
+// GetMedia mocks base method.
+func (m *MockClient) GetMedia(arg0 context.Context, arg1 string) (client.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMedia", arg0, arg1)
+	ret0, _ := ret[0].(client.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMedia indicates an expected call of GetMedia.
+func (mr *MockClientMockRecorder) GetMedia(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMedia", reflect.TypeOf((*MockClient)(nil).GetMedia), arg0, arg1)
+}
+
+// GetMediaDownload mocks base method.
+func (m *MockClient) GetMediaDownload(arg0 context.Context, arg1 string) (client.MediaDownload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMediaDownload", arg0, arg1)
+	ret0, _ := ret[0].(client.MediaDownload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMediaDownload indicates an expected call of GetMediaDownload.
+func (mr *MockClientMockRecorder) GetMediaDownload(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMediaDownload", reflect.TypeOf((*MockClient)(nil).GetMediaDownload), arg0, arg1)
+}
+
+// OpenDownload mocks base method.
+func (m *MockClient) OpenDownload(arg0 context.Context, arg1 string, arg2 int64) (io.ReadCloser, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OpenDownload", arg0, arg1, arg2)
+	ret0, _ := ret[0].(io.ReadCloser)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// OpenDownload indicates an expected call of OpenDownload.
+func (mr *MockClientMockRecorder) OpenDownload(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OpenDownload", reflect.TypeOf((*MockClient)(nil).OpenDownload), arg0, arg1, arg2)
+}
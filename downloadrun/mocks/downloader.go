@@ -0,0 +1,55 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/legosx/gopro-media-library-verifier/downloadrun (interfaces: Downloader)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/downloader.go -package=mocks github.com/legosx/gopro-media-library-verifier/downloadrun Downloader
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	fetch "github.com/legosx/gopro-media-library-verifier/fetch"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockDownloader is a mock of Downloader interface.
+type MockDownloader struct {
+	ctrl     *gomock.Controller
+	recorder *MockDownloaderMockRecorder
+}
+
+// MockDownloaderMockRecorder is the mock recorder for MockDownloader.
+type MockDownloaderMockRecorder struct {
+	mock *MockDownloader
+}
+
+// NewMockDownloader creates a new mock instance.
+func NewMockDownloader(ctrl *gomock.Controller) *MockDownloader {
+	mock := &MockDownloader{ctrl: ctrl}
+	mock.recorder = &MockDownloaderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockDownloader) EXPECT() *MockDownloaderMockRecorder {
+	return m.recorder
+}
+
+// DownloadMedias mocks base method.
+func (m *MockDownloader) DownloadMedias(arg0 context.Context, arg1 []fetch.Media, arg2 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DownloadMedias", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DownloadMedias indicates an expected call of DownloadMedias.
+func (mr *MockDownloaderMockRecorder) DownloadMedias(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DownloadMedias", reflect.TypeOf((*MockDownloader)(nil).DownloadMedias), arg0, arg1, arg2)
+}
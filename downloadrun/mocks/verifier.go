@@ -0,0 +1,56 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/legosx/gopro-media-library-verifier/downloadrun (interfaces: Verifier)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/verifier.go -package=mocks github.com/legosx/gopro-media-library-verifier/downloadrun Verifier
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	fetch "github.com/legosx/gopro-media-library-verifier/fetch"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockVerifier is a mock of Verifier interface.
+type MockVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockVerifierMockRecorder
+}
+
+// MockVerifierMockRecorder is the mock recorder for MockVerifier.
+type MockVerifierMockRecorder struct {
+	mock *MockVerifier
+}
+
+// NewMockVerifier creates a new mock instance.
+func NewMockVerifier(ctrl *gomock.Controller) *MockVerifier {
+	mock := &MockVerifier{ctrl: ctrl}
+	mock.recorder = &MockVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVerifier) EXPECT() *MockVerifierMockRecorder {
+	return m.recorder
+}
+
+// IdentifyRemoteOnlyMedias mocks base method.
+func (m *MockVerifier) IdentifyRemoteOnlyMedias(arg0 context.Context, arg1 string) ([]fetch.Media, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IdentifyRemoteOnlyMedias", arg0, arg1)
+	ret0, _ := ret[0].([]fetch.Media)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IdentifyRemoteOnlyMedias indicates an expected call of IdentifyRemoteOnlyMedias.
+func (mr *MockVerifierMockRecorder) IdentifyRemoteOnlyMedias(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentifyRemoteOnlyMedias", reflect.TypeOf((*MockVerifier)(nil).IdentifyRemoteOnlyMedias), arg0, arg1)
+}
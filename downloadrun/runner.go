@@ -0,0 +1,155 @@
+package downloadrun
+
+import (
+	"context"
+	"fmt"
+	"github.com/legosx/gopro-media-library-verifier/buildclient"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/dirscan"
+	"github.com/legosx/gopro-media-library-verifier/download"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/legosx/gopro-media-library-verifier/verify"
+	"github.com/legosx/gopro-media-library-verifier/verifyrun"
+	"github.com/spf13/cobra"
+	"sync"
+)
+
+type Runner struct {
+	path               string
+	outputDirPath      string
+	maxConcurrentCalls int
+	tokenPromptMethod  verifyrun.TokenPromptMethod
+	buildClient        func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error)
+	buildVerifier      func(fetcher fetch.Fetcher, scanner dirscan.Scanner) Verifier
+	buildDownloader    func(c *client.Client, maxConcurrentCalls int, onDone func(media fetch.Media, err error)) Downloader
+}
+
+type Verifier interface {
+	IdentifyRemoteOnlyMedias(ctx context.Context, path string) (medias []fetch.Media, err error)
+}
+
+type Downloader interface {
+	DownloadMedias(ctx context.Context, medias []fetch.Media, dirPath string) (err error)
+}
+
+func NewRunner(path, outputDirPath string, maxConcurrentCalls int, tokenPromptMethod verifyrun.TokenPromptMethod, opts ...func(*Runner)) (runner Runner) {
+	r := Runner{
+		path:               path,
+		outputDirPath:      outputDirPath,
+		maxConcurrentCalls: maxConcurrentCalls,
+		tokenPromptMethod:  tokenPromptMethod,
+		buildClient: func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+			return buildclient.NewBuilder(opts...).Build()
+		},
+		buildVerifier: func(fetcher fetch.Fetcher, scanner dirscan.Scanner) Verifier {
+			return verify.NewVerifier(fetcher, scanner)
+		},
+		buildDownloader: func(c *client.Client, maxConcurrentCalls int, onDone func(media fetch.Media, err error)) Downloader {
+			return download.NewDownloader(c, download.WithMaxConcurrentCalls(maxConcurrentCalls), download.WithOnDone(onDone))
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	return r
+}
+
+func WithBuildClient(buildClient func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error)) func(r *Runner) {
+	return func(r *Runner) {
+		r.buildClient = buildClient
+	}
+}
+
+func WithBuildVerifier(buildVerifier func(fetcher fetch.Fetcher, scanner dirscan.Scanner) Verifier) func(r *Runner) {
+	return func(r *Runner) {
+		r.buildVerifier = buildVerifier
+	}
+}
+
+func WithBuildDownloader(buildDownloader func(c *client.Client, maxConcurrentCalls int, onDone func(media fetch.Media, err error)) Downloader) func(r *Runner) {
+	return func(r *Runner) {
+		r.buildDownloader = buildDownloader
+	}
+}
+
+func (r Runner) Run(ctx context.Context) (err error) {
+	builderOpts, err := verifyrun.BuildClientOptions(ctx, r.tokenPromptMethod)
+	if err != nil {
+		return err
+	}
+
+	fetcherOpts, err := verifyrun.FetcherOptions()
+	if err != nil {
+		return err
+	}
+
+	c, err := r.buildClient(builderOpts...)
+	if err != nil {
+		return verifyrun.NewAuthError(err)
+	}
+
+	verifier := r.buildVerifier(fetch.NewFetcher(*c, fetcherOpts...), dirscan.NewScanner(c.GetAllowedExtensions()))
+
+	medias, err := verifier.IdentifyRemoteOnlyMedias(ctx, r.path)
+	if err != nil {
+		return err
+	}
+
+	if len(medias) == 0 {
+		fmt.Println("\nAll files from Gopro Media Library are already in the specified local directory.")
+		return nil
+	}
+
+	outputDirPath := r.outputDirPath
+	if outputDirPath == "" {
+		outputDirPath = r.path
+	}
+
+	fmt.Printf("\nDownloading %d files from Gopro Media Library to %s:\n", len(medias), outputDirPath)
+
+	var (
+		mu               sync.Mutex
+		done, downloaded int
+	)
+
+	downloader := r.buildDownloader(c, r.maxConcurrentCalls, func(media fetch.Media, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		done++
+		if err != nil {
+			fmt.Printf("[%d/%d] %s failed: %s\n", done, len(medias), media.FileName(), err)
+			return
+		}
+
+		downloaded++
+		fmt.Printf("[%d/%d] %s\n", done, len(medias), media.FileName())
+	})
+
+	err = downloader.DownloadMedias(ctx, medias, outputDirPath)
+
+	fmt.Printf("\nDownloaded: %d, failed: %d\n", downloaded, len(medias)-downloaded)
+
+	if err != nil {
+		fmt.Println("Run the command again to resume the failed downloads.")
+	}
+
+	return err
+}
+
+func Init(cmd *cobra.Command) error {
+	cmd.Flags().StringP("path", "p", "", "path to the local directory to compare Gopro Media Library with")
+
+	if err := cmd.MarkFlagRequired("path"); err != nil {
+		return err
+	}
+
+	cmd.Flags().StringP("outputDirPath", "d", "", "a path to the directory where the files are downloaded to (default is the path)")
+	cmd.Flags().IntP("concurrency", "c", 4, "number of files downloaded in parallel")
+
+	verifyrun.InitTokenPromptMethod(cmd)
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package downloadrun_test
+
+import (
+	"context"
+	"github.com/legosx/gopro-media-library-verifier/buildclient"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/dirscan"
+	"github.com/legosx/gopro-media-library-verifier/downloadrun"
+	"github.com/legosx/gopro-media-library-verifier/downloadrun/mocks"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/legosx/gopro-media-library-verifier/verifyrun"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"testing"
+)
+
+//go:generate mockgen -destination=./mocks/verifier.go -package=mocks github.com/legosx/gopro-media-library-verifier/downloadrun Verifier
+//go:generate mockgen -destination=./mocks/downloader.go -package=mocks github.com/legosx/gopro-media-library-verifier/downloadrun Downloader
+
+func TestRunner_Run(t *testing.T) {
+	type fields struct {
+		path              string
+		outputDirPath     string
+		tokenPromptMethod verifyrun.TokenPromptMethod
+		opts              func(mockCtrl *gomock.Controller) []func(*downloadrun.Runner)
+	}
+
+	type want struct {
+		err error
+	}
+
+	buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+		return &client.Client{}, nil
+	}
+
+	medias := []fetch.Media{
+		fetch.NewMedia("file1.mp4", 10, fetch.WithMediaID("id1")),
+		fetch.NewMedia("file2.mp4", 20, fetch.WithMediaID("id2")),
+	}
+
+	tests := []struct {
+		name string
+		fields
+		want
+	}{
+		{
+			name: "happy path, downloads to the compared path by default",
+			fields: fields{
+				path:              "test",
+				tokenPromptMethod: verifyrun.TokenPromptMethodInput,
+				opts: func(mockCtrl *gomock.Controller) []func(*downloadrun.Runner) {
+					buildVerifier := func(fetcher fetch.Fetcher, scanner dirscan.Scanner) downloadrun.Verifier {
+						verifier := mocks.NewMockVerifier(mockCtrl)
+						verifier.EXPECT().IdentifyRemoteOnlyMedias(gomock.Any(), "test").Return(medias, nil)
+
+						return verifier
+					}
+
+					buildDownloader := func(c *client.Client, maxConcurrentCalls int, onDone func(media fetch.Media, err error)) downloadrun.Downloader {
+						assert.Equal(t, 3, maxConcurrentCalls)
+
+						downloader := mocks.NewMockDownloader(mockCtrl)
+						downloader.EXPECT().DownloadMedias(gomock.Any(), medias, "test").DoAndReturn(func(ctx context.Context, medias []fetch.Media, dirPath string) error {
+							onDone(medias[0], nil)
+							onDone(medias[1], nil)
+
+							return nil
+						})
+
+						return downloader
+					}
+
+					return []func(*downloadrun.Runner){
+						downloadrun.WithBuildClient(buildClient),
+						downloadrun.WithBuildVerifier(buildVerifier),
+						downloadrun.WithBuildDownloader(buildDownloader),
+					}
+				},
+			},
+		},
+		{
+			name: "happy path, nothing to download",
+			fields: fields{
+				path: "test",
+				opts: func(mockCtrl *gomock.Controller) []func(*downloadrun.Runner) {
+					buildVerifier := func(fetcher fetch.Fetcher, scanner dirscan.Scanner) downloadrun.Verifier {
+						verifier := mocks.NewMockVerifier(mockCtrl)
+						verifier.EXPECT().IdentifyRemoteOnlyMedias(gomock.Any(), "test").Return([]fetch.Media{}, nil)
+
+						return verifier
+					}
+
+					buildDownloader := func(c *client.Client, maxConcurrentCalls int, onDone func(media fetch.Media, err error)) downloadrun.Downloader {
+						t.Error("downloader should not be built")
+
+						return nil
+					}
+
+					return []func(*downloadrun.Runner){
+						downloadrun.WithBuildClient(buildClient),
+						downloadrun.WithBuildVerifier(buildVerifier),
+						downloadrun.WithBuildDownloader(buildDownloader),
+					}
+				},
+			},
+		},
+		{
+			name: "sad path, download fails",
+			fields: fields{
+				path:          "test",
+				outputDirPath: "output",
+				opts: func(mockCtrl *gomock.Controller) []func(*downloadrun.Runner) {
+					buildVerifier := func(fetcher fetch.Fetcher, scanner dirscan.Scanner) downloadrun.Verifier {
+						verifier := mocks.NewMockVerifier(mockCtrl)
+						verifier.EXPECT().IdentifyRemoteOnlyMedias(gomock.Any(), "test").Return(medias, nil)
+
+						return verifier
+					}
+
+					buildDownloader := func(c *client.Client, maxConcurrentCalls int, onDone func(media fetch.Media, err error)) downloadrun.Downloader {
+						downloader := mocks.NewMockDownloader(mockCtrl)
+						downloader.EXPECT().DownloadMedias(gomock.Any(), medias, "output").DoAndReturn(func(ctx context.Context, medias []fetch.Media, dirPath string) error {
+							onDone(medias[0], nil)
+							onDone(medias[1], assert.AnError)
+
+							return assert.AnError
+						})
+
+						return downloader
+					}
+
+					return []func(*downloadrun.Runner){
+						downloadrun.WithBuildClient(buildClient),
+						downloadrun.WithBuildVerifier(buildVerifier),
+						downloadrun.WithBuildDownloader(buildDownloader),
+					}
+				},
+			},
+			want: want{
+				err: assert.AnError,
+			},
+		},
+		{
+			name: "sad path, verifier fails",
+			fields: fields{
+				path: "test",
+				opts: func(mockCtrl *gomock.Controller) []func(*downloadrun.Runner) {
+					buildVerifier := func(fetcher fetch.Fetcher, scanner dirscan.Scanner) downloadrun.Verifier {
+						verifier := mocks.NewMockVerifier(mockCtrl)
+						verifier.EXPECT().IdentifyRemoteOnlyMedias(gomock.Any(), "test").Return([]fetch.Media{}, assert.AnError)
+
+						return verifier
+					}
+
+					return []func(*downloadrun.Runner){
+						downloadrun.WithBuildClient(buildClient),
+						downloadrun.WithBuildVerifier(buildVerifier),
+					}
+				},
+			},
+			want: want{
+				err: assert.AnError,
+			},
+		},
+		{
+			name: "sad path, buildClient fails",
+			fields: fields{
+				path: "test",
+				opts: func(mockCtrl *gomock.Controller) []func(*downloadrun.Runner) {
+					buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+						return nil, assert.AnError
+					}
+
+					return []func(*downloadrun.Runner){
+						downloadrun.WithBuildClient(buildClient),
+					}
+				},
+			},
+			want: want{
+				err: assert.AnError,
+			},
+		},
+		{
+			name: "sad path, invalid token prompt method",
+			fields: fields{
+				path:              "test",
+				tokenPromptMethod: "invalid",
+				opts: func(mockCtrl *gomock.Controller) []func(*downloadrun.Runner) {
+					return []func(*downloadrun.Runner){}
+				},
+			},
+			want: want{
+				err: errors.New("invalid token prompt method: invalid"),
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			err := downloadrun.NewRunner(tt.path, tt.outputDirPath, 3, tt.tokenPromptMethod, tt.fields.opts(mockCtrl)...).Run(context.Background())
+			if tt.want.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err.Error())
+			}
+		})
+	}
+}
+
+func TestRunner_Init(t *testing.T) {
+	t.Parallel()
+
+	cmd := &cobra.Command{}
+
+	assert.NoError(t, downloadrun.Init(cmd))
+	assert.NotNil(t, cmd.Flag("path"))
+	assert.NotNil(t, cmd.Flag("outputDirPath"))
+	assert.NotNil(t, cmd.Flag("concurrency"))
+	assert.NotNil(t, cmd.Flag("tokenPromptMethod"))
+}
@@ -0,0 +1,128 @@
+package exportrun
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/legosx/gopro-media-library-verifier/indexcache"
+	"github.com/legosx/gopro-media-library-verifier/verifyrun"
+	"github.com/pkg/errors"
+	"io"
+	"strconv"
+	"time"
+)
+
+// FormatsAvailable are the formats of the export, the text format of verify has no use here
+var FormatsAvailable = []verifyrun.Format{
+	verifyrun.FormatJSON,
+	verifyrun.FormatCSV,
+	verifyrun.FormatNDJSON,
+}
+
+var csvHeader = []string{
+	"id",
+	"filename",
+	"file_size",
+	"type",
+	"captured_at",
+	"processing_state",
+	"camera_model",
+	"created_at",
+	"content_title",
+	"duration_ms",
+	"width",
+	"height",
+	"gopro_user_id",
+}
+
+func validateFormat(format verifyrun.Format) error {
+	for _, available := range FormatsAvailable {
+		if format == available {
+			return nil
+		}
+	}
+
+	return errors.Errorf("invalid format: %s", format)
+}
+
+// writeExport writes the medias in the given format.
+// The JSON export has the layout of the cached index, so verify can read it back with --manifest.
+func writeExport(w io.Writer, format verifyrun.Format, medias []fetch.Media, exportedAt time.Time) (err error) {
+	index := indexcache.NewIndex(medias, exportedAt, exportedAt)
+
+	switch format {
+	case verifyrun.FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return errors.Wrap(encoder.Encode(index), "error encoding JSON")
+	case verifyrun.FormatNDJSON:
+		encoder := json.NewEncoder(w)
+
+		for _, media := range index.Medias {
+			if err = encoder.Encode(media); err != nil {
+				return errors.Wrap(err, "error encoding JSON")
+			}
+		}
+
+		return nil
+	case verifyrun.FormatCSV:
+		writer := csv.NewWriter(w)
+
+		if err = writer.Write(csvHeader); err != nil {
+			return errors.Wrap(err, "error writing CSV")
+		}
+
+		for _, media := range index.Medias {
+			if err = writer.Write([]string{
+				media.ID,
+				media.FileName,
+				strconv.FormatInt(media.FileSize, 10),
+				media.Type,
+				formatCSVTime(media.CapturedAt),
+				media.ProcessingState,
+				media.CameraModel,
+				formatCSVOptionalTime(media.CreatedAt),
+				media.ContentTitle,
+				formatCSVInt(media.DurationMs),
+				formatCSVInt(int64(media.Width)),
+				formatCSVInt(int64(media.Height)),
+				media.GoProUserID,
+			}); err != nil {
+				return errors.Wrap(err, "error writing CSV")
+			}
+		}
+
+		writer.Flush()
+
+		return errors.Wrap(writer.Error(), "error writing CSV")
+	default:
+		return errors.Errorf("invalid format: %s", format)
+	}
+}
+
+// formatCSVTime leaves the unknown times empty
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+func formatCSVOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return formatCSVTime(*t)
+}
+
+// formatCSVInt leaves the unknown numbers empty, e.g. the duration of a photo
+func formatCSVInt(i int64) string {
+	if i == 0 {
+		return ""
+	}
+
+	return strconv.FormatInt(i, 10)
+}
@@ -0,0 +1,157 @@
+package exportrun
+
+import (
+	"context"
+	"fmt"
+	"github.com/legosx/gopro-media-library-verifier/buildclient"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/legosx/gopro-media-library-verifier/verify"
+	"github.com/legosx/gopro-media-library-verifier/verifyrun"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
+	"io"
+	"os"
+	"sort"
+	"time"
+)
+
+type Runner struct {
+	outputFilePath    string
+	format            verifyrun.Format
+	tokenPromptMethod verifyrun.TokenPromptMethod
+	stdout            io.Writer
+	stderr            io.Writer
+	now               func() time.Time
+	buildClient       func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error)
+	buildFetcher      func(c *client.Client, opts ...func(fetcher *fetch.Fetcher)) Fetcher
+}
+
+type Fetcher interface {
+	GetMedias(ctx context.Context) (medias []fetch.Media, err error)
+}
+
+func NewRunner(outputFilePath string, format verifyrun.Format, tokenPromptMethod verifyrun.TokenPromptMethod, opts ...func(*Runner)) (runner Runner) {
+	r := Runner{
+		outputFilePath:    outputFilePath,
+		format:            format,
+		tokenPromptMethod: tokenPromptMethod,
+		stdout:            os.Stdout,
+		stderr:            os.Stderr,
+		now:               time.Now,
+		buildClient: func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+			return buildclient.NewBuilder(opts...).Build()
+		},
+		buildFetcher: func(c *client.Client, opts ...func(fetcher *fetch.Fetcher)) Fetcher {
+			return fetch.NewFetcher(*c, opts...)
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	return r
+}
+
+func WithBuildClient(buildClient func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error)) func(r *Runner) {
+	return func(r *Runner) {
+		r.buildClient = buildClient
+	}
+}
+
+func WithBuildFetcher(buildFetcher func(c *client.Client, opts ...func(fetcher *fetch.Fetcher)) Fetcher) func(r *Runner) {
+	return func(r *Runner) {
+		r.buildFetcher = buildFetcher
+	}
+}
+
+// WithOutput sets where the export and the progress messages are written to
+func WithOutput(stdout, stderr io.Writer) func(r *Runner) {
+	return func(r *Runner) {
+		r.stdout = stdout
+		r.stderr = stderr
+	}
+}
+
+// WithNow sets the clock used to stamp the export
+func WithNow(now func() time.Time) func(r *Runner) {
+	return func(r *Runner) {
+		r.now = now
+	}
+}
+
+// Run lists the whole Gopro Media Library and writes it out.
+// An incomplete listing fails the export, an inventory with holes would be misleading.
+func (r Runner) Run(ctx context.Context) (err error) {
+	if err = validateFormat(r.format); err != nil {
+		return err
+	}
+
+	builderOpts, err := verifyrun.BuildClientOptions(ctx, r.tokenPromptMethod)
+	if err != nil {
+		return err
+	}
+
+	fetcherOpts, err := verifyrun.FetcherOptions()
+	if err != nil {
+		return err
+	}
+
+	c, err := r.buildClient(builderOpts...)
+	if err != nil {
+		return verifyrun.NewAuthError(err)
+	}
+
+	exportedAt := r.now()
+
+	medias, err := r.buildFetcher(c, fetcherOpts...).GetMedias(ctx)
+	if err != nil {
+		return verify.NewFetchError(errors.Wrap(err, "error getting remote files"))
+	}
+
+	// The API order isn't stable, sorting makes two exports easy to diff
+	sort.SliceStable(medias, func(i, j int) bool {
+		if !medias[i].CapturedAt().Equal(medias[j].CapturedAt()) {
+			return medias[i].CapturedAt().Before(medias[j].CapturedAt())
+		}
+
+		return medias[i].FileName() < medias[j].FileName()
+	})
+
+	if r.outputFilePath == "" {
+		return writeExport(r.stdout, r.format, medias, exportedAt)
+	}
+
+	if err = r.writeFile(medias, exportedAt); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(r.stderr, "\nExported %d medias to %s\n", len(medias), r.outputFilePath)
+
+	return nil
+}
+
+func (r Runner) writeFile(medias []fetch.Media, exportedAt time.Time) (err error) {
+	file, err := os.Create(r.outputFilePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if innerErr := file.Close(); innerErr != nil {
+			err = errors.Wrap(multierr.Append(err, innerErr), "error closing output file")
+		}
+	}()
+
+	return writeExport(file, r.format, medias, exportedAt)
+}
+
+func Init(cmd *cobra.Command) error {
+	cmd.Flags().StringP("outputFilePath", "o", "", "a path to a file where the export will be written to instead of stdout")
+	cmd.Flags().String("format", string(verifyrun.FormatJSON), fmt.Sprintf("output format: %s, %s or %s", verifyrun.FormatJSON, verifyrun.FormatCSV, verifyrun.FormatNDJSON))
+
+	verifyrun.InitTokenPromptMethod(cmd)
+
+	return nil
+}
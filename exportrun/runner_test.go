@@ -0,0 +1,225 @@
+package exportrun_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/legosx/gopro-media-library-verifier/buildclient"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/exportrun"
+	"github.com/legosx/gopro-media-library-verifier/exportrun/mocks"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/legosx/gopro-media-library-verifier/indexcache"
+	"github.com/legosx/gopro-media-library-verifier/verify"
+	"github.com/legosx/gopro-media-library-verifier/verifyrun"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//go:generate mockgen -destination=./mocks/fetcher.go -package=mocks github.com/legosx/gopro-media-library-verifier/exportrun Fetcher
+
+func TestRunner_Run(t *testing.T) {
+	type fields struct {
+		format   verifyrun.Format
+		fetchErr error
+	}
+
+	type want struct {
+		stdout string
+		err    string
+	}
+
+	exportedAt := time.Date(2023, 6, 11, 8, 0, 0, 0, time.UTC)
+
+	medias := []fetch.Media{
+		fetch.NewMedia("GX010002.MP4", 20,
+			fetch.WithMediaID("id2"),
+			fetch.WithMediaCapturedAt(time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)),
+			fetch.WithMediaProcessingState("ready"),
+			fetch.WithMediaType("Video"),
+			fetch.WithMediaCameraModel("HERO11 Black"),
+			fetch.WithMediaCreatedAt(time.Date(2023, 6, 11, 7, 0, 0, 0, time.UTC)),
+			fetch.WithMediaContentTitle("Ride"),
+			fetch.WithMediaSourceDuration(63*time.Second),
+			fetch.WithMediaDimensions(3840, 2160),
+			fetch.WithMediaGoProUserID("user1"),
+		),
+		fetch.NewMedia("photo.jpg", 5, fetch.WithMediaID("id1"), fetch.WithMediaType("Photo")),
+	}
+
+	tests := []struct {
+		name string
+		fields
+		want
+	}{
+		{
+			name:   "happy path, json",
+			fields: fields{format: verifyrun.FormatJSON},
+			want: want{
+				stdout: `{
+  "version": 1,
+  "revalidated_at": "2023-06-11T08:00:00Z",
+  "updated_at": "2023-06-11T08:00:00Z",
+  "medias": [
+    {
+      "id": "id1",
+      "filename": "photo.jpg",
+      "file_size": 5,
+      "captured_at": "0001-01-01T00:00:00Z",
+      "type": "Photo"
+    },
+    {
+      "id": "id2",
+      "filename": "GX010002.MP4",
+      "file_size": 20,
+      "captured_at": "2023-06-10T12:34:56Z",
+      "processing_state": "ready",
+      "type": "Video",
+      "camera_model": "HERO11 Black",
+      "created_at": "2023-06-11T07:00:00Z",
+      "content_title": "Ride",
+      "duration_ms": 63000,
+      "width": 3840,
+      "height": 2160,
+      "gopro_user_id": "user1"
+    }
+  ]
+}
+`,
+			},
+		},
+		{
+			name:   "happy path, ndjson",
+			fields: fields{format: verifyrun.FormatNDJSON},
+			want: want{
+				stdout: `{"id":"id1","filename":"photo.jpg","file_size":5,"captured_at":"0001-01-01T00:00:00Z","type":"Photo"}
+{"id":"id2","filename":"GX010002.MP4","file_size":20,"captured_at":"2023-06-10T12:34:56Z","processing_state":"ready","type":"Video","camera_model":"HERO11 Black","created_at":"2023-06-11T07:00:00Z","content_title":"Ride","duration_ms":63000,"width":3840,"height":2160,"gopro_user_id":"user1"}
+`,
+			},
+		},
+		{
+			name:   "happy path, csv",
+			fields: fields{format: verifyrun.FormatCSV},
+			want: want{
+				stdout: `id,filename,file_size,type,captured_at,processing_state,camera_model,created_at,content_title,duration_ms,width,height,gopro_user_id
+id1,photo.jpg,5,Photo,,,,,,,,,
+id2,GX010002.MP4,20,Video,2023-06-10T12:34:56Z,ready,HERO11 Black,2023-06-11T07:00:00Z,Ride,63000,3840,2160,user1
+`,
+			},
+		},
+		{
+			name:   "sad path, text is not an export format",
+			fields: fields{format: verifyrun.FormatText},
+			want: want{
+				err: "invalid format: text",
+			},
+		},
+		{
+			name: "sad path, incomplete listing fails the export",
+			fields: fields{
+				format:   verifyrun.FormatJSON,
+				fetchErr: fetch.NewIncompleteListingError(3, 2),
+			},
+			want: want{
+				err: "error getting remote files: incomplete remote listing: expected 3 medias, received 2",
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+				return &client.Client{}, nil
+			}
+
+			buildFetcher := func(c *client.Client, opts ...func(fetcher *fetch.Fetcher)) exportrun.Fetcher {
+				fetcher := mocks.NewMockFetcher(mockCtrl)
+				fetcher.EXPECT().GetMedias(gomock.Any()).Return(append([]fetch.Media{}, medias...), tt.fields.fetchErr)
+
+				return fetcher
+			}
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+			err := exportrun.NewRunner(
+				"",
+				tt.fields.format,
+				verifyrun.TokenPromptMethodInput,
+				exportrun.WithBuildClient(buildClient),
+				exportrun.WithBuildFetcher(buildFetcher),
+				exportrun.WithOutput(stdout, stderr),
+				exportrun.WithNow(func() time.Time { return exportedAt }),
+			).Run(context.Background())
+			if tt.want.err != "" {
+				assert.EqualError(t, err, tt.want.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.stdout, stdout.String())
+		})
+	}
+}
+
+func TestRunner_Run_ReadableByVerify(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	exportedAt := time.Date(2023, 6, 11, 8, 0, 0, 0, time.UTC)
+	medias := []fetch.Media{fetch.NewMedia("GX010001.MP4", 10,
+		fetch.WithMediaID("id1"),
+		fetch.WithMediaCameraModel("HERO11 Black"),
+		fetch.WithMediaCreatedAt(time.Date(2023, 6, 11, 7, 0, 0, 0, time.UTC)),
+		fetch.WithMediaSourceDuration(63*time.Second),
+		fetch.WithMediaDimensions(3840, 2160),
+	)}
+
+	buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+		return &client.Client{}, nil
+	}
+
+	buildFetcher := func(c *client.Client, opts ...func(fetcher *fetch.Fetcher)) exportrun.Fetcher {
+		fetcher := mocks.NewMockFetcher(mockCtrl)
+		fetcher.EXPECT().GetMedias(gomock.Any()).Return(medias, nil)
+
+		return fetcher
+	}
+
+	outputFilePath := filepath.Join(t.TempDir(), "library.json")
+	stderr := &bytes.Buffer{}
+
+	err := exportrun.NewRunner(
+		outputFilePath,
+		verifyrun.FormatJSON,
+		verifyrun.TokenPromptMethodInput,
+		exportrun.WithBuildClient(buildClient),
+		exportrun.WithBuildFetcher(buildFetcher),
+		exportrun.WithOutput(&bytes.Buffer{}, stderr),
+		exportrun.WithNow(func() time.Time { return exportedAt }),
+	).Run(context.Background())
+	assert.NoError(t, err)
+	assert.Contains(t, stderr.String(), "Exported 1 medias to "+outputFilePath)
+
+	snapshot, err := indexcache.LoadSnapshot(outputFilePath)
+	assert.NoError(t, err)
+
+	got, err := snapshot.GetMedias(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, medias, got)
+
+	var source verify.SnapshotSource = snapshot
+	assert.Equal(t, exportedAt, source.SnapshotTakenAt().UTC())
+}
@@ -0,0 +1,83 @@
+package fakeapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"os"
+	"sort"
+	"time"
+)
+
+// Media is a media item of the fake library, it's encoded the same way as media/search returns it
+type Media struct {
+	ID             string    `json:"id"`
+	FileName       string    `json:"filename"`
+	FileSize       int64     `json:"file_size"`
+	CapturedAt     time.Time `json:"captured_at"`
+	ReadyToView    string    `json:"ready_to_view,omitempty"`
+	Type           string    `json:"type,omitempty"`
+	CameraModel    string    `json:"camera_model,omitempty"`
+	CreatedAt      time.Time `json:"created_at,omitempty"`
+	SourceDuration int64     `json:"source_duration,omitempty"`
+	Width          int       `json:"width,omitempty"`
+	Height         int       `json:"height,omitempty"`
+}
+
+// Library is the list of medias served by the fake server, ordered by capture date
+type Library struct {
+	medias []Media
+}
+
+func NewLibrary(medias []Media) Library {
+	medias = append([]Media{}, medias...)
+	sort.SliceStable(medias, func(i, j int) bool {
+		return medias[i].CapturedAt.Before(medias[j].CapturedAt)
+	})
+
+	return Library{medias: medias}
+}
+
+// LoadLibrary reads the library from a JSON fixture with a list of medias
+func LoadLibrary(filePath string) (library Library, err error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return Library{}, errors.Wrap(err, "error reading library fixture")
+	}
+
+	var medias []Media
+	if err = json.Unmarshal(data, &medias); err != nil {
+		return Library{}, errors.Wrapf(err, "error decoding library fixture: %s", filePath)
+	}
+
+	return NewLibrary(medias), nil
+}
+
+// GenerateLibrary creates a library of the given size.
+// The same count always gives the same medias, so tests can rely on the names and sizes.
+func GenerateLibrary(count int) Library {
+	capturedAt := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	medias := make([]Media, 0, count)
+	for i := 1; i <= count; i++ {
+		medias = append(medias, Media{
+			ID:             fmt.Sprintf("media%d", i),
+			FileName:       fmt.Sprintf("GX%02d%04d.MP4", 1+i/10000, i%10000),
+			FileSize:       int64(1000000 + i*1000),
+			CapturedAt:     capturedAt.Add(time.Duration(i) * time.Hour),
+			ReadyToView:    "ready",
+			Type:           "Video",
+			CameraModel:    "HERO11 Black",
+			CreatedAt:      capturedAt.Add(time.Duration(i)*time.Hour + 24*time.Hour),
+			SourceDuration: int64(60000 + i*1000),
+			Width:          3840,
+			Height:         2160,
+		})
+	}
+
+	return NewLibrary(medias)
+}
+
+func (l Library) Medias() []Media {
+	return l.medias
+}
@@ -0,0 +1,76 @@
+package fakeapi_test
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/fakeapi"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadLibrary(t *testing.T) {
+	type want struct {
+		medias []fakeapi.Media
+		err    string
+	}
+
+	tests := []struct {
+		name    string
+		fixture string
+		want
+	}{
+		{
+			name: "happy path, medias are ordered by capture date",
+			fixture: `[
+				{"id": "2", "filename": "GX010002.MP4", "file_size": 20, "captured_at": "2023-01-02T10:00:00Z"},
+				{"id": "1", "filename": "GX010001.MP4", "file_size": 10, "captured_at": "2023-01-01T10:00:00Z"}
+			]`,
+			want: want{
+				medias: []fakeapi.Media{
+					{ID: "1", FileName: "GX010001.MP4", FileSize: 10, CapturedAt: time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)},
+					{ID: "2", FileName: "GX010002.MP4", FileSize: 20, CapturedAt: time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)},
+				},
+			},
+		},
+		{
+			name:    "sad path, invalid JSON",
+			fixture: `{`,
+			want: want{
+				err: "error decoding library fixture",
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			filePath := filepath.Join(t.TempDir(), "library.json")
+			assert.NoError(t, os.WriteFile(filePath, []byte(tt.fixture), 0644))
+
+			library, err := fakeapi.LoadLibrary(filePath)
+			if tt.want.err != "" {
+				assert.ErrorContains(t, err, tt.want.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.medias, library.Medias())
+		})
+	}
+}
+
+func TestGenerateLibrary(t *testing.T) {
+	t.Parallel()
+
+	library := fakeapi.GenerateLibrary(3)
+
+	assert.Len(t, library.Medias(), 3)
+	assert.Equal(t, fakeapi.GenerateLibrary(3), library)
+	assert.Equal(t, "GX010001.MP4", library.Medias()[0].FileName)
+}
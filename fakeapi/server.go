@@ -0,0 +1,326 @@
+package fakeapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultToken is the only bearer token the server accepts unless another one is set with WithToken
+	DefaultToken = "fake-token"
+
+	// PathFaults is the control endpoint to inject faults into a running server, it doesn't require a token
+	PathFaults = "/_fakeapi/faults"
+
+	pathNotifications = "/notification_center/notifications"
+	pathMediaSearch   = "/media/search"
+
+	defaultPerPage = 30
+)
+
+// Fault makes the server fail the next requests with the given status code instead of serving them
+type Fault struct {
+	StatusCode int `json:"status_code"`
+	// Path limits the fault to requests of a single path, e.g. /media/search. Empty path matches every request.
+	Path string `json:"path"`
+	// Times is how many requests fail, at least one
+	Times int `json:"times"`
+	// RetryAfter is sent in the Retry-After header in seconds when it's above zero
+	RetryAfter int `json:"retry_after"`
+}
+
+// Server emulates the parts of Gopro Media Library API used by the verifier
+type Server struct {
+	library         Library
+	token           string
+	incompletePages int
+	mu              *sync.Mutex
+	faults          []Fault
+	pageAttempts    map[string]int
+}
+
+func NewServer(library Library, opts ...func(*Server)) *Server {
+	s := &Server{
+		library:      library,
+		token:        DefaultToken,
+		mu:           &sync.Mutex{},
+		faults:       []Fault{},
+		pageAttempts: map[string]int{},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func WithToken(token string) func(s *Server) {
+	return func(s *Server) {
+		s.token = token
+	}
+}
+
+// WithIncompletePages makes every page come back with one media less the first given times it's requested.
+// That's how the real API behaves sometimes, the client has to ask for the page again.
+func WithIncompletePages(times int) func(s *Server) {
+	return func(s *Server) {
+		s.incompletePages = times
+	}
+}
+
+// InjectFault queues a fault, the faults are applied in the order they were injected
+func (s *Server) InjectFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if fault.Times < 1 {
+		fault.Times = 1
+	}
+
+	s.faults = append(s.faults, fault)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == PathFaults {
+		s.handleFaults(w, r)
+
+		return
+	}
+
+	if r.Header.Get("Authorization") != "Bearer "+s.token {
+		writeError(w, http.StatusUnauthorized, "invalid bearer token")
+
+		return
+	}
+
+	if fault, ok := s.takeFault(r.URL.Path); ok {
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(fault.RetryAfter))
+		}
+		writeError(w, fault.StatusCode, "injected fault")
+
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == pathNotifications:
+		writeJSON(w, map[string]interface{}{
+			"_embedded": map[string]interface{}{
+				"notifications": []interface{}{},
+			},
+		})
+	case r.Method == http.MethodGet && r.URL.Path == pathMediaSearch:
+		s.handleMediaSearch(w, r)
+	default:
+		writeError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) handleFaults(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+
+		return
+	}
+
+	var fault Fault
+	if err := json.NewDecoder(r.Body).Decode(&fault); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid fault: %s", err))
+
+		return
+	}
+
+	if fault.StatusCode < 400 || fault.StatusCode > 599 {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid fault status code: %d", fault.StatusCode))
+
+		return
+	}
+
+	s.InjectFault(fault)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleMediaSearch(w http.ResponseWriter, r *http.Request) {
+	pageNumber, err := getPositiveInt(r, "page", 1)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	perPage, err := getPositiveInt(r, "per_page", defaultPerPage)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	medias, err := getMediasInRange(r, s.library.Medias())
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+
+		return
+	}
+
+	totalPages := (len(medias) + perPage - 1) / perPage
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	pageMedias := []Media{}
+	if start := (pageNumber - 1) * perPage; start < len(medias) {
+		end := start + perPage
+		if end > len(medias) {
+			end = len(medias)
+		}
+		pageMedias = medias[start:end]
+	}
+
+	if len(pageMedias) > 0 && s.isIncompleteAttempt(pageNumber, perPage) {
+		pageMedias = pageMedias[:len(pageMedias)-1]
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"_pages": map[string]int{
+			"current_page": pageNumber,
+			"per_page":     perPage,
+			"total_items":  len(medias),
+			"total_pages":  totalPages,
+		},
+		"_embedded": map[string]interface{}{
+			"errors": []interface{}{},
+			"media":  pageMedias,
+		},
+	})
+}
+
+func (s *Server) takeFault(path string) (fault Fault, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.faults {
+		if s.faults[i].Path != "" && s.faults[i].Path != path {
+			continue
+		}
+
+		fault = s.faults[i]
+
+		s.faults[i].Times--
+		if s.faults[i].Times == 0 {
+			s.faults = append(s.faults[:i], s.faults[i+1:]...)
+		}
+
+		return fault, true
+	}
+
+	return Fault{}, false
+}
+
+func (s *Server) isIncompleteAttempt(pageNumber, perPage int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := fmt.Sprintf("%d/%d", pageNumber, perPage)
+	s.pageAttempts[key]++
+
+	return s.pageAttempts[key] <= s.incompletePages
+}
+
+// getMediasInRange filters the medias by the captured_range parameter, both ends are inclusive
+func getMediasInRange(r *http.Request, medias []Media) (mediasInRange []Media, err error) {
+	rawRange := r.URL.Query().Get("captured_range")
+	if rawRange == "" {
+		return medias, nil
+	}
+
+	rawFrom, rawTo, found := strings.Cut(rawRange, ",")
+	if !found {
+		return nil, fmt.Errorf("invalid captured_range: %s", rawRange)
+	}
+
+	from, err := time.Parse(time.RFC3339, rawFrom)
+	if err != nil {
+		return nil, fmt.Errorf("invalid captured_range: %s", rawRange)
+	}
+
+	to, err := time.Parse(time.RFC3339, rawTo)
+	if err != nil {
+		return nil, fmt.Errorf("invalid captured_range: %s", rawRange)
+	}
+
+	mediasInRange = []Media{}
+	for _, media := range medias {
+		if !media.CapturedAt.Before(from) && !media.CapturedAt.After(to) {
+			mediasInRange = append(mediasInRange, media)
+		}
+	}
+
+	return mediasInRange, nil
+}
+
+func getPositiveInt(r *http.Request, key string, defaultValue int) (value int, err error) {
+	rawValue := r.URL.Query().Get(key)
+	if rawValue == "" {
+		return defaultValue, nil
+	}
+
+	value, err = strconv.Atoi(rawValue)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("invalid %s: %s", key, rawValue)
+	}
+
+	return value, nil
+}
+
+func writeJSON(w http.ResponseWriter, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(payload)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, description string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"_errors": []map[string]interface{}{
+			{"code": statusCode, "description": description},
+		},
+	})
+}
+
+// ListenAndServe serves the fake API on the given address until the context is done
+func (s *Server) ListenAndServe(ctx context.Context, address string) (err error) {
+	httpServer := &http.Server{
+		Addr:              address,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err = <-errs:
+		return errors.Wrap(err, "error serving fake API")
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err = httpServer.Shutdown(shutdownCtx); err != nil {
+		return errors.Wrap(err, "error shutting down fake API")
+	}
+
+	return nil
+}
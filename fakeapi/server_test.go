@@ -0,0 +1,264 @@
+package fakeapi_test
+
+import (
+	"context"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/fakeapi"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestServer_MediaSearch(t *testing.T) {
+	type fields struct {
+		libraryCount int
+		token        string
+		opts         []func(*fakeapi.Server)
+		faults       []fakeapi.Fault
+	}
+
+	type want struct {
+		mediaCount int
+		err        string
+	}
+
+	tests := []struct {
+		name string
+		fields
+		want
+	}{
+		{
+			name: "happy path, all pages are fetched",
+			fields: fields{
+				libraryCount: 25,
+				token:        fakeapi.DefaultToken,
+			},
+			want: want{
+				mediaCount: 25,
+			},
+		},
+		{
+			name: "happy path, incomplete pages are requested again",
+			fields: fields{
+				libraryCount: 25,
+				token:        fakeapi.DefaultToken,
+				opts:         []func(*fakeapi.Server){fakeapi.WithIncompletePages(2)},
+			},
+			want: want{
+				mediaCount: 25,
+			},
+		},
+		{
+			name: "happy path, empty library",
+			fields: fields{
+				token: fakeapi.DefaultToken,
+			},
+			want: want{
+				mediaCount: 0,
+			},
+		},
+		{
+			name: "happy path, custom token",
+			fields: fields{
+				libraryCount: 5,
+				token:        "custom",
+				opts:         []func(*fakeapi.Server){fakeapi.WithToken("custom")},
+			},
+			want: want{
+				mediaCount: 5,
+			},
+		},
+		{
+			name: "sad path, wrong token",
+			fields: fields{
+				libraryCount: 5,
+				token:        "wrong",
+			},
+			want: want{
+				err: "401 Unauthorized",
+			},
+		},
+		{
+			name: "happy path, injected server error is retried",
+			fields: fields{
+				libraryCount: 5,
+				token:        fakeapi.DefaultToken,
+				faults:       []fakeapi.Fault{{StatusCode: http.StatusInternalServerError, Path: "/media/search"}},
+			},
+			want: want{
+				mediaCount: 5,
+			},
+		},
+		{
+			name: "sad path, injected server error persists",
+			fields: fields{
+				libraryCount: 5,
+				token:        fakeapi.DefaultToken,
+				faults:       []fakeapi.Fault{{StatusCode: http.StatusInternalServerError, Path: "/media/search", Times: 5}},
+			},
+			want: want{
+				err: "giving up after 5 attempts: 500 Internal Server Error",
+			},
+		},
+		{
+			name: "sad path, injected rate limit persists",
+			fields: fields{
+				libraryCount: 5,
+				token:        fakeapi.DefaultToken,
+				faults:       []fakeapi.Fault{{StatusCode: http.StatusTooManyRequests, RetryAfter: 1, Times: 5}},
+			},
+			want: want{
+				err: "giving up after 5 attempts: 429 Too Many Requests",
+			},
+		},
+		{
+			name: "happy path, fault for another path is not applied",
+			fields: fields{
+				libraryCount: 5,
+				token:        fakeapi.DefaultToken,
+				faults:       []fakeapi.Fault{{StatusCode: http.StatusUnauthorized, Path: "/notification_center/notifications"}},
+			},
+			want: want{
+				mediaCount: 5,
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := fakeapi.NewServer(fakeapi.GenerateLibrary(tt.libraryCount), tt.opts...)
+			for _, fault := range tt.faults {
+				server.InjectFault(fault)
+			}
+
+			httpServer := httptest.NewServer(server)
+			defer httpServer.Close()
+
+			c, err := client.NewClient(tt.token, client.WithBaseURL(httpServer.URL), client.WithSleep(noSleep))
+			assert.NoError(t, err)
+
+			medias, err := fetch.NewFetcher(c, fetch.WithPerPage(10)).GetMedias(context.Background())
+			if tt.want.err != "" {
+				assert.ErrorContains(t, err, tt.want.err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, medias, tt.want.mediaCount)
+		})
+	}
+}
+
+func TestServer_MediaSearch_Windows(t *testing.T) {
+	t.Parallel()
+
+	httpServer := httptest.NewServer(fakeapi.NewServer(fakeapi.GenerateLibrary(95)))
+	defer httpServer.Close()
+
+	c, err := client.NewClient(fakeapi.DefaultToken, client.WithBaseURL(httpServer.URL), client.WithSleep(noSleep))
+	assert.NoError(t, err)
+
+	fetcher := fetch.NewFetcher(c,
+		fetch.WithPerPage(10),
+		fetch.WithStrategy(fetch.StrategyWindows),
+		fetch.WithMaxWindowItems(20),
+	)
+
+	medias, err := fetcher.GetMedias(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, medias, 95)
+}
+
+func TestServer_AuthCheck(t *testing.T) {
+	t.Parallel()
+
+	server := fakeapi.NewServer(fakeapi.GenerateLibrary(1))
+	server.InjectFault(fakeapi.Fault{StatusCode: http.StatusUnauthorized, Times: 2})
+
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	c, err := client.NewClient(fakeapi.DefaultToken, client.WithBaseURL(httpServer.URL))
+	assert.NoError(t, err)
+
+	assert.ErrorContains(t, c.AuthCheck(context.Background()), "401 Unauthorized")
+	assert.ErrorContains(t, c.AuthCheck(context.Background()), "401 Unauthorized")
+	assert.NoError(t, c.AuthCheck(context.Background()))
+}
+
+func TestServer_Faults(t *testing.T) {
+	type want struct {
+		statusCode int
+		retryAfter string
+	}
+
+	tests := []struct {
+		name string
+		body string
+		want
+	}{
+		{
+			name: "happy path, fault is injected",
+			body: `{"status_code": 429, "retry_after": 3}`,
+			want: want{
+				statusCode: http.StatusTooManyRequests,
+				retryAfter: "3",
+			},
+		},
+		{
+			name: "sad path, invalid status code",
+			body: `{"status_code": 200}`,
+			want: want{
+				statusCode: http.StatusOK,
+			},
+		},
+		{
+			name: "sad path, invalid JSON",
+			body: `{`,
+			want: want{
+				statusCode: http.StatusOK,
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			httpServer := httptest.NewServer(fakeapi.NewServer(fakeapi.GenerateLibrary(1)))
+			defer httpServer.Close()
+
+			resp, err := http.Post(httpServer.URL+fakeapi.PathFaults, "application/json", strings.NewReader(tt.body))
+			assert.NoError(t, err)
+			assert.NoError(t, resp.Body.Close())
+
+			req, err := http.NewRequest(http.MethodGet, httpServer.URL+"/media/search", nil)
+			assert.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+fakeapi.DefaultToken)
+
+			resp, err = http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			assert.NoError(t, resp.Body.Close())
+
+			assert.Equal(t, tt.want.statusCode, resp.StatusCode)
+			assert.Equal(t, tt.want.retryAfter, resp.Header.Get("Retry-After"))
+		})
+	}
+}
+
+func noSleep(ctx context.Context, d time.Duration) error {
+	return nil
+}
@@ -0,0 +1,30 @@
+package fetch
+
+import "fmt"
+
+// IncompleteListingError is returned when the number of fetched medias doesn't match the total reported by the API
+type IncompleteListingError struct {
+	expected int
+	received int
+}
+
+func NewIncompleteListingError(expected, received int) error {
+	return IncompleteListingError{
+		expected: expected,
+		received: received,
+	}
+}
+
+func (e IncompleteListingError) Error() string {
+	return fmt.Sprintf("incomplete remote listing: expected %d medias, received %d", e.expected, e.received)
+}
+
+// Expected is the total number of medias reported by the API
+func (e IncompleteListingError) Expected() int {
+	return e.expected
+}
+
+// Received is the number of medias actually fetched
+func (e IncompleteListingError) Received() int {
+	return e.received
+}
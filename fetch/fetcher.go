@@ -1,23 +1,60 @@
 package fetch
 
 import (
+	"context"
+	"fmt"
 	"github.com/legosx/gopro-media-library-verifier/client"
 	"github.com/pkg/errors"
+	"strings"
+	"time"
 )
 
 type Client interface {
-	GetPage(pageNumber, perPage int) (page client.Page, err error)
+	GetPage(ctx context.Context, pageNumber, perPage int) (page client.Page, err error)
+	GetPageInRange(ctx context.Context, capturedRange client.CapturedRange, pageNumber, perPage int) (page client.Page, err error)
+}
+
+// Strategy tells how the medias are listed from Gopro Media Library
+type Strategy string
+
+const (
+	// StrategyPages walks through the pages of the whole library
+	StrategyPages Strategy = "pages"
+	// StrategyWindows splits the library into captured_at windows small enough to be listed reliably
+	StrategyWindows Strategy = "windows"
+)
+
+// Strategies lists all the supported strategies
+var Strategies = []Strategy{StrategyPages, StrategyWindows}
+
+func (s Strategy) Validate() error {
+	for _, strategy := range Strategies {
+		if s == strategy {
+			return nil
+		}
+	}
+
+	names := make([]string, 0, len(Strategies))
+	for _, strategy := range Strategies {
+		names = append(names, string(strategy))
+	}
+
+	return fmt.Errorf("invalid fetch strategy: %s, expected one of: %s", s, strings.Join(names, ", "))
 }
 
 type Fetcher struct {
-	client  Client
-	perPage int
+	client         Client
+	perPage        int
+	strategy       Strategy
+	maxWindowItems int
 }
 
 func NewFetcher(client Client, opts ...func(fetcher *Fetcher)) Fetcher {
 	f := Fetcher{
-		client:  client,
-		perPage: 250,
+		client:         client,
+		perPage:        250,
+		strategy:       StrategyPages,
+		maxWindowItems: 2500,
 	}
 
 	for _, opt := range opts {
@@ -33,8 +70,87 @@ func WithPerPage(perPage int) func(f *Fetcher) {
 	}
 }
 
-func (f Fetcher) GetMedias() (medias []Media, err error) {
-	medias = []Media{}
+func WithStrategy(strategy Strategy) func(f *Fetcher) {
+	return func(f *Fetcher) {
+		f.strategy = strategy
+	}
+}
+
+// WithMaxWindowItems sets how many medias a captured_at window may hold before it's split in two,
+// only used by StrategyWindows
+func WithMaxWindowItems(maxWindowItems int) func(f *Fetcher) {
+	return func(f *Fetcher) {
+		f.maxWindowItems = maxWindowItems
+	}
+}
+
+// GetMedias fetches all the pages of Gopro Media Library in parallel.
+// The pages still in flight are cancelled as soon as one of them fails or the context is done.
+// When the number of fetched medias doesn't match the total reported by the API,
+// the medias are returned together with IncompleteListingError, so the caller can decide whether to use them.
+func (f Fetcher) GetMedias(ctx context.Context) (medias []Media, err error) {
+	if f.strategy == StrategyWindows {
+		return f.getMediasInWindows(ctx)
+	}
+
+	firstPage, err := f.client.GetPage(ctx, 1, f.perPage)
+	if err != nil {
+		return []Media{}, errors.Wrap(err, "error getting medias")
+	}
+
+	medias, err = f.getAllPages(ctx, firstPage, func(ctx context.Context, pageNumber int) (page client.Page, err error) {
+		return f.client.GetPage(ctx, pageNumber, f.perPage)
+	})
+	if err != nil {
+		return []Media{}, err
+	}
+
+	if totalItems := firstPage.TotalItems(); totalItems >= 0 && len(medias) != totalItems {
+		return medias, NewIncompleteListingError(totalItems, len(medias))
+	}
+
+	return medias, nil
+}
+
+// GetMediasInRange fetches the medias captured within the range, e.g. the ones newer than a cached index
+func (f Fetcher) GetMediasInRange(ctx context.Context, capturedRange client.CapturedRange) (medias []Media, err error) {
+	getPage := func(ctx context.Context, pageNumber int) (page client.Page, err error) {
+		return f.client.GetPageInRange(ctx, capturedRange, pageNumber, f.perPage)
+	}
+
+	firstPage, err := getPage(ctx, 1)
+	if err != nil {
+		return []Media{}, errors.Wrap(err, "error getting medias")
+	}
+
+	if medias, err = f.getAllPages(ctx, firstPage, getPage); err != nil {
+		return []Media{}, err
+	}
+
+	if totalItems := firstPage.TotalItems(); totalItems >= 0 && len(medias) != totalItems {
+		return medias, NewIncompleteListingError(totalItems, len(medias))
+	}
+
+	return medias, nil
+}
+
+// CountMedias returns the number of medias in the whole library as reported by the API, -1 when unknown
+func (f Fetcher) CountMedias(ctx context.Context) (count int, err error) {
+	page, err := f.client.GetPage(ctx, 1, 1)
+	if err != nil {
+		return 0, errors.Wrap(err, "error counting medias")
+	}
+
+	return page.TotalItems(), nil
+}
+
+// getAllPages collects the medias of the first page and fetches the rest of the pages in parallel
+func (f Fetcher) getAllPages(
+	ctx context.Context,
+	firstPage client.Page,
+	getPage func(ctx context.Context, pageNumber int) (page client.Page, err error),
+) (medias []Media, err error) {
+	medias = f.convertClientMedias(firstPage.Medias())
 
 	handleResult := func(result getPageResult) error {
 		if err = result.err; err != nil {
@@ -50,21 +166,29 @@ func (f Fetcher) GetMedias() (medias []Media, err error) {
 
 	maxConcurrentCalls := 10
 
-	result := newGetPageResult(f.client.GetPage(1, f.perPage))
-	if err = handleResult(result); err != nil {
-		return []Media{}, err
-	}
+	totalPages := firstPage.TotalPages()
 
-	totalPages := result.page.TotalPages()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
 	resultCh := make(chan getPageResult, totalPages)
 	sem := make(chan struct{}, maxConcurrentCalls)
 	for pageNumber := 2; pageNumber <= totalPages; pageNumber++ {
 		go func(pageNumber int) {
-			sem <- struct{}{}
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				resultCh <- newGetPageResult(client.Page{}, ctx.Err())
+				return
+			}
 			defer func() { <-sem }()
 
-			resultCh <- newGetPageResult(f.client.GetPage(pageNumber, f.perPage))
+			if err := ctx.Err(); err != nil {
+				resultCh <- newGetPageResult(client.Page{}, err)
+				return
+			}
+
+			resultCh <- newGetPageResult(getPage(ctx, pageNumber))
 		}(pageNumber)
 	}
 
@@ -77,9 +201,141 @@ func (f Fetcher) GetMedias() (medias []Media, err error) {
 	return medias, nil
 }
 
+// getMediasInWindows lists the library window by window, so deep pagination isn't needed for large libraries.
+// The windows overlap on their bounds, the medias are deduplicated and reconciled against the total afterwards.
+func (f Fetcher) getMediasInWindows(ctx context.Context) (medias []Media, err error) {
+	// With one media per page the first and the last pages hold the bounds of the library
+	firstPage, err := f.client.GetPage(ctx, 1, 1)
+	if err != nil {
+		return []Media{}, errors.Wrap(err, "error getting medias")
+	}
+
+	totalItems := firstPage.TotalItems()
+	if len(firstPage.Medias()) == 0 {
+		if totalItems > 0 {
+			return []Media{}, NewIncompleteListingError(totalItems, 0)
+		}
+
+		return []Media{}, nil
+	}
+
+	first := firstPage.Medias()[0].CapturedAt()
+	last := first
+	if lastPageNumber := firstPage.TotalPages(); lastPageNumber > 1 {
+		lastPage, err := f.client.GetPage(ctx, lastPageNumber, 1)
+		if err != nil {
+			return []Media{}, errors.Wrap(err, "error getting medias")
+		}
+
+		if len(lastPage.Medias()) > 0 {
+			last = lastPage.Medias()[0].CapturedAt()
+		}
+	}
+
+	if last.Before(first) {
+		first, last = last, first
+	}
+
+	windowMedias, err := f.getWindowMedias(ctx, newSecondsRange(first, last))
+	if err != nil {
+		return []Media{}, err
+	}
+
+	medias = dedupeMedias(windowMedias)
+
+	if totalItems >= 0 && len(medias) != totalItems {
+		return medias, NewIncompleteListingError(totalItems, len(medias))
+	}
+
+	return medias, nil
+}
+
+// getWindowMedias fetches the medias captured within the range, splitting it while it holds too many of them
+func (f Fetcher) getWindowMedias(ctx context.Context, capturedRange client.CapturedRange) (medias []Media, err error) {
+	getPage := func(ctx context.Context, pageNumber int) (page client.Page, err error) {
+		return f.client.GetPageInRange(ctx, capturedRange, pageNumber, f.perPage)
+	}
+
+	firstPage, err := getPage(ctx, 1)
+	if err != nil {
+		return []Media{}, errors.Wrap(err, "error getting medias")
+	}
+
+	if firstPage.TotalItems() > f.maxWindowItems {
+		// The API filters by whole seconds, a window of a single second can't be split anymore
+		middle := capturedRange.From.Add(capturedRange.To.Sub(capturedRange.From) / 2).Truncate(time.Second)
+		if middle.After(capturedRange.From) && middle.Before(capturedRange.To) {
+			left, err := f.getWindowMedias(ctx, client.CapturedRange{From: capturedRange.From, To: middle})
+			if err != nil {
+				return []Media{}, err
+			}
+
+			right, err := f.getWindowMedias(ctx, client.CapturedRange{From: middle, To: capturedRange.To})
+			if err != nil {
+				return []Media{}, err
+			}
+
+			return append(left, right...), nil
+		}
+	}
+
+	return f.getAllPages(ctx, firstPage, getPage)
+}
+
+// newSecondsRange widens the range to whole seconds, as the bounds are sent to the API without the fraction
+func newSecondsRange(from, to time.Time) client.CapturedRange {
+	capturedRange := client.CapturedRange{
+		From: from.Truncate(time.Second),
+		To:   to.Truncate(time.Second),
+	}
+
+	if capturedRange.To.Before(to) {
+		capturedRange.To = capturedRange.To.Add(time.Second)
+	}
+
+	return capturedRange
+}
+
+// dedupeMedias drops the medias listed twice, e.g. on the bound shared by two windows
+func dedupeMedias(medias []Media) (deduped []Media) {
+	deduped = make([]Media, 0, len(medias))
+	seen := make(map[string]struct{}, len(medias))
+
+	for _, media := range medias {
+		key := media.ID()
+		if key == "" {
+			key = fmt.Sprintf("%s|%d|%s", media.FileName(), media.FileSize(), media.CapturedAt().Format(time.RFC3339Nano))
+		}
+
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		deduped = append(deduped, media)
+	}
+
+	return deduped
+}
+
 func (f Fetcher) convertClientMedias(medias []client.Media) (convertedMedias []Media) {
+	convertedMedias = []Media{}
+
 	for _, media := range medias {
-		convertedMedias = append(convertedMedias, NewMedia(media.FileName(), media.FileSize()))
+		convertedMedias = append(convertedMedias, NewMedia(
+			media.FileName(),
+			media.FileSize(),
+			WithMediaID(media.ID()),
+			WithMediaCapturedAt(media.CapturedAt()),
+			WithMediaProcessingState(media.ProcessingState()),
+			WithMediaType(media.Type()),
+			WithMediaCameraModel(media.CameraModel()),
+			WithMediaCreatedAt(media.CreatedAt()),
+			WithMediaContentTitle(media.ContentTitle()),
+			WithMediaSourceDuration(media.SourceDuration()),
+			WithMediaDimensions(media.Width(), media.Height()),
+			WithMediaGoProUserID(media.GoProUserID()),
+		))
 	}
 
 	return convertedMedias
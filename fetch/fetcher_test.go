@@ -1,6 +1,8 @@
 package fetch_test
 
 import (
+	"context"
+	"fmt"
 	"github.com/legosx/gopro-media-library-verifier/client"
 	"github.com/legosx/gopro-media-library-verifier/fetch"
 	"github.com/legosx/gopro-media-library-verifier/fetch/mocks"
@@ -9,6 +11,7 @@ import (
 	"go.uber.org/mock/gomock"
 	"sort"
 	"testing"
+	"time"
 )
 
 //go:generate mockgen -destination=./mocks/client.go -package=mocks github.com/legosx/gopro-media-library-verifier/fetch Client
@@ -34,14 +37,25 @@ func TestFetcher_GetMedias(t *testing.T) {
 			fields: fields{
 				client: func(mockCtrl *gomock.Controller) fetch.Client {
 					mock := mocks.NewMockClient(mockCtrl)
-					mock.EXPECT().GetPage(gomock.Any(), gomock.Any()).Times(3).DoAndReturn(func(pageNumber, perPage int) (page client.Page, err error) {
+					mock.EXPECT().GetPage(gomock.Any(), gomock.Any(), gomock.Any()).Times(3).DoAndReturn(func(ctx context.Context, pageNumber, perPage int) (page client.Page, err error) {
 						assert.GreaterOrEqual(t, pageNumber, 1)
 						assert.LessOrEqual(t, pageNumber, 3)
 						assert.Equal(t, 2, perPage)
 
 						mediasPerPages := map[int][]client.Media{
 							1: {
-								client.NewMedia("file1.mp4", 10),
+								client.NewMedia("file1.mp4", 10,
+									client.WithMediaID("id1"),
+									client.WithMediaCapturedAt(time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)),
+									client.WithMediaCreatedAt(time.Date(2023, 6, 11, 8, 0, 0, 0, time.UTC)),
+									client.WithMediaProcessingState("ready"),
+									client.WithMediaType("Video"),
+									client.WithMediaContentTitle("Ride"),
+									client.WithMediaCameraModel("HERO11 Black"),
+									client.WithMediaSourceDuration(63*time.Second),
+									client.WithMediaDimensions(3840, 2160),
+									client.WithMediaGoProUserID("user1"),
+								),
 								client.NewMedia("file2.jpg", 20),
 							},
 							2: {
@@ -66,7 +80,18 @@ func TestFetcher_GetMedias(t *testing.T) {
 			},
 			want: want{
 				medias: []fetch.Media{
-					fetch.NewMedia("file1.mp4", 10),
+					fetch.NewMedia("file1.mp4", 10,
+						fetch.WithMediaID("id1"),
+						fetch.WithMediaCapturedAt(time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)),
+						fetch.WithMediaCreatedAt(time.Date(2023, 6, 11, 8, 0, 0, 0, time.UTC)),
+						fetch.WithMediaProcessingState("ready"),
+						fetch.WithMediaType("Video"),
+						fetch.WithMediaContentTitle("Ride"),
+						fetch.WithMediaCameraModel("HERO11 Black"),
+						fetch.WithMediaSourceDuration(63*time.Second),
+						fetch.WithMediaDimensions(3840, 2160),
+						fetch.WithMediaGoProUserID("user1"),
+					),
 					fetch.NewMedia("file2.jpg", 20),
 					fetch.NewMedia("file3.mp4", 30),
 					fetch.NewMedia("file4.jpg", 40),
@@ -74,12 +99,83 @@ func TestFetcher_GetMedias(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "happy path, all medias reported by the API are fetched",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) fetch.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetPage(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).DoAndReturn(func(ctx context.Context, pageNumber, perPage int) (page client.Page, err error) {
+						mediasPerPages := map[int][]client.Media{
+							1: {
+								client.NewMedia("file1.mp4", 10),
+								client.NewMedia("file2.jpg", 20),
+							},
+							2: {
+								client.NewMedia("file3.mp4", 30),
+							},
+						}
+
+						return client.NewPage(2, mediasPerPages[pageNumber], client.WithPageTotalItems(3)), nil
+					})
+
+					return mock
+				},
+				opts: func(mockCtrl *gomock.Controller) []func(fetcher *fetch.Fetcher) {
+					return []func(fetcher *fetch.Fetcher){
+						fetch.WithPerPage(2),
+					}
+				},
+			},
+			want: want{
+				medias: []fetch.Media{
+					fetch.NewMedia("file1.mp4", 10),
+					fetch.NewMedia("file2.jpg", 20),
+					fetch.NewMedia("file3.mp4", 30),
+				},
+			},
+		},
+		{
+			name: "sad path, fewer medias fetched than reported by the API",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) fetch.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetPage(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).DoAndReturn(func(ctx context.Context, pageNumber, perPage int) (page client.Page, err error) {
+						mediasPerPages := map[int][]client.Media{
+							1: {
+								client.NewMedia("file1.mp4", 10),
+								client.NewMedia("file2.jpg", 20),
+							},
+							2: {
+								client.NewMedia("file3.mp4", 30),
+							},
+						}
+
+						return client.NewPage(2, mediasPerPages[pageNumber], client.WithPageTotalItems(4)), nil
+					})
+
+					return mock
+				},
+				opts: func(mockCtrl *gomock.Controller) []func(fetcher *fetch.Fetcher) {
+					return []func(fetcher *fetch.Fetcher){
+						fetch.WithPerPage(2),
+					}
+				},
+			},
+			want: want{
+				medias: []fetch.Media{
+					fetch.NewMedia("file1.mp4", 10),
+					fetch.NewMedia("file2.jpg", 20),
+					fetch.NewMedia("file3.mp4", 30),
+				},
+				err: errors.New("incomplete remote listing: expected 4 medias, received 3"),
+			},
+		},
 		{
 			name: "sad path, client error on page 1",
 			fields: fields{
 				client: func(mockCtrl *gomock.Controller) fetch.Client {
 					mock := mocks.NewMockClient(mockCtrl)
-					mock.EXPECT().GetPage(1, 250).Times(1).DoAndReturn(func(pageNumber, perPage int) (page client.Page, err error) {
+					mock.EXPECT().GetPage(gomock.Any(), 1, 250).Times(1).DoAndReturn(func(ctx context.Context, pageNumber, perPage int) (page client.Page, err error) {
 						return client.Page{}, assert.AnError
 					})
 
@@ -98,7 +194,7 @@ func TestFetcher_GetMedias(t *testing.T) {
 			fields: fields{
 				client: func(mockCtrl *gomock.Controller) fetch.Client {
 					mock := mocks.NewMockClient(mockCtrl)
-					mock.EXPECT().GetPage(gomock.Any(), gomock.Any()).Times(2).DoAndReturn(func(pageNumber, perPage int) (page client.Page, err error) {
+					mock.EXPECT().GetPage(gomock.Any(), gomock.Any(), gomock.Any()).Times(2).DoAndReturn(func(ctx context.Context, pageNumber, perPage int) (page client.Page, err error) {
 						assert.True(t, pageNumber == 1 || pageNumber == 2)
 
 						if pageNumber == 2 {
@@ -137,17 +233,244 @@ func TestFetcher_GetMedias(t *testing.T) {
 
 			fetcher := fetch.NewFetcher(tt.fields.client(mockCtrl), tt.fields.opts(mockCtrl)...)
 
-			got, err := fetcher.GetMedias()
+			got, err := fetcher.GetMedias(context.Background())
 			if tt.want.err == nil {
 				assert.Equal(t, sortList(tt.want.medias), sortList(got))
 				assert.NoError(t, err)
 			} else {
 				assert.EqualError(t, err, tt.want.err.Error())
 			}
+
+			// The medias are still returned when the listing is incomplete
+			if tt.want.medias != nil {
+				assert.Equal(t, sortList(tt.want.medias), sortList(got))
+			}
+		})
+	}
+}
+
+func TestFetcher_GetMedias_Cancel(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mock := mocks.NewMockClient(mockCtrl)
+	mock.EXPECT().GetPage(gomock.Any(), 1, 2).Times(1).DoAndReturn(func(ctx context.Context, pageNumber, perPage int) (page client.Page, err error) {
+		cancel()
+
+		return client.NewPage(3, []client.Media{
+			client.NewMedia("file1.mp4", 10),
+			client.NewMedia("file2.jpg", 20),
+		}), nil
+	})
+
+	got, err := fetch.NewFetcher(mock, fetch.WithPerPage(2)).GetMedias(ctx)
+
+	assert.Equal(t, []fetch.Media{}, got)
+	assert.EqualError(t, err, errors.Wrap(context.Canceled, "error getting medias").Error())
+}
+
+func TestFetcher_GetMedias_Windows(t *testing.T) {
+	type fields struct {
+		medias         []client.Media
+		missing        int
+		maxWindowItems int
+	}
+
+	type want struct {
+		mediaCount   int
+		splitWindows bool
+		err          string
+	}
+
+	start := time.Date(2023, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	generateMedias := func(count int) (medias []client.Media) {
+		for i := 0; i < count; i++ {
+			medias = append(medias, client.NewMedia(fmt.Sprintf("file%d.mp4", i), int64(i),
+				client.WithMediaID(fmt.Sprintf("id%d", i)),
+				client.WithMediaCapturedAt(start.Add(time.Duration(i)*time.Minute)),
+			))
+		}
+
+		return medias
+	}
+
+	tests := []struct {
+		name string
+		fields
+		want
+	}{
+		{
+			name: "happy path, library fits a single window",
+			fields: fields{
+				medias:         generateMedias(7),
+				maxWindowItems: 10,
+			},
+			want: want{
+				mediaCount: 7,
+			},
+		},
+		{
+			name: "happy path, windows are split and the shared bounds are deduplicated",
+			fields: fields{
+				medias:         generateMedias(25),
+				maxWindowItems: 4,
+			},
+			want: want{
+				mediaCount:   25,
+				splitWindows: true,
+			},
+		},
+		{
+			name: "happy path, medias captured at the same second are not split",
+			fields: fields{
+				medias: []client.Media{
+					client.NewMedia("file1.mp4", 10, client.WithMediaID("id1"), client.WithMediaCapturedAt(start)),
+					client.NewMedia("file2.mp4", 20, client.WithMediaID("id2"), client.WithMediaCapturedAt(start)),
+					client.NewMedia("file3.mp4", 30, client.WithMediaID("id3"), client.WithMediaCapturedAt(start)),
+				},
+				maxWindowItems: 1,
+			},
+			want: want{
+				mediaCount: 3,
+			},
+		},
+		{
+			name: "happy path, empty library",
+			fields: fields{
+				maxWindowItems: 10,
+			},
+			want: want{
+				mediaCount: 0,
+			},
+		},
+		{
+			name: "sad path, windows miss medias reported by the API",
+			fields: fields{
+				medias:         generateMedias(10),
+				missing:        2,
+				maxWindowItems: 4,
+			},
+			want: want{
+				mediaCount:   10,
+				splitWindows: true,
+				err:          "incomplete remote listing: expected 12 medias, received 10",
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			getPage := func(medias []client.Media, pageNumber, perPage, totalItems int) client.Page {
+				totalPages := (len(medias) + perPage - 1) / perPage
+				from, to := min((pageNumber-1)*perPage, len(medias)), min(pageNumber*perPage, len(medias))
+
+				return client.NewPage(totalPages, medias[from:to], client.WithPageTotalItems(totalItems))
+			}
+
+			mock := mocks.NewMockClient(mockCtrl)
+			mock.EXPECT().GetPage(gomock.Any(), gomock.Any(), 1).AnyTimes().DoAndReturn(func(ctx context.Context, pageNumber, perPage int) (page client.Page, err error) {
+				return getPage(tt.fields.medias, pageNumber, perPage, len(tt.fields.medias)+tt.fields.missing), nil
+			})
+
+			windows := 0
+			mock.EXPECT().GetPageInRange(gomock.Any(), gomock.Any(), gomock.Any(), 3).AnyTimes().DoAndReturn(func(ctx context.Context, capturedRange client.CapturedRange, pageNumber, perPage int) (page client.Page, err error) {
+				if pageNumber == 1 {
+					windows++
+				}
+
+				var medias []client.Media
+				for _, media := range tt.fields.medias {
+					if !media.CapturedAt().Before(capturedRange.From) && !media.CapturedAt().After(capturedRange.To) {
+						medias = append(medias, media)
+					}
+				}
+
+				return getPage(medias, pageNumber, perPage, len(medias)), nil
+			})
+
+			fetcher := fetch.NewFetcher(mock,
+				fetch.WithPerPage(3),
+				fetch.WithStrategy(fetch.StrategyWindows),
+				fetch.WithMaxWindowItems(tt.fields.maxWindowItems),
+			)
+
+			got, err := fetcher.GetMedias(context.Background())
+			if tt.want.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err)
+			}
+
+			assert.Len(t, got, tt.want.mediaCount)
+			assert.Equal(t, tt.want.splitWindows, windows > 1)
 		})
 	}
 }
 
+func TestFetcher_GetMediasInRange(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	capturedRange := client.CapturedRange{
+		From: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:   time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	mock := mocks.NewMockClient(mockCtrl)
+	mock.EXPECT().GetPageInRange(gomock.Any(), capturedRange, gomock.Any(), 2).Times(2).DoAndReturn(func(ctx context.Context, capturedRange client.CapturedRange, pageNumber, perPage int) (page client.Page, err error) {
+		mediasPerPages := map[int][]client.Media{
+			1: {
+				client.NewMedia("file1.mp4", 10),
+				client.NewMedia("file2.jpg", 20),
+			},
+			2: {
+				client.NewMedia("file3.mp4", 30),
+			},
+		}
+
+		return client.NewPage(2, mediasPerPages[pageNumber], client.WithPageTotalItems(3)), nil
+	})
+	mock.EXPECT().GetPage(gomock.Any(), 1, 1).Times(1).Return(client.NewPage(10, []client.Media{client.NewMedia("file1.mp4", 10)}, client.WithPageTotalItems(10)), nil)
+
+	fetcher := fetch.NewFetcher(mock, fetch.WithPerPage(2))
+
+	got, err := fetcher.GetMediasInRange(context.Background(), capturedRange)
+	assert.NoError(t, err)
+	assert.Equal(t, []fetch.Media{
+		fetch.NewMedia("file1.mp4", 10),
+		fetch.NewMedia("file2.jpg", 20),
+		fetch.NewMedia("file3.mp4", 30),
+	}, sortList(got))
+
+	count, err := fetcher.CountMedias(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 10, count)
+}
+
+func TestStrategy_Validate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, fetch.StrategyPages.Validate())
+	assert.NoError(t, fetch.StrategyWindows.Validate())
+	assert.EqualError(t, fetch.Strategy("random").Validate(), "invalid fetch strategy: random, expected one of: pages, windows")
+}
+
 func sortList(list []fetch.Media) []fetch.Media {
 	sort.Slice(list, func(i, j int) bool {
 		return list[i].FileName() < list[j].FileName()
@@ -4,18 +4,22 @@ import (
 	"github.com/legosx/gopro-media-library-verifier/fetch"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestMedia(t *testing.T) {
 	type fields struct {
 		fileName string
 		fileSize int64
+		opts     []func(media *fetch.Media)
 	}
 
 	type want struct {
-		media    fetch.Media
-		fileName string
-		fileSize int64
+		media      fetch.Media
+		id         string
+		fileName   string
+		fileSize   int64
+		capturedAt time.Time
 	}
 
 	tests := []struct {
@@ -35,6 +39,34 @@ func TestMedia(t *testing.T) {
 				fileSize: 10,
 			},
 		},
+		{
+			name: "happy path, with id",
+			fields: fields{
+				fileName: "file1.mp4",
+				fileSize: 10,
+				opts:     []func(media *fetch.Media){fetch.WithMediaID("id1")},
+			},
+			want: want{
+				media:    fetch.NewMedia("file1.mp4", 10, fetch.WithMediaID("id1")),
+				id:       "id1",
+				fileName: "file1.mp4",
+				fileSize: 10,
+			},
+		},
+		{
+			name: "happy path, with capture time",
+			fields: fields{
+				fileName: "file1.mp4",
+				fileSize: 10,
+				opts:     []func(media *fetch.Media){fetch.WithMediaCapturedAt(time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC))},
+			},
+			want: want{
+				media:      fetch.NewMedia("file1.mp4", 10, fetch.WithMediaCapturedAt(time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC))),
+				fileName:   "file1.mp4",
+				fileSize:   10,
+				capturedAt: time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC),
+			},
+		},
 	}
 
 	t.Parallel()
@@ -44,10 +76,12 @@ func TestMedia(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			got := fetch.NewMedia(tt.fields.fileName, tt.fields.fileSize)
+			got := fetch.NewMedia(tt.fields.fileName, tt.fields.fileSize, tt.fields.opts...)
 			assert.Equal(t, tt.want.media, got)
+			assert.Equal(t, tt.want.id, got.ID())
 			assert.Equal(t, tt.want.fileName, got.FileName())
 			assert.Equal(t, tt.want.fileSize, got.FileSize())
+			assert.Equal(t, tt.want.capturedAt, got.CapturedAt())
 		})
 	}
 }
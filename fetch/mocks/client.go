@@ -10,6 +10,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	client "github.com/legosx/gopro-media-library-verifier/client"
@@ -40,16 +41,31 @@ func (m *MockClient) EXPECT() *MockClientMockRecorder {
 }
 
 // GetPage mocks base method.
-func (m *MockClient) GetPage(arg0, arg1 int) (client.Page, error) {
+func (m *MockClient) GetPage(arg0 context.Context, arg1, arg2 int) (client.Page, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetPage", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetPage", arg0, arg1, arg2)
 	ret0, _ := ret[0].(client.Page)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetPage indicates an expected call of GetPage.
-func (mr *MockClientMockRecorder) GetPage(arg0, arg1 any) *gomock.Call {
+func (mr *MockClientMockRecorder) GetPage(arg0, arg1, arg2 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPage", reflect.TypeOf((*MockClient)(nil).GetPage), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPage", reflect.TypeOf((*MockClient)(nil).GetPage), arg0, arg1, arg2)
+}
+
+// GetPageInRange mocks base method.
+func (m *MockClient) GetPageInRange(arg0 context.Context, arg1 client.CapturedRange, arg2, arg3 int) (client.Page, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPageInRange", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(client.Page)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPageInRange indicates an expected call of GetPageInRange.
+func (mr *MockClientMockRecorder) GetPageInRange(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPageInRange", reflect.TypeOf((*MockClient)(nil).GetPageInRange), arg0, arg1, arg2, arg3)
 }
@@ -0,0 +1,46 @@
+package fetch
+
+import (
+	"github.com/pkg/errors"
+)
+
+// ProcessingCategory groups the processing states Gopro Media Library reports for an uploaded media
+type ProcessingCategory string
+
+const (
+	// ProcessingReady means the media is processed and can be viewed and downloaded
+	ProcessingReady ProcessingCategory = "ready"
+	// ProcessingInProgress means the upload is received, but the media is still being processed
+	ProcessingInProgress ProcessingCategory = "processing"
+	// ProcessingFailed means the processing failed, the copy in the cloud can't be relied on
+	ProcessingFailed ProcessingCategory = "failed"
+)
+
+var ProcessingCategoriesAvailable = []ProcessingCategory{
+	ProcessingReady,
+	ProcessingInProgress,
+	ProcessingFailed,
+}
+
+func (c ProcessingCategory) Validate() error {
+	for _, category := range ProcessingCategoriesAvailable {
+		if c == category {
+			return nil
+		}
+	}
+
+	return errors.Errorf("invalid processing category: %s", c)
+}
+
+// GetProcessingCategory tells the category of a processing state, e.g. transcoding is in progress.
+// A media without a state, e.g. from a manifest saved by an older version, is taken as ready.
+func GetProcessingCategory(processingState string) ProcessingCategory {
+	switch processingState {
+	case "", "ready":
+		return ProcessingReady
+	case "failure":
+		return ProcessingFailed
+	default:
+		return ProcessingInProgress
+	}
+}
@@ -0,0 +1,18 @@
+package fetch_test
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestGetProcessingCategory(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, fetch.ProcessingReady, fetch.GetProcessingCategory("ready"))
+	assert.Equal(t, fetch.ProcessingReady, fetch.GetProcessingCategory(""))
+	assert.Equal(t, fetch.ProcessingFailed, fetch.GetProcessingCategory("failure"))
+	assert.Equal(t, fetch.ProcessingInProgress, fetch.GetProcessingCategory("transcoding"))
+	assert.Equal(t, fetch.ProcessingInProgress, fetch.GetProcessingCategory("registered"))
+	assert.EqualError(t, fetch.ProcessingCategory("done").Validate(), "invalid processing category: done")
+}
@@ -0,0 +1,197 @@
+package indexcache
+
+import (
+	"context"
+	"fmt"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/pkg/errors"
+	"io"
+	"os"
+	"time"
+)
+
+// Mode tells how the cached index is used
+type Mode string
+
+const (
+	// ModeAuto fetches only the medias captured since the cached ones, and the whole library from time to time
+	ModeAuto Mode = "auto"
+	// ModeRefresh fetches the whole library and replaces the cached index
+	ModeRefresh Mode = "refresh"
+)
+
+// DefaultRevalidateAfter is how old the last full fetch can get before the whole library is fetched again
+const DefaultRevalidateAfter = 24 * time.Hour
+
+// maxProcessingTime is how long after its upload a media is expected to finish processing.
+// A media that is still processing later is stuck, it's left to the regular revalidation.
+const maxProcessingTime = 24 * time.Hour
+
+// captureClockSkew widens the update range past now, camera clocks are often a few hours ahead
+const captureClockSkew = 24 * time.Hour
+
+type Fetcher interface {
+	GetMedias(ctx context.Context) (medias []fetch.Media, err error)
+	GetMediasInRange(ctx context.Context, capturedRange client.CapturedRange) (medias []fetch.Media, err error)
+	CountMedias(ctx context.Context) (count int, err error)
+}
+
+// Cache keeps the remote media index on disk, so a run doesn't have to list the whole library again
+type Cache struct {
+	fetcher         Fetcher
+	path            string
+	mode            Mode
+	revalidateAfter time.Duration
+	now             func() time.Time
+	logWriter       io.Writer
+}
+
+func NewCache(fetcher Fetcher, path string, opts ...func(cache *Cache)) Cache {
+	c := Cache{
+		fetcher:         fetcher,
+		path:            path,
+		mode:            ModeAuto,
+		revalidateAfter: DefaultRevalidateAfter,
+		now:             time.Now,
+		logWriter:       os.Stdout,
+	}
+
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	return c
+}
+
+func WithMode(mode Mode) func(c *Cache) {
+	return func(c *Cache) {
+		c.mode = mode
+	}
+}
+
+// WithRevalidateAfter sets how old the last full fetch can get before the whole library is fetched again
+func WithRevalidateAfter(revalidateAfter time.Duration) func(c *Cache) {
+	return func(c *Cache) {
+		c.revalidateAfter = revalidateAfter
+	}
+}
+
+func WithNow(now func() time.Time) func(c *Cache) {
+	return func(c *Cache) {
+		c.now = now
+	}
+}
+
+// WithLogWriter sets where the progress messages are written to
+func WithLogWriter(logWriter io.Writer) func(c *Cache) {
+	return func(c *Cache) {
+		c.logWriter = logWriter
+	}
+}
+
+// GetMedias returns the medias of the library, fetching as little as the mode allows.
+// A listing that comes back incomplete is returned as it is and isn't cached.
+func (c Cache) GetMedias(ctx context.Context) (medias []fetch.Media, err error) {
+	if c.mode == ModeRefresh {
+		return c.revalidate(ctx)
+	}
+
+	index, err := Load(c.path)
+
+	switch {
+	case errors.Is(err, ErrNoIndex):
+		return c.revalidate(ctx)
+	case err != nil:
+		fmt.Fprintf(c.logWriter, "\nWarning: ignoring the cached index: %s\n", err)
+
+		return c.revalidate(ctx)
+	case c.now().Sub(index.RevalidatedAt) >= c.revalidateAfter:
+		return c.revalidate(ctx)
+	case countProcessing(index) > 0:
+		// An update keeps the cached medias as they are, so their processing would never finish
+		fmt.Fprintf(c.logWriter, "\nThe cached index has %d medias that are still processing, fetching the whole library\n", countProcessing(index))
+
+		return c.revalidate(ctx)
+	default:
+		return c.update(ctx, index)
+	}
+}
+
+// revalidate fetches the whole library and replaces the cached index
+func (c Cache) revalidate(ctx context.Context) (medias []fetch.Media, err error) {
+	if medias, err = c.fetcher.GetMedias(ctx); err != nil {
+		return medias, err
+	}
+
+	now := c.now()
+	c.save(NewIndex(medias, now, now))
+
+	return medias, nil
+}
+
+// update fetches the medias captured since the high-water mark of the index.
+// The media captured earlier but uploaded since then, or deleted ones, change the total,
+// so the whole library is fetched again when the result doesn't add up.
+func (c Cache) update(ctx context.Context, index Index) (medias []fetch.Media, err error) {
+	highWaterMark := index.HighWaterMark()
+
+	fetched, err := c.fetcher.GetMediasInRange(ctx, client.CapturedRange{
+		From: highWaterMark,
+		To:   c.now().Add(captureClockSkew),
+	})
+	if errors.As(err, &fetch.IncompleteListingError{}) {
+		return c.revalidate(ctx)
+	}
+	if err != nil {
+		return []fetch.Media{}, err
+	}
+
+	// The medias captured at the high-water mark itself are fetched again, so they're replaced with the fresh ones
+	for _, media := range index.FetchMedias() {
+		if media.CapturedAt().Before(highWaterMark) {
+			medias = append(medias, media)
+		}
+	}
+	medias = append(medias, fetched...)
+
+	count, err := c.fetcher.CountMedias(ctx)
+	if err != nil {
+		return []fetch.Media{}, err
+	}
+
+	if count >= 0 && count != len(medias) {
+		fmt.Fprintf(c.logWriter, "\nThe cached index is out of date (%d medias instead of %d), fetching the whole library\n", len(medias), count)
+
+		return c.revalidate(ctx)
+	}
+
+	c.save(NewIndex(medias, index.RevalidatedAt, c.now()))
+
+	return medias, nil
+}
+
+// countProcessing counts the cached medias whose processing may still change.
+// The failed ones are left out, they don't become ready on their own and would make every run fetch the whole library.
+// So are the ones that were already processing for maxProcessingTime when the whole library was last fetched,
+// and the ones without a creation time.
+func countProcessing(index Index) (count int) {
+	for _, media := range index.FetchMedias() {
+		if fetch.GetProcessingCategory(media.ProcessingState()) != fetch.ProcessingInProgress {
+			continue
+		}
+
+		if !media.CreatedAt().IsZero() && media.CreatedAt().Add(maxProcessingTime).After(index.RevalidatedAt) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// save doesn't fail the run, the medias are fetched anyway and the index is only needed next time
+func (c Cache) save(index Index) {
+	if err := Save(c.path, index); err != nil {
+		fmt.Fprintf(c.logWriter, "\nWarning: the index of Gopro Media Library is not cached: %s\n", err)
+	}
+}
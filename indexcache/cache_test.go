@@ -0,0 +1,286 @@
+package indexcache_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/legosx/gopro-media-library-verifier/indexcache"
+	"github.com/legosx/gopro-media-library-verifier/indexcache/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//go:generate mockgen -destination=./mocks/fetcher.go -package=mocks github.com/legosx/gopro-media-library-verifier/indexcache Fetcher
+
+func TestCache_GetMedias(t *testing.T) {
+	type fields struct {
+		index   *indexcache.Index
+		mode    indexcache.Mode
+		fetcher func(mockCtrl *gomock.Controller) indexcache.Fetcher
+	}
+
+	type want struct {
+		medias        []fetch.Media
+		err           string
+		saved         bool
+		revalidatedAt time.Time
+	}
+
+	now := time.Date(2023, 6, 10, 12, 0, 0, 0, time.UTC)
+	hour := func(h int) time.Time {
+		return time.Date(2023, 6, 1, h, 0, 0, 0, time.UTC)
+	}
+
+	media := func(name string, capturedAt time.Time) fetch.Media {
+		return fetch.NewMedia(name, 10, fetch.WithMediaID(name), fetch.WithMediaCapturedAt(capturedAt), fetch.WithMediaProcessingState("ready"))
+	}
+
+	cached := indexcache.NewIndex([]fetch.Media{media("file1.mp4", hour(1)), media("file2.mp4", hour(2))}, now.Add(-time.Hour), now.Add(-time.Hour))
+
+	tests := []struct {
+		name string
+		fields
+		want
+	}{
+		{
+			name: "happy path, no cached index, the whole library is fetched",
+			fields: fields{
+				mode: indexcache.ModeAuto,
+				fetcher: func(mockCtrl *gomock.Controller) indexcache.Fetcher {
+					mock := mocks.NewMockFetcher(mockCtrl)
+					mock.EXPECT().GetMedias(gomock.Any()).Times(1).Return([]fetch.Media{media("file1.mp4", hour(1))}, nil)
+
+					return mock
+				},
+			},
+			want: want{
+				medias:        []fetch.Media{media("file1.mp4", hour(1))},
+				saved:         true,
+				revalidatedAt: now,
+			},
+		},
+		{
+			name: "happy path, only the medias captured since the high-water mark are fetched",
+			fields: fields{
+				index: &cached,
+				mode:  indexcache.ModeAuto,
+				fetcher: func(mockCtrl *gomock.Controller) indexcache.Fetcher {
+					mock := mocks.NewMockFetcher(mockCtrl)
+					mock.EXPECT().GetMediasInRange(gomock.Any(), gomock.Any()).Times(1).DoAndReturn(func(ctx context.Context, capturedRange client.CapturedRange) ([]fetch.Media, error) {
+						assert.Equal(t, hour(2), capturedRange.From)
+						assert.True(t, capturedRange.To.After(now))
+
+						return []fetch.Media{media("file2.mp4", hour(2)), media("file3.mp4", hour(3))}, nil
+					})
+					mock.EXPECT().CountMedias(gomock.Any()).Times(1).Return(3, nil)
+
+					return mock
+				},
+			},
+			want: want{
+				medias:        []fetch.Media{media("file1.mp4", hour(1)), media("file2.mp4", hour(2)), media("file3.mp4", hour(3))},
+				saved:         true,
+				revalidatedAt: now.Add(-time.Hour),
+			},
+		},
+		{
+			name: "happy path, the total doesn't add up, the whole library is fetched again",
+			fields: fields{
+				index: &cached,
+				mode:  indexcache.ModeAuto,
+				fetcher: func(mockCtrl *gomock.Controller) indexcache.Fetcher {
+					mock := mocks.NewMockFetcher(mockCtrl)
+					mock.EXPECT().GetMediasInRange(gomock.Any(), gomock.Any()).Times(1).Return([]fetch.Media{media("file2.mp4", hour(2))}, nil)
+					mock.EXPECT().CountMedias(gomock.Any()).Times(1).Return(3, nil)
+					mock.EXPECT().GetMedias(gomock.Any()).Times(1).Return([]fetch.Media{
+						media("file0.mp4", hour(0)),
+						media("file1.mp4", hour(1)),
+						media("file2.mp4", hour(2)),
+					}, nil)
+
+					return mock
+				},
+			},
+			want: want{
+				medias:        []fetch.Media{media("file0.mp4", hour(0)), media("file1.mp4", hour(1)), media("file2.mp4", hour(2))},
+				saved:         true,
+				revalidatedAt: now,
+			},
+		},
+		{
+			name: "happy path, the cached index is too old, the whole library is fetched",
+			fields: fields{
+				index: func() *indexcache.Index {
+					index := indexcache.NewIndex([]fetch.Media{media("file1.mp4", hour(1))}, now.Add(-48*time.Hour), now.Add(-time.Hour))
+
+					return &index
+				}(),
+				mode: indexcache.ModeAuto,
+				fetcher: func(mockCtrl *gomock.Controller) indexcache.Fetcher {
+					mock := mocks.NewMockFetcher(mockCtrl)
+					mock.EXPECT().GetMedias(gomock.Any()).Times(1).Return([]fetch.Media{media("file2.mp4", hour(2))}, nil)
+
+					return mock
+				},
+			},
+			want: want{
+				medias:        []fetch.Media{media("file2.mp4", hour(2))},
+				saved:         true,
+				revalidatedAt: now,
+			},
+		},
+		{
+			name: "happy path, the cached index has medias still processing, the whole library is fetched",
+			fields: fields{
+				index: func() *indexcache.Index {
+					processing := fetch.NewMedia("file1.mp4", 10, fetch.WithMediaID("file1.mp4"), fetch.WithMediaCapturedAt(hour(1)),
+						fetch.WithMediaProcessingState("transcoding"), fetch.WithMediaCreatedAt(now.Add(-2*time.Hour)))
+					index := indexcache.NewIndex([]fetch.Media{processing}, now.Add(-time.Hour), now.Add(-time.Hour))
+
+					return &index
+				}(),
+				mode: indexcache.ModeAuto,
+				fetcher: func(mockCtrl *gomock.Controller) indexcache.Fetcher {
+					mock := mocks.NewMockFetcher(mockCtrl)
+					mock.EXPECT().GetMedias(gomock.Any()).Times(1).Return([]fetch.Media{media("file1.mp4", hour(1))}, nil)
+
+					return mock
+				},
+			},
+			want: want{
+				medias:        []fetch.Media{media("file1.mp4", hour(1))},
+				saved:         true,
+				revalidatedAt: now,
+			},
+		},
+		{
+			name: "happy path, the cached index has a media stuck processing, only the new medias are fetched",
+			fields: fields{
+				index: func() *indexcache.Index {
+					stuck := fetch.NewMedia("file1.mp4", 10, fetch.WithMediaID("file1.mp4"), fetch.WithMediaCapturedAt(hour(1)),
+						fetch.WithMediaProcessingState("transcoding"), fetch.WithMediaCreatedAt(now.Add(-30*time.Hour)))
+					index := indexcache.NewIndex([]fetch.Media{stuck, media("file2.mp4", hour(2))}, now.Add(-time.Hour), now.Add(-time.Hour))
+
+					return &index
+				}(),
+				mode: indexcache.ModeAuto,
+				fetcher: func(mockCtrl *gomock.Controller) indexcache.Fetcher {
+					mock := mocks.NewMockFetcher(mockCtrl)
+					mock.EXPECT().GetMediasInRange(gomock.Any(), gomock.Any()).Times(1).Return([]fetch.Media{media("file2.mp4", hour(2))}, nil)
+					mock.EXPECT().CountMedias(gomock.Any()).Times(1).Return(2, nil)
+
+					return mock
+				},
+			},
+			want: want{
+				medias: []fetch.Media{
+					fetch.NewMedia("file1.mp4", 10, fetch.WithMediaID("file1.mp4"), fetch.WithMediaCapturedAt(hour(1)),
+						fetch.WithMediaProcessingState("transcoding"), fetch.WithMediaCreatedAt(now.Add(-30*time.Hour))),
+					media("file2.mp4", hour(2)),
+				},
+				saved:         true,
+				revalidatedAt: now.Add(-time.Hour),
+			},
+		},
+		{
+			name: "happy path, refresh fetches the whole library",
+			fields: fields{
+				index: &cached,
+				mode:  indexcache.ModeRefresh,
+				fetcher: func(mockCtrl *gomock.Controller) indexcache.Fetcher {
+					mock := mocks.NewMockFetcher(mockCtrl)
+					mock.EXPECT().GetMedias(gomock.Any()).Times(1).Return([]fetch.Media{media("file1.mp4", hour(1))}, nil)
+
+					return mock
+				},
+			},
+			want: want{
+				medias:        []fetch.Media{media("file1.mp4", hour(1))},
+				saved:         true,
+				revalidatedAt: now,
+			},
+		},
+		{
+			name: "sad path, incomplete listing is not cached",
+			fields: fields{
+				mode: indexcache.ModeAuto,
+				fetcher: func(mockCtrl *gomock.Controller) indexcache.Fetcher {
+					mock := mocks.NewMockFetcher(mockCtrl)
+					mock.EXPECT().GetMedias(gomock.Any()).Times(1).Return([]fetch.Media{media("file1.mp4", hour(1))}, fetch.NewIncompleteListingError(2, 1))
+
+					return mock
+				},
+			},
+			want: want{
+				medias: []fetch.Media{media("file1.mp4", hour(1))},
+				err:    "incomplete remote listing: expected 2 medias, received 1",
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			path := filepath.Join(t.TempDir(), "cache", "index.json")
+			if tt.fields.index != nil {
+				assert.NoError(t, indexcache.Save(path, *tt.fields.index))
+			}
+
+			cache := indexcache.NewCache(tt.fields.fetcher(mockCtrl), path,
+				indexcache.WithMode(tt.fields.mode),
+				indexcache.WithNow(func() time.Time { return now }),
+				indexcache.WithLogWriter(&bytes.Buffer{}),
+			)
+
+			got, err := cache.GetMedias(context.Background())
+			if tt.want.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err)
+			}
+			assert.Equal(t, tt.want.medias, got)
+
+			index, err := indexcache.Load(path)
+			if !tt.want.saved {
+				assert.ErrorIs(t, err, indexcache.ErrNoIndex)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.medias, index.FetchMedias())
+			assert.Equal(t, tt.want.revalidatedAt, index.RevalidatedAt.UTC())
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	_, err := indexcache.Load(filepath.Join(dir, "missing.json"))
+	assert.ErrorIs(t, err, indexcache.ErrNoIndex)
+
+	otherVersion := filepath.Join(dir, "other.json")
+	assert.NoError(t, os.WriteFile(otherVersion, []byte(`{"version": 999, "medias": []}`), 0o600))
+	_, err = indexcache.Load(otherVersion)
+	assert.ErrorIs(t, err, indexcache.ErrNoIndex)
+
+	corrupted := filepath.Join(dir, "corrupted.json")
+	assert.NoError(t, os.WriteFile(corrupted, []byte(`{`), 0o600))
+	_, err = indexcache.Load(corrupted)
+	assert.ErrorContains(t, err, "error decoding index")
+}
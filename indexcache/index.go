@@ -0,0 +1,182 @@
+package indexcache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// indexVersion is bumped when the format changes, an index of another version is fetched again
+const indexVersion = 1
+
+// ErrNoIndex is returned when there is no usable index at the path
+var ErrNoIndex = errors.New("no saved index of Gopro Media Library")
+
+// Index is the remote media index as it's stored on disk
+type Index struct {
+	Version int `json:"version"`
+	// RevalidatedAt is when the whole library was last fetched
+	RevalidatedAt time.Time `json:"revalidated_at"`
+	// UpdatedAt is when the index was last brought up to date, either fully or incrementally
+	UpdatedAt time.Time `json:"updated_at"`
+	Medias    []Media   `json:"medias"`
+}
+
+// Media is a media of the index, it keeps what's needed to verify the sync
+type Media struct {
+	ID              string     `json:"id,omitempty"`
+	FileName        string     `json:"filename"`
+	FileSize        int64      `json:"file_size"`
+	CapturedAt      time.Time  `json:"captured_at"`
+	ProcessingState string     `json:"processing_state,omitempty"`
+	Type            string     `json:"type,omitempty"`
+	CameraModel     string     `json:"camera_model,omitempty"`
+	CreatedAt       *time.Time `json:"created_at,omitempty"`
+	ContentTitle    string     `json:"content_title,omitempty"`
+	DurationMs      int64      `json:"duration_ms,omitempty"`
+	Width           int        `json:"width,omitempty"`
+	Height          int        `json:"height,omitempty"`
+	GoProUserID     string     `json:"gopro_user_id,omitempty"`
+}
+
+func NewIndex(medias []fetch.Media, revalidatedAt, updatedAt time.Time) Index {
+	index := Index{
+		Version:       indexVersion,
+		RevalidatedAt: revalidatedAt,
+		UpdatedAt:     updatedAt,
+		Medias:        make([]Media, 0, len(medias)),
+	}
+
+	for _, media := range medias {
+		index.Medias = append(index.Medias, Media{
+			ID:              media.ID(),
+			FileName:        media.FileName(),
+			FileSize:        media.FileSize(),
+			CapturedAt:      media.CapturedAt(),
+			ProcessingState: media.ProcessingState(),
+			Type:            media.Type(),
+			CameraModel:     media.CameraModel(),
+			CreatedAt:       optionalTime(media.CreatedAt()),
+			ContentTitle:    media.ContentTitle(),
+			DurationMs:      media.SourceDuration().Milliseconds(),
+			Width:           media.Width(),
+			Height:          media.Height(),
+			GoProUserID:     media.GoProUserID(),
+		})
+	}
+
+	return index
+}
+
+func (i Index) FetchMedias() (medias []fetch.Media) {
+	medias = make([]fetch.Media, 0, len(i.Medias))
+
+	for _, media := range i.Medias {
+		medias = append(medias, fetch.NewMedia(
+			media.FileName,
+			media.FileSize,
+			fetch.WithMediaID(media.ID),
+			fetch.WithMediaCapturedAt(media.CapturedAt),
+			fetch.WithMediaProcessingState(media.ProcessingState),
+			fetch.WithMediaType(media.Type),
+			fetch.WithMediaCameraModel(media.CameraModel),
+			fetch.WithMediaCreatedAt(media.createdAt()),
+			fetch.WithMediaContentTitle(media.ContentTitle),
+			fetch.WithMediaSourceDuration(time.Duration(media.DurationMs)*time.Millisecond),
+			fetch.WithMediaDimensions(media.Width, media.Height),
+			fetch.WithMediaGoProUserID(media.GoProUserID),
+		))
+	}
+
+	return medias
+}
+
+func (m Media) createdAt() time.Time {
+	if m.CreatedAt == nil {
+		return time.Time{}
+	}
+
+	return *m.CreatedAt
+}
+
+// optionalTime leaves the unknown times out of the index
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	return &t
+}
+
+// HighWaterMark is the latest capture time in the index, the medias captured since then are fetched on update
+func (i Index) HighWaterMark() (highWaterMark time.Time) {
+	for _, media := range i.Medias {
+		if media.CapturedAt.After(highWaterMark) {
+			highWaterMark = media.CapturedAt
+		}
+	}
+
+	return highWaterMark
+}
+
+// DefaultPath returns where the index of the given API server is cached in the user's cache directory
+func DefaultPath(baseURL string) (path string, err error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "error getting user cache directory")
+	}
+
+	// Each API server gets its own index, e.g. the fake server doesn't overwrite the real library
+	sum := sha256.Sum256([]byte(baseURL))
+
+	return filepath.Join(cacheDir, "gopro-media-library-verifier", "index-"+hex.EncodeToString(sum[:6])+".json"), nil
+}
+
+// Load reads the index, ErrNoIndex is returned when the file doesn't exist or has another version
+func Load(path string) (index Index, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Index{}, ErrNoIndex
+	}
+	if err != nil {
+		return Index{}, errors.Wrap(err, "error reading index")
+	}
+
+	if err = json.Unmarshal(data, &index); err != nil {
+		return Index{}, errors.Wrapf(err, "error decoding index: %s", path)
+	}
+
+	if index.Version != indexVersion {
+		return Index{}, ErrNoIndex
+	}
+
+	return index, nil
+}
+
+// Save writes the index through a temporary file, so an interrupted run doesn't leave a truncated index behind
+func Save(path string, index Index) (err error) {
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.Wrap(err, "error creating cache directory")
+	}
+
+	data, err := json.Marshal(index)
+	if err != nil {
+		return errors.Wrap(err, "error encoding cached index")
+	}
+
+	tmpPath := path + ".tmp"
+	if err = os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return errors.Wrap(err, "error writing cached index")
+	}
+
+	if err = os.Rename(tmpPath, path); err != nil {
+		return errors.Wrap(err, "error replacing cached index")
+	}
+
+	return nil
+}
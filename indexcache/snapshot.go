@@ -0,0 +1,36 @@
+package indexcache
+
+import (
+	"context"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Snapshot serves the medias of a saved index instead of listing Gopro Media Library, e.g. to verify offline
+type Snapshot struct {
+	index Index
+}
+
+func NewSnapshot(index Index) Snapshot {
+	return Snapshot{index: index}
+}
+
+// LoadSnapshot reads the cached index or a manifest exported in the same format
+func LoadSnapshot(path string) (snapshot Snapshot, err error) {
+	index, err := Load(path)
+	if err != nil {
+		return Snapshot{}, errors.Wrapf(err, "error loading snapshot from %s", path)
+	}
+
+	return NewSnapshot(index), nil
+}
+
+func (s Snapshot) GetMedias(ctx context.Context) (medias []fetch.Media, err error) {
+	return s.index.FetchMedias(), nil
+}
+
+// SnapshotTakenAt is when the medias were last listed from Gopro Media Library
+func (s Snapshot) SnapshotTakenAt() time.Time {
+	return s.index.UpdatedAt
+}
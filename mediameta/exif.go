@@ -0,0 +1,259 @@
+package mediameta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// exifTimeLayout is how EXIF writes times, without a time zone
+const exifTimeLayout = "2006:01:02 15:04:05"
+
+// The EXIF tags that are read
+const (
+	tagMake             = 0x010f
+	tagModel            = 0x0110
+	tagExifIFDPointer   = 0x8769
+	tagDateTimeOriginal = 0x9003
+	tagPixelXDimension  = 0xa002
+	tagPixelYDimension  = 0xa003
+)
+
+// exifHeader starts the APP1 segment of JPEG and, optionally, the EXIF item of HEIF
+var exifHeader = []byte("Exif\x00\x00")
+
+// ReadJPEG reads the EXIF metadata of a JPEG file.
+// The segments are walked up to the image data, which is never read.
+func ReadJPEG(r io.ReadSeeker) (metadata Metadata, err error) {
+	marker := make([]byte, 2)
+	if _, err = io.ReadFull(r, marker); err != nil || marker[0] != 0xff || marker[1] != 0xd8 {
+		return Metadata{}, ErrNoMetadata
+	}
+
+	found := false
+
+	for {
+		if _, err = io.ReadFull(r, marker); err != nil {
+			return jpegResult(metadata, found, errors.Wrap(err, "error reading JPEG marker"))
+		}
+
+		if marker[0] != 0xff {
+			return jpegResult(metadata, found, errors.Errorf("invalid JPEG marker: %x", marker))
+		}
+
+		// Start of scan is followed by the image data
+		if marker[1] == 0xda || marker[1] == 0xd9 {
+			return jpegResult(metadata, found, nil)
+		}
+
+		// Fill bytes and the markers without a payload
+		if marker[1] == 0xff {
+			if _, err = r.Seek(-1, io.SeekCurrent); err != nil {
+				return Metadata{}, errors.Wrap(err, "error reading JPEG marker")
+			}
+
+			continue
+		}
+		if marker[1] == 0x01 || (marker[1] >= 0xd0 && marker[1] <= 0xd7) {
+			continue
+		}
+
+		length := make([]byte, 2)
+		if _, err = io.ReadFull(r, length); err != nil {
+			return jpegResult(metadata, found, errors.Wrap(err, "error reading JPEG segment"))
+		}
+
+		size := int64(binary.BigEndian.Uint16(length)) - 2
+		if size < 0 {
+			return jpegResult(metadata, found, errors.New("invalid JPEG segment length"))
+		}
+
+		switch {
+		case marker[1] == 0xe1 || isStartOfFrame(marker[1]):
+			segment := make([]byte, size)
+			if _, err = io.ReadFull(r, segment); err != nil {
+				return jpegResult(metadata, found, errors.Wrap(err, "error reading JPEG segment"))
+			}
+
+			if marker[1] == 0xe1 {
+				if !bytes.HasPrefix(segment, exifHeader) {
+					continue
+				}
+
+				exif, err := parseTIFF(segment[len(exifHeader):])
+				if err != nil {
+					return Metadata{}, err
+				}
+
+				// The dimensions of the frame win, EXIF may be out of date after an edit
+				width, height := metadata.Width, metadata.Height
+				metadata, found = exif, true
+
+				if width > 0 && height > 0 {
+					metadata.Width, metadata.Height = width, height
+				}
+
+				continue
+			}
+
+			if len(segment) >= 5 {
+				metadata.Height = int(binary.BigEndian.Uint16(segment[1:3]))
+				metadata.Width = int(binary.BigEndian.Uint16(segment[3:5]))
+				found = true
+			}
+		default:
+			if _, err = r.Seek(size, io.SeekCurrent); err != nil {
+				return Metadata{}, errors.Wrap(err, "error skipping JPEG segment")
+			}
+		}
+	}
+}
+
+// jpegResult keeps what was read before a broken segment
+func jpegResult(metadata Metadata, found bool, err error) (Metadata, error) {
+	if found {
+		return metadata, nil
+	}
+
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	return Metadata{}, ErrNoMetadata
+}
+
+// isStartOfFrame tells the SOFn markers apart from DHT, JPG and DAC that share their range
+func isStartOfFrame(marker byte) bool {
+	return marker >= 0xc0 && marker <= 0xcf && marker != 0xc4 && marker != 0xc8 && marker != 0xcc
+}
+
+// tiffReader reads the IFDs of the TIFF structure EXIF is stored in
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// ifdEntry is a tag of an IFD with the bytes of its value
+type ifdEntry struct {
+	valueType uint16
+	count     uint32
+	value     []byte
+}
+
+// parseTIFF reads the camera make and model from IFD0, and the capture time and dimensions from the EXIF IFD
+func parseTIFF(data []byte) (metadata Metadata, err error) {
+	if len(data) < 8 {
+		return Metadata{}, errors.New("EXIF data is too short")
+	}
+
+	t := tiffReader{data: data}
+
+	switch string(data[:2]) {
+	case "II":
+		t.order = binary.LittleEndian
+	case "MM":
+		t.order = binary.BigEndian
+	default:
+		return Metadata{}, errors.New("invalid EXIF byte order")
+	}
+
+	if t.order.Uint16(data[2:4]) != 42 {
+		return Metadata{}, errors.New("invalid EXIF header")
+	}
+
+	ifd0, err := t.readIFD(t.order.Uint32(data[4:8]))
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	metadata.CameraMake = t.readString(ifd0[tagMake])
+	metadata.CameraModel = t.readString(ifd0[tagModel])
+
+	pointer, ok := t.readUint(ifd0[tagExifIFDPointer])
+	if !ok {
+		return metadata, nil
+	}
+
+	exifIFD, err := t.readIFD(pointer)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	if capturedAt, err := time.Parse(exifTimeLayout, t.readString(exifIFD[tagDateTimeOriginal])); err == nil {
+		metadata.CapturedAt = capturedAt
+	}
+
+	if width, ok := t.readUint(exifIFD[tagPixelXDimension]); ok {
+		metadata.Width = int(width)
+	}
+
+	if height, ok := t.readUint(exifIFD[tagPixelYDimension]); ok {
+		metadata.Height = int(height)
+	}
+
+	return metadata, nil
+}
+
+// typeSizes are the sizes of the EXIF value types, by their number
+var typeSizes = map[uint16]uint32{1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 7: 1, 9: 4, 10: 8}
+
+func (t tiffReader) readIFD(offset uint32) (entries map[uint16]ifdEntry, err error) {
+	if uint64(offset)+2 > uint64(len(t.data)) {
+		return nil, errors.New("EXIF IFD is out of bounds")
+	}
+
+	count := uint32(t.order.Uint16(t.data[offset:]))
+	if uint64(offset)+2+uint64(count)*12 > uint64(len(t.data)) {
+		return nil, errors.New("EXIF IFD is out of bounds")
+	}
+
+	entries = map[uint16]ifdEntry{}
+
+	for i := uint32(0); i < count; i++ {
+		raw := t.data[offset+2+i*12 : offset+2+(i+1)*12]
+		entry := ifdEntry{valueType: t.order.Uint16(raw[2:4]), count: t.order.Uint32(raw[4:8])}
+
+		typeSize, ok := typeSizes[entry.valueType]
+		if !ok {
+			continue
+		}
+
+		size := uint64(typeSize) * uint64(entry.count)
+		if size <= 4 {
+			entry.value = raw[8 : 8+size]
+		} else {
+			valueOffset := uint64(t.order.Uint32(raw[8:12]))
+			if valueOffset+size > uint64(len(t.data)) {
+				continue
+			}
+
+			entry.value = t.data[valueOffset : valueOffset+size]
+		}
+
+		entries[t.order.Uint16(raw[0:2])] = entry
+	}
+
+	return entries, nil
+}
+
+func (t tiffReader) readString(entry ifdEntry) string {
+	if entry.valueType != 2 {
+		return ""
+	}
+
+	return strings.TrimSpace(strings.TrimRight(string(entry.value), "\x00"))
+}
+
+func (t tiffReader) readUint(entry ifdEntry) (value uint32, ok bool) {
+	switch {
+	case entry.valueType == 3 && len(entry.value) >= 2:
+		return uint32(t.order.Uint16(entry.value)), true
+	case entry.valueType == 4 && len(entry.value) >= 4:
+		return t.order.Uint32(entry.value), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,210 @@
+package mediameta_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/legosx/gopro-media-library-verifier/mediameta"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestReadJPEG(t *testing.T) {
+	type want struct {
+		metadata mediameta.Metadata
+		err      error
+		errMsg   string
+	}
+
+	capturedAt := time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		file []byte
+		want
+	}{
+		{
+			name: "happy path, little-endian EXIF and the frame dimensions",
+			file: jpeg(
+				segment(0xe0, []byte("JFIF\x00\x01\x01")),
+				segment(0xe1, exifHeaderBytes, tiff(binary.LittleEndian, "GoPro", "HERO12 Black", capturedAt, 5568, 4872)),
+				segment(0xc0, sof(4000, 3000)),
+			),
+			want: want{
+				metadata: mediameta.Metadata{
+					CapturedAt:  capturedAt,
+					CameraMake:  "GoPro",
+					CameraModel: "HERO12 Black",
+					Width:       4000,
+					Height:      3000,
+				},
+			},
+		},
+		{
+			name: "happy path, big-endian EXIF without a frame",
+			file: jpeg(segment(0xe1, exifHeaderBytes, tiff(binary.BigEndian, "Apple", "iPhone 15 Pro", capturedAt, 4032, 3024))),
+			want: want{
+				metadata: mediameta.Metadata{
+					CapturedAt:  capturedAt,
+					CameraMake:  "Apple",
+					CameraModel: "iPhone 15 Pro",
+					Width:       4032,
+					Height:      3024,
+				},
+			},
+		},
+		{
+			name: "happy path, frame dimensions without EXIF",
+			file: jpeg(segment(0xe1, []byte("http://ns.adobe.com/xap/1.0/\x00")), segment(0xc2, sof(1920, 1080))),
+			want: want{
+				metadata: mediameta.Metadata{Width: 1920, Height: 1080},
+			},
+		},
+		{
+			name: "sad path, not a JPEG",
+			file: []byte("\x89PNG\r\n\x1a\n"),
+			want: want{
+				err: mediameta.ErrNoMetadata,
+			},
+		},
+		{
+			name: "sad path, invalid EXIF byte order",
+			file: jpeg(segment(0xe1, exifHeaderBytes, []byte("XX\x00\x2a\x00\x00\x00\x08"))),
+			want: want{
+				errMsg: "invalid EXIF byte order",
+			},
+		},
+		{
+			name: "sad path, truncated segment",
+			file: []byte{0xff, 0xd8, 0xff, 0xe1, 0x00},
+			want: want{
+				errMsg: "error reading JPEG segment: unexpected EOF",
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := mediameta.ReadJPEG(bytes.NewReader(tt.file))
+
+			switch {
+			case tt.want.err != nil:
+				assert.ErrorIs(t, err, tt.want.err)
+			case tt.want.errMsg != "":
+				assert.EqualError(t, err, tt.want.errMsg)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.metadata, got)
+			}
+		})
+	}
+}
+
+var exifHeaderBytes = []byte("Exif\x00\x00")
+
+// jpeg wraps the segments between the start of image and the start of scan followed by some image data
+func jpeg(segments ...[]byte) []byte {
+	return concat([]byte{0xff, 0xd8}, concat(segments...), []byte{0xff, 0xda}, make([]byte, 64), []byte{0xff, 0xd9})
+}
+
+func segment(marker byte, payloads ...[]byte) []byte {
+	payload := concat(payloads...)
+
+	header := []byte{0xff, marker, 0, 0}
+	binary.BigEndian.PutUint16(header[2:], uint16(2+len(payload)))
+
+	return concat(header, payload)
+}
+
+// sof is the payload of a start of frame segment with three components
+func sof(width, height uint16) []byte {
+	payload := make([]byte, 15)
+	payload[0] = 8
+	binary.BigEndian.PutUint16(payload[1:], height)
+	binary.BigEndian.PutUint16(payload[3:], width)
+	payload[5] = 3
+
+	return payload
+}
+
+// tiff builds IFD0 with the make and the model, pointing to the EXIF IFD with the capture time and the dimensions
+func tiff(order binary.ByteOrder, cameraMake, model string, capturedAt time.Time, width, height uint32) []byte {
+	type entry struct {
+		tag, valueType uint16
+		count          uint32
+		value          []byte
+	}
+
+	ascii := func(tag uint16, value string) entry {
+		return entry{tag: tag, valueType: 2, count: uint32(len(value) + 1), value: append([]byte(value), 0)}
+	}
+
+	long := func(tag uint16, value uint32) entry {
+		raw := make([]byte, 4)
+		order.PutUint32(raw, value)
+
+		return entry{tag: tag, valueType: 4, count: 1, value: raw}
+	}
+
+	short := func(tag uint16, value uint16) entry {
+		raw := make([]byte, 4)
+		order.PutUint16(raw, value)
+
+		return entry{tag: tag, valueType: 3, count: 1, value: raw}
+	}
+
+	// ifd lays out the entries at the offset with the values that don't fit in 4 bytes right after them
+	ifd := func(offset uint32, entries ...entry) []byte {
+		table := make([]byte, 2+len(entries)*12+4)
+		order.PutUint16(table, uint16(len(entries)))
+
+		values := []byte{}
+		valuesOffset := offset + uint32(len(table))
+
+		for i, e := range entries {
+			raw := table[2+i*12:]
+			order.PutUint16(raw[0:], e.tag)
+			order.PutUint16(raw[2:], e.valueType)
+			order.PutUint32(raw[4:], e.count)
+
+			if len(e.value) <= 4 {
+				copy(raw[8:12], e.value)
+
+				continue
+			}
+
+			order.PutUint32(raw[8:], valuesOffset+uint32(len(values)))
+			values = append(values, e.value...)
+		}
+
+		return concat(table, values)
+	}
+
+	header := make([]byte, 8)
+	if order == binary.LittleEndian {
+		copy(header, "II")
+	} else {
+		copy(header, "MM")
+	}
+
+	order.PutUint16(header[2:], 42)
+	order.PutUint32(header[4:], 8)
+
+	// The EXIF IFD pointer is known once IFD0 is laid out, its length doesn't depend on the pointer value
+	ifd0 := ifd(8, ascii(0x010f, cameraMake), ascii(0x0110, model), long(0x8769, 0))
+	exifOffset := uint32(8 + len(ifd0))
+	ifd0 = ifd(8, ascii(0x010f, cameraMake), ascii(0x0110, model), long(0x8769, exifOffset))
+
+	exifIFD := ifd(exifOffset,
+		ascii(0x9003, capturedAt.Format("2006:01:02 15:04:05")),
+		long(0xa002, width),
+		short(0xa003, uint16(height)),
+	)
+
+	return concat(header, ifd0, exifIFD)
+}
@@ -0,0 +1,361 @@
+package mediameta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/pkg/errors"
+	"io"
+	"math"
+)
+
+// heifItem is where an item of the HEIF file is stored
+type heifItem struct {
+	itemType string
+	// constructionMethod is 0 for offsets in the file and 1 for offsets in idat
+	constructionMethod uint16
+	extents            []heifExtent
+}
+
+type heifExtent struct {
+	offset uint64
+	length uint64
+}
+
+// ReadHEIF reads the metadata of a HEIF file, e.g. .heic.
+// The meta box is read to find the EXIF item and the dimensions of the primary image, the image data is never read.
+func ReadHEIF(r io.ReadSeeker) (metadata Metadata, err error) {
+	for {
+		header, err := readBoxHeader(r)
+		if errors.Is(err, io.EOF) {
+			return Metadata{}, ErrNoMetadata
+		}
+		if err != nil {
+			return Metadata{}, err
+		}
+
+		if header.size < 0 {
+			return Metadata{}, ErrNoMetadata
+		}
+
+		if header.boxType != "meta" {
+			if _, err = r.Seek(header.size, io.SeekCurrent); err != nil {
+				return Metadata{}, errors.Wrapf(err, "error skipping %s box", header.boxType)
+			}
+
+			continue
+		}
+
+		if header.size < 4 || header.size > maxMetadataBoxSize {
+			return Metadata{}, errors.Errorf("unexpected meta box size: %d", header.size)
+		}
+
+		payload := make([]byte, header.size)
+		if _, err = io.ReadFull(r, payload); err != nil {
+			return Metadata{}, errors.Wrap(err, "error reading meta box")
+		}
+
+		// meta is a full box, its children follow the version and the flags
+		return readHEIFMeta(r, childBoxes(payload[4:]))
+	}
+}
+
+func readHEIFMeta(r io.ReadSeeker, children map[string][]byte) (metadata Metadata, err error) {
+	primaryID, err := parsePitm(children["pitm"])
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	items, err := parseIinf(children["iinf"])
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	if err = parseIloc(children["iloc"], items); err != nil {
+		return Metadata{}, err
+	}
+
+	found := false
+
+	for _, item := range items {
+		if item.itemType != "Exif" {
+			continue
+		}
+
+		data, err := readHEIFItem(r, item, children["idat"])
+		if err != nil {
+			return Metadata{}, err
+		}
+
+		if metadata, err = parseHEIFExif(data); err != nil {
+			return Metadata{}, err
+		}
+
+		found = true
+
+		break
+	}
+
+	if iprp, ok := children["iprp"]; ok {
+		if width, height, ok := primaryDimensions(childBoxes(iprp), primaryID); ok {
+			metadata.Width, metadata.Height = width, height
+			found = true
+		}
+	}
+
+	if !found {
+		return Metadata{}, ErrNoMetadata
+	}
+
+	return metadata, nil
+}
+
+// parsePitm reads the ID of the primary image
+func parsePitm(pitm []byte) (itemID uint32, err error) {
+	if pitm == nil {
+		return 0, nil
+	}
+
+	p := fullBoxReader{data: pitm}
+	version, _ := p.header()
+
+	return p.readID(version == 0), p.err("pitm")
+}
+
+// parseIinf reads the types of the items from their infe boxes by the item IDs
+func parseIinf(iinf []byte) (items map[uint32]*heifItem, err error) {
+	items = map[uint32]*heifItem{}
+
+	if iinf == nil {
+		return items, nil
+	}
+
+	p := fullBoxReader{data: iinf}
+	version, _ := p.header()
+	p.readID(version == 0)
+
+	if err = p.err("iinf"); err != nil {
+		return nil, err
+	}
+
+	for _, child := range splitBoxes(p.data[p.position:]) {
+		if child.boxType != "infe" {
+			continue
+		}
+
+		infe := fullBoxReader{data: child.payload}
+
+		// The versions before 2 have no item type, they don't describe EXIF
+		infeVersion, _ := infe.header()
+		if infeVersion < 2 {
+			continue
+		}
+
+		itemID := infe.readID(infeVersion == 2)
+		infe.readUint(2)
+		itemType := infe.readBytes(4)
+
+		if err = infe.err("infe"); err != nil {
+			return nil, err
+		}
+
+		items[itemID] = &heifItem{itemType: string(itemType)}
+	}
+
+	return items, nil
+}
+
+// parseIloc reads the extents of the items that iinf describes
+func parseIloc(iloc []byte, items map[uint32]*heifItem) (err error) {
+	if iloc == nil {
+		return nil
+	}
+
+	p := fullBoxReader{data: iloc}
+	version, _ := p.header()
+
+	if version > 2 {
+		return errors.Errorf("unsupported iloc version: %d", version)
+	}
+
+	sizes := p.readBytes(2)
+	if err = p.err("iloc"); err != nil {
+		return err
+	}
+
+	offsetSize, lengthSize, baseOffsetSize, indexSize := int(sizes[0]>>4), int(sizes[0]&0x0f), int(sizes[1]>>4), 0
+	if version > 0 {
+		indexSize = int(sizes[1] & 0x0f)
+	}
+
+	itemCount := p.readID(version < 2)
+
+	for i := uint32(0); i < itemCount && p.failed == nil; i++ {
+		item := &heifItem{}
+
+		itemID := p.readID(version < 2)
+		if version > 0 {
+			item.constructionMethod = uint16(p.readUint(2) & 0x0f)
+		}
+
+		p.readUint(2)
+		baseOffset := p.readUint(baseOffsetSize)
+		extentCount := p.readUint(2)
+
+		for j := uint64(0); j < extentCount && p.failed == nil; j++ {
+			p.readUint(indexSize)
+
+			offset := p.readUint(offsetSize)
+			if offset > math.MaxUint64-baseOffset {
+				return errors.Errorf("iloc extent offset is out of range: %d + %d", baseOffset, offset)
+			}
+
+			item.extents = append(item.extents, heifExtent{offset: baseOffset + offset, length: p.readUint(lengthSize)})
+		}
+
+		if described, ok := items[itemID]; ok {
+			described.constructionMethod, described.extents = item.constructionMethod, item.extents
+		}
+	}
+
+	return p.err("iloc")
+}
+
+// readHEIFItem reads the extents of the item from the file or from idat
+func readHEIFItem(r io.ReadSeeker, item *heifItem, idat []byte) (data []byte, err error) {
+	for _, extent := range item.extents {
+		if extent.length > maxMetadataBoxSize-uint64(len(data)) {
+			return nil, errors.Errorf("unexpected %s item size", item.itemType)
+		}
+
+		switch item.constructionMethod {
+		case 0:
+			if _, err = r.Seek(int64(extent.offset), io.SeekStart); err != nil {
+				return nil, errors.Wrapf(err, "error seeking to %s item", item.itemType)
+			}
+
+			chunk := make([]byte, extent.length)
+			if _, err = io.ReadFull(r, chunk); err != nil {
+				return nil, errors.Wrapf(err, "error reading %s item", item.itemType)
+			}
+
+			data = append(data, chunk...)
+		case 1:
+			if extent.offset > uint64(len(idat)) || extent.length > uint64(len(idat))-extent.offset {
+				return nil, errors.Errorf("%s item is out of idat bounds", item.itemType)
+			}
+
+			data = append(data, idat[extent.offset:extent.offset+extent.length]...)
+		default:
+			return nil, errors.Errorf("unsupported construction method of %s item: %d", item.itemType, item.constructionMethod)
+		}
+	}
+
+	return data, nil
+}
+
+// parseHEIFExif skips the offset the EXIF item starts with to get to the TIFF header
+func parseHEIFExif(data []byte) (metadata Metadata, err error) {
+	if len(data) < 4 {
+		return Metadata{}, errors.New("EXIF item is too short")
+	}
+
+	offset := uint64(binary.BigEndian.Uint32(data[:4])) + 4
+	if offset > uint64(len(data)) {
+		return Metadata{}, errors.New("EXIF item is out of bounds")
+	}
+
+	// Some writers keep the JPEG header before the TIFF one
+	return parseTIFF(bytes.TrimPrefix(data[offset:], exifHeader))
+}
+
+// primaryDimensions reads ispe of the primary image through the property associations of ipma
+func primaryDimensions(iprp map[string][]byte, primaryID uint32) (width, height int, ok bool) {
+	properties := splitBoxes(iprp["ipco"])
+
+	p := fullBoxReader{data: iprp["ipma"]}
+	version, flags := p.header()
+	largeIndexes := flags&1 == 1
+	entryCount := p.readUint(4)
+
+	for i := uint64(0); i < entryCount && p.failed == nil; i++ {
+		itemID := p.readID(version < 1)
+		associationCount := p.readUint(1)
+
+		for j := uint64(0); j < associationCount && p.failed == nil; j++ {
+			var index uint64
+			if largeIndexes {
+				index = p.readUint(2) & 0x7fff
+			} else {
+				index = p.readUint(1) & 0x7f
+			}
+
+			// The indexes start from 1, 0 means no property
+			if itemID != primaryID || index == 0 || index > uint64(len(properties)) {
+				continue
+			}
+
+			property := properties[index-1]
+			if property.boxType != "ispe" || len(property.payload) < 12 {
+				continue
+			}
+
+			width = int(binary.BigEndian.Uint32(property.payload[4:8]))
+			height = int(binary.BigEndian.Uint32(property.payload[8:12]))
+
+			return width, height, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// fullBoxReader reads the fields of a full box one after another, the first out of bounds read fails the rest
+type fullBoxReader struct {
+	data     []byte
+	position int
+	failed   error
+}
+
+// header reads the version and the flags every full box starts with
+func (f *fullBoxReader) header() (version uint8, flags uint32) {
+	return uint8(f.readUint(1)), uint32(f.readUint(3))
+}
+
+// readID reads a 16-bit ID of the old box versions or a 32-bit one
+func (f *fullBoxReader) readID(short bool) uint32 {
+	if short {
+		return uint32(f.readUint(2))
+	}
+
+	return uint32(f.readUint(4))
+}
+
+// readUint reads a big-endian unsigned integer of 0 to 8 bytes
+func (f *fullBoxReader) readUint(size int) (value uint64) {
+	for _, b := range f.readBytes(size) {
+		value = value<<8 | uint64(b)
+	}
+
+	return value
+}
+
+func (f *fullBoxReader) readBytes(size int) []byte {
+	if f.failed != nil || f.position+size > len(f.data) {
+		f.failed = io.ErrUnexpectedEOF
+
+		return make([]byte, size)
+	}
+
+	value := f.data[f.position : f.position+size]
+	f.position += size
+
+	return value
+}
+
+func (f *fullBoxReader) err(boxType string) error {
+	if f.failed != nil {
+		return errors.Wrapf(f.failed, "error reading %s box", boxType)
+	}
+
+	return nil
+}
@@ -0,0 +1,188 @@
+package mediameta_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/legosx/gopro-media-library-verifier/mediameta"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestReadHEIF(t *testing.T) {
+	type want struct {
+		metadata mediameta.Metadata
+		err      error
+		errMsg   string
+	}
+
+	capturedAt := time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)
+	exif := tiff(binary.BigEndian, "Apple", "iPhone 15 Pro", capturedAt, 4032, 3024)
+
+	tests := []struct {
+		name string
+		file []byte
+		want
+	}{
+		{
+			name: "happy path, EXIF item in mdat after its JPEG header",
+			file: heif(concat(uint32Bytes(6), exifHeaderBytes, exif), false),
+			want: want{
+				metadata: mediameta.Metadata{
+					CapturedAt:  capturedAt,
+					CameraMake:  "Apple",
+					CameraModel: "iPhone 15 Pro",
+					Width:       4284,
+					Height:      5712,
+				},
+			},
+		},
+		{
+			name: "happy path, EXIF item in idat",
+			file: heif(concat(uint32Bytes(0), exif), true),
+			want: want{
+				metadata: mediameta.Metadata{
+					CapturedAt:  capturedAt,
+					CameraMake:  "Apple",
+					CameraModel: "iPhone 15 Pro",
+					Width:       4284,
+					Height:      5712,
+				},
+			},
+		},
+		{
+			name: "sad path, idat extent offset wraps around",
+			file: heifExif(1, 0, heifExtent{offset: math.MaxUint64, length: 2}),
+			want: want{
+				errMsg: "Exif item is out of idat bounds",
+			},
+		},
+		{
+			name: "sad path, base offset and extent offset wrap around",
+			file: heifExif(0, math.MaxUint64, heifExtent{offset: 2, length: 2}),
+			want: want{
+				errMsg: "iloc extent offset is out of range: 18446744073709551615 + 2",
+			},
+		},
+		{
+			name: "sad path, extent lengths wrap around",
+			file: heifExif(1, 0, heifExtent{offset: 0, length: 4}, heifExtent{offset: 0, length: math.MaxUint64}),
+			want: want{
+				errMsg: "unexpected Exif item size",
+			},
+		},
+		{
+			name: "sad path, no meta",
+			file: concat(box("ftyp", []byte("heic")), box("mdat", make([]byte, 10))),
+			want: want{
+				err: mediameta.ErrNoMetadata,
+			},
+		},
+		{
+			name: "sad path, truncated pitm",
+			file: box("meta", make([]byte, 4), box("pitm", []byte{0, 0, 0, 0, 1})),
+			want: want{
+				errMsg: "error reading pitm box: unexpected EOF",
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := mediameta.ReadHEIF(bytes.NewReader(tt.file))
+
+			switch {
+			case tt.want.err != nil:
+				assert.ErrorIs(t, err, tt.want.err)
+			case tt.want.errMsg != "":
+				assert.EqualError(t, err, tt.want.errMsg)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.metadata, got)
+			}
+		})
+	}
+}
+
+// heif builds a file with the primary image 1 of 4284x5712 and the EXIF item 2, stored in mdat or in idat
+func heif(exifItem []byte, inIdat bool) []byte {
+	image := make([]byte, 256)
+
+	meta := func(imageOffset, exifOffset uint32) []byte {
+		constructionMethod := uint16(0)
+		idat := []byte{}
+
+		if inIdat {
+			constructionMethod, exifOffset, idat = 1, 0, box("idat", exifItem)
+		}
+
+		// Version 1 with 4-byte offsets and lengths and no base offsets
+		iloc := concat([]byte{1, 0, 0, 0, 0x44, 0x00}, uint16Bytes(2),
+			uint16Bytes(1), uint16Bytes(0), uint16Bytes(0), uint16Bytes(1), uint32Bytes(imageOffset), uint32Bytes(uint32(len(image))),
+			uint16Bytes(2), uint16Bytes(constructionMethod), uint16Bytes(0), uint16Bytes(1), uint32Bytes(exifOffset), uint32Bytes(uint32(len(exifItem))),
+		)
+
+		return box("meta", make([]byte, 4),
+			box("hdlr", make([]byte, 4), make([]byte, 4), []byte("pict"), make([]byte, 13)),
+			box("pitm", make([]byte, 4), uint16Bytes(1)),
+			box("iinf", make([]byte, 4), uint16Bytes(2),
+				box("infe", []byte{2, 0, 0, 0}, uint16Bytes(1), uint16Bytes(0), []byte("hvc1\x00")),
+				box("infe", []byte{2, 0, 0, 0}, uint16Bytes(2), uint16Bytes(0), []byte("Exif\x00")),
+			),
+			box("iloc", iloc),
+			box("iprp",
+				box("ipco", box("hvcC", make([]byte, 23)), box("ispe", make([]byte, 4), uint32Bytes(4284), uint32Bytes(5712))),
+				box("ipma", make([]byte, 4), uint32Bytes(1), uint16Bytes(1), []byte{2, 0x81, 0x02}),
+			),
+			idat,
+		)
+	}
+
+	ftyp := box("ftyp", []byte("heic"), make([]byte, 4), []byte("mif1heic"))
+
+	// The offsets in mdat are known once meta is laid out, its length doesn't depend on their values
+	imageOffset := uint32(len(ftyp) + len(meta(0, 0)) + 8)
+
+	return concat(ftyp, meta(imageOffset, imageOffset+uint32(len(image))), box("mdat", image, exifItem))
+}
+
+type heifExtent struct {
+	offset, length uint64
+}
+
+// heifExif builds a meta box with just the EXIF item 1 of the given extents, with 8-byte offsets and lengths, and a 16-byte idat
+func heifExif(constructionMethod uint16, baseOffset uint64, extents ...heifExtent) []byte {
+	iloc := concat([]byte{1, 0, 0, 0, 0x88, 0x80}, uint16Bytes(1),
+		uint16Bytes(1), uint16Bytes(constructionMethod), uint16Bytes(0), uint64Bytes(baseOffset), uint16Bytes(uint16(len(extents))),
+	)
+
+	for _, extent := range extents {
+		iloc = concat(iloc, uint64Bytes(extent.offset), uint64Bytes(extent.length))
+	}
+
+	return box("meta", make([]byte, 4),
+		box("iinf", make([]byte, 4), uint16Bytes(1),
+			box("infe", []byte{2, 0, 0, 0}, uint16Bytes(1), uint16Bytes(0), []byte("Exif\x00")),
+		),
+		box("iloc", iloc),
+		box("idat", make([]byte, 16)),
+	)
+}
+
+func uint16Bytes(value uint16) []byte {
+	return binary.BigEndian.AppendUint16(nil, value)
+}
+
+func uint32Bytes(value uint32) []byte {
+	return binary.BigEndian.AppendUint32(nil, value)
+}
+
+func uint64Bytes(value uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, value)
+}
@@ -0,0 +1,273 @@
+package mediameta
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// mp4Epoch is where the times of the mvhd box are counted from
+var mp4Epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// maxMetadataBoxSize bounds the boxes that are read into memory, mvhd is about a hundred bytes and udta a few kilobytes
+const maxMetadataBoxSize = 1 << 20
+
+// boxHeader is the size and the type every MP4 box starts with
+type boxHeader struct {
+	boxType string
+	// size is of the payload, without the header
+	size       int64
+	headerSize int64
+}
+
+// ReadMP4 reads the metadata of an MP4 or MOV file, e.g. .mp4, .mov or .360.
+// Only the box headers are read until moov is found, so the media data is skipped whatever its size.
+func ReadMP4(r io.ReadSeeker) (metadata Metadata, err error) {
+	for {
+		header, err := readBoxHeader(r)
+		if errors.Is(err, io.EOF) {
+			return Metadata{}, ErrNoMetadata
+		}
+		if err != nil {
+			return Metadata{}, err
+		}
+
+		if header.boxType != "moov" {
+			if header.size < 0 {
+				return Metadata{}, ErrNoMetadata
+			}
+
+			if _, err = r.Seek(header.size, io.SeekCurrent); err != nil {
+				return Metadata{}, errors.Wrapf(err, "error skipping %s box", header.boxType)
+			}
+
+			continue
+		}
+
+		return readMoov(r, header.size)
+	}
+}
+
+// readMoov reads mvhd and udta of moov, the tracks are skipped as the media data is, their tables are megabytes long
+func readMoov(r io.ReadSeeker, size int64) (metadata Metadata, err error) {
+	children := map[string][]byte{}
+
+	for size < 0 || size >= 8 {
+		header, err := readBoxHeader(r)
+		if errors.Is(err, io.EOF) && size < 0 {
+			break
+		}
+		if err != nil {
+			return Metadata{}, errors.Wrap(err, "error reading moov box")
+		}
+
+		if header.size < 0 || (size >= 0 && header.headerSize+header.size > size) {
+			return Metadata{}, errors.Errorf("invalid size of %s box", header.boxType)
+		}
+
+		if size >= 0 {
+			size -= header.headerSize + header.size
+		}
+
+		if header.boxType != "mvhd" && header.boxType != "udta" {
+			if _, err = r.Seek(header.size, io.SeekCurrent); err != nil {
+				return Metadata{}, errors.Wrapf(err, "error skipping %s box", header.boxType)
+			}
+
+			continue
+		}
+
+		if header.size > maxMetadataBoxSize {
+			return Metadata{}, errors.Errorf("unexpected %s box size: %d", header.boxType, header.size)
+		}
+
+		payload := make([]byte, header.size)
+		if _, err = io.ReadFull(r, payload); err != nil {
+			return Metadata{}, errors.Wrapf(err, "error reading %s box", header.boxType)
+		}
+
+		children[header.boxType] = payload
+	}
+
+	return parseMoov(children)
+}
+
+// readBoxHeader reads the header of the next box, the size is -1 when the box extends to the end of the file
+func readBoxHeader(r io.Reader) (header boxHeader, err error) {
+	buf := make([]byte, 8)
+	if _, err = io.ReadFull(r, buf); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return boxHeader{}, errors.Wrap(err, "error reading box header")
+		}
+
+		return boxHeader{}, err
+	}
+
+	size := int64(binary.BigEndian.Uint32(buf[:4]))
+	header.boxType = string(buf[4:8])
+	header.headerSize = 8
+
+	switch size {
+	case 0:
+		header.size = -1
+	case 1:
+		if _, err = io.ReadFull(r, buf); err != nil {
+			return boxHeader{}, errors.Wrap(err, "error reading box size")
+		}
+
+		header.headerSize = 16
+		header.size = int64(binary.BigEndian.Uint64(buf)) - 16
+	default:
+		header.size = size - 8
+	}
+
+	if header.size < -1 {
+		return boxHeader{}, errors.Errorf("invalid size of %s box", header.boxType)
+	}
+
+	return header, nil
+}
+
+// rawBox is a box inside the payload of its container
+type rawBox struct {
+	boxType string
+	payload []byte
+}
+
+// splitBoxes splits the payload of a container box into its children in order, a broken child ends the list
+func splitBoxes(payload []byte) (boxes []rawBox) {
+	for len(payload) >= 8 {
+		size := int64(binary.BigEndian.Uint32(payload[:4]))
+		boxType := string(payload[4:8])
+		headerSize := int64(8)
+
+		switch size {
+		case 0:
+			size = int64(len(payload))
+		case 1:
+			if len(payload) < 16 {
+				return boxes
+			}
+
+			size = int64(binary.BigEndian.Uint64(payload[8:16]))
+			headerSize = 16
+		}
+
+		if size < headerSize || size > int64(len(payload)) {
+			return boxes
+		}
+
+		boxes = append(boxes, rawBox{boxType: boxType, payload: payload[headerSize:size]})
+		payload = payload[size:]
+	}
+
+	return boxes
+}
+
+// childBoxes splits the payload of a container box into its children by type, the first child of a type is kept
+func childBoxes(payload []byte) (children map[string][]byte) {
+	children = map[string][]byte{}
+
+	for _, child := range splitBoxes(payload) {
+		if _, exists := children[child.boxType]; !exists {
+			children[child.boxType] = child.payload
+		}
+	}
+
+	return children
+}
+
+func parseMoov(children map[string][]byte) (metadata Metadata, err error) {
+	mvhd, ok := children["mvhd"]
+	if !ok {
+		return Metadata{}, ErrNoMetadata
+	}
+
+	if metadata, err = parseMvhd(mvhd); err != nil {
+		return Metadata{}, err
+	}
+
+	// GoPro cameras keep their own atoms in udta
+	if udta, ok := children["udta"]; ok {
+		udtaChildren := childBoxes(udta)
+
+		if firm, ok := udtaChildren["FIRM"]; ok {
+			metadata.Firmware = strings.TrimRight(string(firm), "\x00 ")
+			metadata.CameraModel = goProModel(metadata.Firmware)
+		}
+
+		if came, ok := udtaChildren["CAME"]; ok {
+			metadata.CameraID = hex.EncodeToString(came)
+		}
+	}
+
+	return metadata, nil
+}
+
+// parseMvhd reads the creation time and the duration, version 1 has 64-bit times
+func parseMvhd(mvhd []byte) (metadata Metadata, err error) {
+	if len(mvhd) < 4 {
+		return Metadata{}, errors.New("mvhd box is too short")
+	}
+
+	var creationTime, timescale, duration uint64
+
+	switch version := mvhd[0]; version {
+	case 0:
+		if len(mvhd) < 20 {
+			return Metadata{}, errors.New("mvhd box is too short")
+		}
+
+		creationTime = uint64(binary.BigEndian.Uint32(mvhd[4:8]))
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	case 1:
+		if len(mvhd) < 32 {
+			return Metadata{}, errors.New("mvhd box is too short")
+		}
+
+		creationTime = binary.BigEndian.Uint64(mvhd[4:12])
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[20:24]))
+		duration = binary.BigEndian.Uint64(mvhd[24:32])
+	default:
+		return Metadata{}, errors.Errorf("unsupported mvhd version: %d", version)
+	}
+
+	if creationTime > 0 {
+		metadata.CapturedAt = mp4Epoch.Add(time.Duration(creationTime) * time.Second)
+	}
+
+	if timescale > 0 {
+		// Whole seconds first, a long recording in a fine timescale would overflow otherwise
+		metadata.Duration = time.Duration(duration/timescale)*time.Second + time.Duration(duration%timescale)*time.Second/time.Duration(timescale)
+	}
+
+	return metadata, nil
+}
+
+// goProModels maps the firmware prefixes to the camera models
+var goProModels = map[string]string{
+	"HD3": "HERO3",
+	"HD4": "HERO4",
+	"HD5": "HERO5",
+	"HD6": "HERO6 Black",
+	"HD7": "HERO7",
+	"HD8": "HERO8 Black",
+	"HD9": "HERO9 Black",
+	"H19": "MAX",
+	"H21": "HERO10 Black",
+	"H22": "HERO11 Black",
+	"H23": "HERO12 Black",
+	"H24": "HERO13 Black",
+}
+
+func goProModel(firmware string) string {
+	if len(firmware) < 3 {
+		return ""
+	}
+
+	return goProModels[firmware[:3]]
+}
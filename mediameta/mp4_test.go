@@ -0,0 +1,139 @@
+package mediameta_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/legosx/gopro-media-library-verifier/mediameta"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestReadMP4(t *testing.T) {
+	type want struct {
+		metadata mediameta.Metadata
+		err      error
+		errMsg   string
+	}
+
+	capturedAt := time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)
+	udta := box("udta", box("FIRM", []byte("HD9.01.01.72.00")), box("CAME", []byte{0xca, 0xfe, 0x01}))
+
+	tests := []struct {
+		name string
+		file []byte
+		want
+	}{
+		{
+			name: "happy path, GoPro file with moov after the media data",
+			file: concat(
+				box("ftyp", []byte("mp42")),
+				box("mdat", make([]byte, 4096)),
+				box("moov", mvhdV0(capturedAt, 1000, 63500), box("trak", make([]byte, 512)), udta),
+			),
+			want: want{
+				metadata: mediameta.Metadata{
+					CapturedAt:  capturedAt,
+					Duration:    63500 * time.Millisecond,
+					CameraModel: "HERO9 Black",
+					Firmware:    "HD9.01.01.72.00",
+					CameraID:    "cafe01",
+				},
+			},
+		},
+		{
+			name: "happy path, version 1 mvhd and a 64-bit media data size",
+			file: concat(
+				largeBox("mdat", make([]byte, 100)),
+				box("moov", mvhdV1(capturedAt, 90000, 90000*3600*10)),
+			),
+			want: want{
+				metadata: mediameta.Metadata{
+					CapturedAt: capturedAt,
+					Duration:   10 * time.Hour,
+				},
+			},
+		},
+		{
+			name: "sad path, no moov",
+			file: concat(box("ftyp", []byte("mp42")), box("mdat", make([]byte, 10))),
+			want: want{
+				err: mediameta.ErrNoMetadata,
+			},
+		},
+		{
+			name: "sad path, truncated box header",
+			file: concat(box("ftyp", []byte("mp42")), []byte{0, 0, 0}),
+			want: want{
+				errMsg: "error reading box header: unexpected EOF",
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := mediameta.ReadMP4(bytes.NewReader(tt.file))
+
+			switch {
+			case tt.want.err != nil:
+				assert.ErrorIs(t, err, tt.want.err)
+			case tt.want.errMsg != "":
+				assert.EqualError(t, err, tt.want.errMsg)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.metadata, got)
+			}
+		})
+	}
+}
+
+func concat(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func box(boxType string, payloads ...[]byte) []byte {
+	payload := concat(payloads...)
+
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(8+len(payload)))
+	copy(header[4:], boxType)
+
+	return concat(header, payload)
+}
+
+func largeBox(boxType string, payload []byte) []byte {
+	header := make([]byte, 16)
+	binary.BigEndian.PutUint32(header, 1)
+	copy(header[4:], boxType)
+	binary.BigEndian.PutUint64(header[8:], uint64(16+len(payload)))
+
+	return concat(header, payload)
+}
+
+var mp4Epoch = time.Date(1904, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func mvhdV0(creationTime time.Time, timescale, duration uint32) []byte {
+	payload := make([]byte, 100)
+	binary.BigEndian.PutUint32(payload[4:], uint32(creationTime.Sub(mp4Epoch).Seconds()))
+	binary.BigEndian.PutUint32(payload[8:], uint32(creationTime.Sub(mp4Epoch).Seconds()))
+	binary.BigEndian.PutUint32(payload[12:], timescale)
+	binary.BigEndian.PutUint32(payload[16:], duration)
+
+	return box("mvhd", payload)
+}
+
+func mvhdV1(creationTime time.Time, timescale uint32, duration uint64) []byte {
+	payload := make([]byte, 112)
+	payload[0] = 1
+	binary.BigEndian.PutUint64(payload[4:], uint64(creationTime.Sub(mp4Epoch).Seconds()))
+	binary.BigEndian.PutUint64(payload[12:], uint64(creationTime.Sub(mp4Epoch).Seconds()))
+	binary.BigEndian.PutUint32(payload[20:], timescale)
+	binary.BigEndian.PutUint64(payload[24:], duration)
+
+	return box("mvhd", payload)
+}
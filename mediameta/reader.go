@@ -0,0 +1,71 @@
+package mediameta
+
+import (
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNoMetadata is returned when the file has no metadata the reader understands
+var ErrNoMetadata = errors.New("no metadata found")
+
+// Metadata is what the camera recorded in the file itself, the zero values are unknown
+type Metadata struct {
+	// CapturedAt is the creation time of the file, GoPro cameras write their local time as if it was UTC
+	CapturedAt  time.Time
+	Duration    time.Duration
+	CameraMake  string
+	CameraModel string
+	Firmware    string
+	// Width and Height are in pixels
+	Width  int
+	Height int
+	// CameraID identifies the camera that recorded the file
+	CameraID string
+}
+
+// readFuncs are the readers of the supported formats by the file extension
+var readFuncs = map[string]func(r io.ReadSeeker) (Metadata, error){
+	".mp4":  ReadMP4,
+	".mov":  ReadMP4,
+	".360":  ReadMP4,
+	".jpg":  ReadJPEG,
+	".jpeg": ReadJPEG,
+	".heic": ReadHEIF,
+	".heif": ReadHEIF,
+}
+
+// Reader reads the metadata of the local files by their extension
+type Reader struct{}
+
+func NewReader() Reader {
+	return Reader{}
+}
+
+// Read opens the file and reads its metadata, ErrNoMetadata is returned for an extension it doesn't know
+func (r Reader) Read(path string) (metadata Metadata, err error) {
+	read, ok := readFuncs[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return Metadata{}, ErrNoMetadata
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return Metadata{}, errors.Wrap(err, "error opening file")
+	}
+	defer func() {
+		if innerErr := file.Close(); innerErr != nil {
+			err = errors.Wrap(multierr.Append(err, innerErr), "error closing file")
+		}
+	}()
+
+	if metadata, err = read(file); err != nil {
+		return Metadata{}, errors.Wrapf(err, "error reading metadata of %s", path)
+	}
+
+	return metadata, nil
+}
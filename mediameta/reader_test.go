@@ -0,0 +1,37 @@
+package mediameta_test
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/mediameta"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReader_Read(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	capturedAt := time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)
+
+	videoPath := filepath.Join(dir, "GX010001.MP4")
+	assert.NoError(t, os.WriteFile(videoPath, box("moov", mvhdV0(capturedAt, 1000, 2000)), 0o600))
+
+	got, err := mediameta.NewReader().Read(videoPath)
+	assert.NoError(t, err)
+	assert.Equal(t, mediameta.Metadata{CapturedAt: capturedAt, Duration: 2 * time.Second}, got)
+
+	photoPath := filepath.Join(dir, "IMG_0001.JPG")
+	assert.NoError(t, os.WriteFile(photoPath, jpeg(segment(0xc0, sof(4000, 3000))), 0o600))
+
+	got, err = mediameta.NewReader().Read(photoPath)
+	assert.NoError(t, err)
+	assert.Equal(t, mediameta.Metadata{Width: 4000, Height: 3000}, got)
+
+	_, err = mediameta.NewReader().Read(filepath.Join(dir, "notes.txt"))
+	assert.ErrorIs(t, err, mediameta.ErrNoMetadata)
+
+	_, err = mediameta.NewReader().Read(filepath.Join(dir, "missing.mp4"))
+	assert.ErrorContains(t, err, "error opening file")
+}
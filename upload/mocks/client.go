@@ -0,0 +1,100 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/legosx/gopro-media-library-verifier/upload (interfaces: Client)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/client.go -package=mocks github.com/legosx/gopro-media-library-verifier/upload Client
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	io "io"
+	reflect "reflect"
+
+	client "github.com/legosx/gopro-media-library-verifier/client"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockClient is a mock of Client interface.
+type MockClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientMockRecorder
+}
+
+// MockClientMockRecorder is the mock recorder for MockClient.
+type MockClientMockRecorder struct {
+	mock *MockClient
+}
+
+// NewMockClient creates a new mock instance.
+func NewMockClient(ctrl *gomock.Controller) *MockClient {
+	mock := &MockClient{ctrl: ctrl}
+	mock.recorder = &MockClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClient) EXPECT() *MockClientMockRecorder {
+	return m.recorder
+}
+
+// CompleteUpload mocks base method.
+func (m *MockClient) CompleteUpload(arg0 context.Context, arg1 client.Upload, arg2 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompleteUpload", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompleteUpload indicates an expected call of CompleteUpload.
+func (mr *MockClientMockRecorder) CompleteUpload(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompleteUpload", reflect.TypeOf((*MockClient)(nil).CompleteUpload), arg0, arg1, arg2)
+}
+
+// CreateUpload mocks base method.
+func (m *MockClient) CreateUpload(arg0 context.Context, arg1 string, arg2 int64) (client.Upload, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUpload", arg0, arg1, arg2)
+	ret0, _ := ret[0].(client.Upload)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUpload indicates an expected call of CreateUpload.
+func (mr *MockClientMockRecorder) CreateUpload(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUpload", reflect.TypeOf((*MockClient)(nil).CreateUpload), arg0, arg1, arg2)
+}
+
+// GetUploadParts mocks base method.
+func (m *MockClient) GetUploadParts(arg0 context.Context, arg1 client.Upload, arg2, arg3 int64) ([]client.UploadPart, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUploadParts", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]client.UploadPart)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUploadParts indicates an expected call of GetUploadParts.
+func (mr *MockClientMockRecorder) GetUploadParts(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUploadParts", reflect.TypeOf((*MockClient)(nil).GetUploadParts), arg0, arg1, arg2, arg3)
+}
+
+// UploadPart mocks base method.
+func (m *MockClient) UploadPart(arg0 context.Context, arg1 client.UploadPart, arg2 io.Reader, arg3 int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UploadPart", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UploadPart indicates an expected call of UploadPart.
+func (mr *MockClientMockRecorder) UploadPart(arg0, arg1, arg2, arg3 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UploadPart", reflect.TypeOf((*MockClient)(nil).UploadPart), arg0, arg1, arg2, arg3)
+}
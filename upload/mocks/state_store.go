@@ -0,0 +1,84 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: github.com/legosx/gopro-media-library-verifier/upload (interfaces: StateStore)
+//
+// Generated by this command:
+//
+//	mockgen -destination=./mocks/state_store.go -package=mocks github.com/legosx/gopro-media-library-verifier/upload StateStore
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	reflect "reflect"
+
+	upload "github.com/legosx/gopro-media-library-verifier/upload"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockStateStore is a mock of StateStore interface.
+type MockStateStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockStateStoreMockRecorder
+}
+
+// MockStateStoreMockRecorder is the mock recorder for MockStateStore.
+type MockStateStoreMockRecorder struct {
+	mock *MockStateStore
+}
+
+// NewMockStateStore creates a new mock instance.
+func NewMockStateStore(ctrl *gomock.Controller) *MockStateStore {
+	mock := &MockStateStore{ctrl: ctrl}
+	mock.recorder = &MockStateStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockStateStore) EXPECT() *MockStateStoreMockRecorder {
+	return m.recorder
+}
+
+// Delete mocks base method.
+func (m *MockStateStore) Delete(arg0 string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockStateStoreMockRecorder) Delete(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockStateStore)(nil).Delete), arg0)
+}
+
+// Get mocks base method.
+func (m *MockStateStore) Get(arg0 string) (upload.State, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", arg0)
+	ret0, _ := ret[0].(upload.State)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockStateStoreMockRecorder) Get(arg0 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStateStore)(nil).Get), arg0)
+}
+
+// Save mocks base method.
+func (m *MockStateStore) Save(arg0 string, arg1 upload.State) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save.
+func (mr *MockStateStoreMockRecorder) Save(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockStateStore)(nil).Save), arg0, arg1)
+}
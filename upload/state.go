@@ -0,0 +1,154 @@
+package upload
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+type State struct {
+	MediaID        string `json:"media_id"`
+	DerivativeID   string `json:"derivative_id"`
+	UploadID       string `json:"upload_id"`
+	FileSize       int64  `json:"file_size"`
+	PartSize       int64  `json:"part_size"`
+	CompletedParts []int  `json:"completed_parts"`
+}
+
+func (s State) IsPartCompleted(number int) bool {
+	for _, completedPart := range s.CompletedParts {
+		if completedPart == number {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FileStateStore keeps the progress of unfinished uploads in a JSON file, so they can be resumed by the next run.
+type FileStateStore struct {
+	filePath string
+	mu       *sync.Mutex
+}
+
+func NewFileStateStore(filePath string) FileStateStore {
+	return FileStateStore{
+		filePath: filePath,
+		mu:       &sync.Mutex{},
+	}
+}
+
+func DefaultStateFilePath() (filePath string, err error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", errors.Wrap(err, "error getting user cache directory")
+	}
+
+	return filepath.Join(cacheDir, "gopro-media-library-verifier", "uploads.json"), nil
+}
+
+func (s FileStateStore) Get(filePath string) (state State, ok bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states, err := s.read()
+	if err != nil {
+		return State{}, false, err
+	}
+
+	state, ok = states[filePath]
+
+	return state, ok, nil
+}
+
+func (s FileStateStore) Save(filePath string, state State) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	states[filePath] = state
+
+	return s.write(states)
+}
+
+// MediaIDs returns the IDs of the medias the unfinished uploads registered in Gopro Media Library
+func (s FileStateStore) MediaIDs() (mediaIDs []string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, state := range states {
+		if state.MediaID != "" {
+			mediaIDs = append(mediaIDs, state.MediaID)
+		}
+	}
+
+	sort.Strings(mediaIDs)
+
+	return mediaIDs, nil
+}
+
+func (s FileStateStore) Delete(filePath string) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	states, err := s.read()
+	if err != nil {
+		return err
+	}
+
+	if _, ok := states[filePath]; !ok {
+		return nil
+	}
+
+	delete(states, filePath)
+
+	return s.write(states)
+}
+
+func (s FileStateStore) read() (states map[string]State, err error) {
+	states = map[string]State{}
+
+	data, err := os.ReadFile(s.filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return states, nil
+		}
+
+		return nil, errors.Wrap(err, "error reading upload state file")
+	}
+
+	if err = json.Unmarshal(data, &states); err != nil {
+		return nil, errors.Wrap(err, "error decoding upload state file")
+	}
+
+	return states, nil
+}
+
+func (s FileStateStore) write(states map[string]State) (err error) {
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "error encoding upload state file")
+	}
+
+	if err = os.MkdirAll(filepath.Dir(s.filePath), 0755); err != nil {
+		return errors.Wrap(err, "error creating upload state directory")
+	}
+
+	if err = os.WriteFile(s.filePath, data, 0644); err != nil {
+		return errors.Wrap(err, "error writing upload state file")
+	}
+
+	return nil
+}
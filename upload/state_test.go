@@ -0,0 +1,79 @@
+package upload_test
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/upload"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestState_IsPartCompleted(t *testing.T) {
+	state := upload.State{CompletedParts: []int{1, 3}}
+
+	assert.True(t, state.IsPartCompleted(1))
+	assert.False(t, state.IsPartCompleted(2))
+	assert.True(t, state.IsPartCompleted(3))
+}
+
+func TestFileStateStore(t *testing.T) {
+	t.Parallel()
+
+	store := upload.NewFileStateStore(filepath.Join(t.TempDir(), "nested", "uploads.json"))
+
+	_, ok, err := store.Get("/dir/file1.mp4")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	state := upload.State{
+		MediaID:        "media1",
+		DerivativeID:   "derivative1",
+		UploadID:       "upload1",
+		FileSize:       15,
+		PartSize:       10,
+		CompletedParts: []int{1},
+	}
+
+	assert.NoError(t, store.Save("/dir/file1.mp4", state))
+	assert.NoError(t, store.Save("/dir/file2.mp4", upload.State{MediaID: "media2"}))
+
+	got, ok, err := store.Get("/dir/file1.mp4")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, state, got)
+
+	assert.NoError(t, store.Delete("/dir/file1.mp4"))
+	assert.NoError(t, store.Delete("/dir/file1.mp4"))
+
+	_, ok, err = store.Get("/dir/file1.mp4")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = store.Get("/dir/file2.mp4")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestFileStateStore_InvalidFile(t *testing.T) {
+	t.Parallel()
+
+	filePath := filepath.Join(t.TempDir(), "uploads.json")
+	assert.NoError(t, os.WriteFile(filePath, []byte("{invalid}"), 0644))
+
+	store := upload.NewFileStateStore(filePath)
+
+	_, _, err := store.Get("/dir/file1.mp4")
+	assert.EqualError(t, err, "error decoding upload state file: invalid character 'i' looking for beginning of object key string")
+
+	err = store.Save("/dir/file1.mp4", upload.State{})
+	assert.EqualError(t, err, "error decoding upload state file: invalid character 'i' looking for beginning of object key string")
+
+	err = store.Delete("/dir/file1.mp4")
+	assert.EqualError(t, err, "error decoding upload state file: invalid character 'i' looking for beginning of object key string")
+}
+
+func TestDefaultStateFilePath(t *testing.T) {
+	got, err := upload.DefaultStateFilePath()
+	assert.NoError(t, err)
+	assert.Equal(t, "uploads.json", filepath.Base(got))
+}
@@ -0,0 +1,169 @@
+package upload
+
+import (
+	"context"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/pkg/errors"
+	"go.uber.org/multierr"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const DefaultPartSize int64 = 64 * 1024 * 1024
+
+type Client interface {
+	CreateUpload(ctx context.Context, fileName string, fileSize int64) (upload client.Upload, err error)
+	GetUploadParts(ctx context.Context, upload client.Upload, fileSize, partSize int64) (parts []client.UploadPart, err error)
+	UploadPart(ctx context.Context, part client.UploadPart, body io.Reader, size int64) (err error)
+	CompleteUpload(ctx context.Context, upload client.Upload, fileSize int64) (err error)
+}
+
+type StateStore interface {
+	Get(filePath string) (state State, ok bool, err error)
+	Save(filePath string, state State) (err error)
+	Delete(filePath string) (err error)
+}
+
+type Uploader struct {
+	client         Client
+	stateStore     StateStore
+	partSize       int64
+	maxPartRetries int
+}
+
+func NewUploader(client Client, stateStore StateStore, opts ...func(uploader *Uploader)) Uploader {
+	u := Uploader{
+		client:         client,
+		stateStore:     stateStore,
+		partSize:       DefaultPartSize,
+		maxPartRetries: 3,
+	}
+
+	for _, opt := range opts {
+		opt(&u)
+	}
+
+	return u
+}
+
+func WithPartSize(partSize int64) func(u *Uploader) {
+	return func(u *Uploader) {
+		u.partSize = partSize
+	}
+}
+
+func WithMaxPartRetries(maxPartRetries int) func(u *Uploader) {
+	return func(u *Uploader) {
+		u.maxPartRetries = maxPartRetries
+	}
+}
+
+// UploadFile uploads the file in parts.
+// Progress is saved after every part, so a failed upload continues from the first unfinished part on the next call.
+func (u Uploader) UploadFile(ctx context.Context, filePath string) (err error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return errors.Wrap(err, "error opening file")
+	}
+	defer func() {
+		if innerErr := file.Close(); innerErr != nil {
+			err = errors.Wrap(multierr.Append(err, innerErr), "error closing file")
+		}
+	}()
+
+	fileInfo, err := file.Stat()
+	if err != nil {
+		return errors.Wrap(err, "error getting file info")
+	}
+
+	state, err := u.getState(ctx, filePath, fileInfo.Size())
+	if err != nil {
+		return err
+	}
+
+	upload := client.NewUpload(state.MediaID, state.DerivativeID, state.UploadID)
+
+	parts, err := u.client.GetUploadParts(ctx, upload, state.FileSize, state.PartSize)
+	if err != nil {
+		return errors.Wrap(err, "error getting upload parts")
+	}
+
+	for _, part := range parts {
+		if state.IsPartCompleted(part.Number()) {
+			continue
+		}
+
+		if err = u.uploadPart(ctx, file, part, state); err != nil {
+			return err
+		}
+
+		state.CompletedParts = append(state.CompletedParts, part.Number())
+		if err = u.stateStore.Save(filePath, state); err != nil {
+			return errors.Wrap(err, "error saving upload state")
+		}
+	}
+
+	if err = u.client.CompleteUpload(ctx, upload, state.FileSize); err != nil {
+		return errors.Wrap(err, "error completing upload")
+	}
+
+	if err = u.stateStore.Delete(filePath); err != nil {
+		return errors.Wrap(err, "error deleting upload state")
+	}
+
+	return nil
+}
+
+func (u Uploader) getState(ctx context.Context, filePath string, fileSize int64) (state State, err error) {
+	state, ok, err := u.stateStore.Get(filePath)
+	if err != nil {
+		return State{}, errors.Wrap(err, "error getting upload state")
+	}
+
+	// Parts uploaded by a previous attempt can only be reused if the file hasn't changed since
+	if ok && state.FileSize == fileSize && state.PartSize == u.partSize {
+		return state, nil
+	}
+
+	upload, err := u.client.CreateUpload(ctx, filepath.Base(filePath), fileSize)
+	if err != nil {
+		return State{}, errors.Wrap(err, "error creating upload")
+	}
+
+	state = State{
+		MediaID:        upload.MediaID(),
+		DerivativeID:   upload.DerivativeID(),
+		UploadID:       upload.UploadID(),
+		FileSize:       fileSize,
+		PartSize:       u.partSize,
+		CompletedParts: []int{},
+	}
+
+	if err = u.stateStore.Save(filePath, state); err != nil {
+		return State{}, errors.Wrap(err, "error saving upload state")
+	}
+
+	return state, nil
+}
+
+func (u Uploader) uploadPart(ctx context.Context, file io.ReaderAt, part client.UploadPart, state State) (err error) {
+	offset := int64(part.Number()-1) * state.PartSize
+	size := state.PartSize
+	if remaining := state.FileSize - offset; remaining < size {
+		size = remaining
+	}
+
+	for attempt := 0; attempt <= u.maxPartRetries; attempt++ {
+		if err = u.client.UploadPart(ctx, part, io.NewSectionReader(file, offset, size), size); err == nil {
+			return nil
+		}
+
+		// Retrying doesn't help once the run is cancelled
+		if ctx.Err() != nil {
+			return errors.Wrapf(err, "error uploading part %d", part.Number())
+		}
+	}
+
+	return errors.Wrapf(err, "error uploading part %d after %d retries", part.Number(), u.maxPartRetries)
+}
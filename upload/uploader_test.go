@@ -0,0 +1,459 @@
+package upload_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/upload"
+	"github.com/legosx/gopro-media-library-verifier/upload/mocks"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+//go:generate mockgen -destination=./mocks/client.go -package=mocks github.com/legosx/gopro-media-library-verifier/upload Client
+//go:generate mockgen -destination=./mocks/state_store.go -package=mocks github.com/legosx/gopro-media-library-verifier/upload StateStore
+
+func TestUploader_UploadFile(t *testing.T) {
+	type fields struct {
+		client     func(mockCtrl *gomock.Controller) upload.Client
+		stateStore func(mockCtrl *gomock.Controller) upload.StateStore
+	}
+
+	type args struct {
+		fileName string
+	}
+
+	type want struct {
+		err error
+	}
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "GX010001.MP4")
+	assert.NoError(t, os.WriteFile(filePath, []byte("0123456789abcde"), 0644))
+
+	newUpload := client.NewUpload("media1", "derivative1", "upload1")
+	parts := []client.UploadPart{
+		client.NewUploadPart(1, "https://storage/1"),
+		client.NewUploadPart(2, "https://storage/2"),
+	}
+
+	tests := []struct {
+		name string
+		fields
+		args
+		want
+	}{
+		{
+			name: "happy path, new upload",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) upload.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().CreateUpload(gomock.Any(), "GX010001.MP4", int64(15)).Return(newUpload, nil)
+					mock.EXPECT().GetUploadParts(gomock.Any(), newUpload, int64(15), int64(10)).Return(parts, nil)
+					mock.EXPECT().UploadPart(gomock.Any(), parts[0], gomock.Any(), int64(10)).DoAndReturn(func(ctx context.Context, part client.UploadPart, body io.Reader, size int64) error {
+						data, err := io.ReadAll(body)
+						assert.NoError(t, err)
+						assert.Equal(t, "0123456789", string(data))
+
+						return nil
+					})
+					mock.EXPECT().UploadPart(gomock.Any(), parts[1], gomock.Any(), int64(5)).DoAndReturn(func(ctx context.Context, part client.UploadPart, body io.Reader, size int64) error {
+						data, err := io.ReadAll(body)
+						assert.NoError(t, err)
+						assert.Equal(t, "abcde", string(data))
+
+						return nil
+					})
+					mock.EXPECT().CompleteUpload(gomock.Any(), newUpload, int64(15)).Return(nil)
+
+					return mock
+				},
+				stateStore: func(mockCtrl *gomock.Controller) upload.StateStore {
+					mock := mocks.NewMockStateStore(mockCtrl)
+					mock.EXPECT().Get(filePath).Return(upload.State{}, false, nil)
+					mock.EXPECT().Save(filePath, gomock.Any()).Times(3).Return(nil)
+					mock.EXPECT().Delete(filePath).Return(nil)
+
+					return mock
+				},
+			},
+			args: args{fileName: "GX010001.MP4"},
+		},
+		{
+			name: "happy path, resumed upload",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) upload.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().GetUploadParts(gomock.Any(), newUpload, int64(15), int64(10)).Return(parts, nil)
+					mock.EXPECT().UploadPart(gomock.Any(), parts[1], gomock.Any(), int64(5)).Return(nil)
+					mock.EXPECT().CompleteUpload(gomock.Any(), newUpload, int64(15)).Return(nil)
+
+					return mock
+				},
+				stateStore: func(mockCtrl *gomock.Controller) upload.StateStore {
+					mock := mocks.NewMockStateStore(mockCtrl)
+					mock.EXPECT().Get(filePath).Return(upload.State{
+						MediaID:        "media1",
+						DerivativeID:   "derivative1",
+						UploadID:       "upload1",
+						FileSize:       15,
+						PartSize:       10,
+						CompletedParts: []int{1},
+					}, true, nil)
+					mock.EXPECT().Save(filePath, upload.State{
+						MediaID:        "media1",
+						DerivativeID:   "derivative1",
+						UploadID:       "upload1",
+						FileSize:       15,
+						PartSize:       10,
+						CompletedParts: []int{1, 2},
+					}).Return(nil)
+					mock.EXPECT().Delete(filePath).Return(nil)
+
+					return mock
+				},
+			},
+			args: args{fileName: "GX010001.MP4"},
+		},
+		{
+			name: "happy path, stale state of a changed file is replaced",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) upload.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().CreateUpload(gomock.Any(), "GX010001.MP4", int64(15)).Return(newUpload, nil)
+					mock.EXPECT().GetUploadParts(gomock.Any(), newUpload, int64(15), int64(10)).Return(parts, nil)
+					mock.EXPECT().UploadPart(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2).Return(nil)
+					mock.EXPECT().CompleteUpload(gomock.Any(), newUpload, int64(15)).Return(nil)
+
+					return mock
+				},
+				stateStore: func(mockCtrl *gomock.Controller) upload.StateStore {
+					mock := mocks.NewMockStateStore(mockCtrl)
+					mock.EXPECT().Get(filePath).Return(upload.State{
+						MediaID:        "old",
+						FileSize:       30,
+						PartSize:       10,
+						CompletedParts: []int{1, 2},
+					}, true, nil)
+					mock.EXPECT().Save(filePath, gomock.Any()).Times(3).Return(nil)
+					mock.EXPECT().Delete(filePath).Return(nil)
+
+					return mock
+				},
+			},
+			args: args{fileName: "GX010001.MP4"},
+		},
+		{
+			name: "sad path, file does not exist",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) upload.Client {
+					return mocks.NewMockClient(mockCtrl)
+				},
+				stateStore: func(mockCtrl *gomock.Controller) upload.StateStore {
+					return mocks.NewMockStateStore(mockCtrl)
+				},
+			},
+			args: args{fileName: "does-not-exist.mp4"},
+			want: want{
+				err: errors.Errorf("error opening file: open %s: no such file or directory", filepath.Join(dir, "does-not-exist.mp4")),
+			},
+		},
+		{
+			name: "sad path, state store error",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) upload.Client {
+					return mocks.NewMockClient(mockCtrl)
+				},
+				stateStore: func(mockCtrl *gomock.Controller) upload.StateStore {
+					mock := mocks.NewMockStateStore(mockCtrl)
+					mock.EXPECT().Get(filePath).Return(upload.State{}, false, assert.AnError)
+
+					return mock
+				},
+			},
+			args: args{fileName: "GX010001.MP4"},
+			want: want{
+				err: errors.Wrap(assert.AnError, "error getting upload state"),
+			},
+		},
+		{
+			name: "sad path, create upload error",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) upload.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().CreateUpload(gomock.Any(), "GX010001.MP4", int64(15)).Return(client.Upload{}, assert.AnError)
+
+					return mock
+				},
+				stateStore: func(mockCtrl *gomock.Controller) upload.StateStore {
+					mock := mocks.NewMockStateStore(mockCtrl)
+					mock.EXPECT().Get(filePath).Return(upload.State{}, false, nil)
+
+					return mock
+				},
+			},
+			args: args{fileName: "GX010001.MP4"},
+			want: want{
+				err: errors.Wrap(assert.AnError, "error creating upload"),
+			},
+		},
+		{
+			name: "sad path, get upload parts error",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) upload.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().CreateUpload(gomock.Any(), "GX010001.MP4", int64(15)).Return(newUpload, nil)
+					mock.EXPECT().GetUploadParts(gomock.Any(), newUpload, int64(15), int64(10)).Return(nil, assert.AnError)
+
+					return mock
+				},
+				stateStore: func(mockCtrl *gomock.Controller) upload.StateStore {
+					mock := mocks.NewMockStateStore(mockCtrl)
+					mock.EXPECT().Get(filePath).Return(upload.State{}, false, nil)
+					mock.EXPECT().Save(filePath, gomock.Any()).Return(nil)
+
+					return mock
+				},
+			},
+			args: args{fileName: "GX010001.MP4"},
+			want: want{
+				err: errors.Wrap(assert.AnError, "error getting upload parts"),
+			},
+		},
+		{
+			name: "sad path, part keeps failing, progress is kept",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) upload.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().CreateUpload(gomock.Any(), "GX010001.MP4", int64(15)).Return(newUpload, nil)
+					mock.EXPECT().GetUploadParts(gomock.Any(), newUpload, int64(15), int64(10)).Return(parts, nil)
+					mock.EXPECT().UploadPart(gomock.Any(), parts[0], gomock.Any(), int64(10)).Return(nil)
+					mock.EXPECT().UploadPart(gomock.Any(), parts[1], gomock.Any(), int64(5)).Times(3).Return(assert.AnError)
+
+					return mock
+				},
+				stateStore: func(mockCtrl *gomock.Controller) upload.StateStore {
+					mock := mocks.NewMockStateStore(mockCtrl)
+					mock.EXPECT().Get(filePath).Return(upload.State{}, false, nil)
+					mock.EXPECT().Save(filePath, gomock.Any()).Return(nil)
+					mock.EXPECT().Save(filePath, upload.State{
+						MediaID:        "media1",
+						DerivativeID:   "derivative1",
+						UploadID:       "upload1",
+						FileSize:       15,
+						PartSize:       10,
+						CompletedParts: []int{1},
+					}).Return(nil)
+
+					return mock
+				},
+			},
+			args: args{fileName: "GX010001.MP4"},
+			want: want{
+				err: errors.Wrap(assert.AnError, "error uploading part 2 after 2 retries"),
+			},
+		},
+		{
+			name: "sad path, complete upload error",
+			fields: fields{
+				client: func(mockCtrl *gomock.Controller) upload.Client {
+					mock := mocks.NewMockClient(mockCtrl)
+					mock.EXPECT().CreateUpload(gomock.Any(), "GX010001.MP4", int64(15)).Return(newUpload, nil)
+					mock.EXPECT().GetUploadParts(gomock.Any(), newUpload, int64(15), int64(10)).Return(parts, nil)
+					mock.EXPECT().UploadPart(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(2).Return(nil)
+					mock.EXPECT().CompleteUpload(gomock.Any(), newUpload, int64(15)).Return(assert.AnError)
+
+					return mock
+				},
+				stateStore: func(mockCtrl *gomock.Controller) upload.StateStore {
+					mock := mocks.NewMockStateStore(mockCtrl)
+					mock.EXPECT().Get(filePath).Return(upload.State{}, false, nil)
+					mock.EXPECT().Save(filePath, gomock.Any()).Times(3).Return(nil)
+
+					return mock
+				},
+			},
+			args: args{fileName: "GX010001.MP4"},
+			want: want{
+				err: errors.Wrap(assert.AnError, "error completing upload"),
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			uploader := upload.NewUploader(
+				tt.fields.client(mockCtrl),
+				tt.fields.stateStore(mockCtrl),
+				upload.WithPartSize(10),
+				upload.WithMaxPartRetries(2),
+			)
+
+			err := uploader.UploadFile(context.Background(), filepath.Join(dir, tt.args.fileName))
+			if tt.want.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err.Error())
+			}
+		})
+	}
+}
+
+func TestUploader_UploadFile_FakeServer(t *testing.T) {
+	t.Parallel()
+
+	server := newFakeUploadServer()
+	defer server.Close()
+
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "GX010001.MP4")
+	content := "0123456789abcdefghijklmnopqrstuvwxyz"
+	assert.NoError(t, os.WriteFile(filePath, []byte(content), 0644))
+
+	c, err := client.NewClient("token", client.WithBaseURL(server.URL))
+	assert.NoError(t, err)
+
+	stateStore := upload.NewFileStateStore(filepath.Join(dir, "state", "uploads.json"))
+	uploader := upload.NewUploader(c, stateStore, upload.WithPartSize(10), upload.WithMaxPartRetries(1))
+
+	// The third part fails on every attempt of the first run
+	server.failPart(3, 2)
+
+	err = uploader.UploadFile(context.Background(), filePath)
+	assert.ErrorContains(t, err, "error uploading part 3 after 1 retries")
+
+	state, ok, err := stateStore.Get(filePath)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []int{1, 2}, state.CompletedParts)
+
+	// The second run resumes from the failed part
+	assert.NoError(t, uploader.UploadFile(context.Background(), filePath))
+
+	_, ok, err = stateStore.Get(filePath)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	assert.Equal(t, 1, server.createdMediaCount())
+	assert.Equal(t, map[int]int{1: 1, 2: 1, 3: 3, 4: 1}, server.partAttempts())
+	assert.Equal(t, content, server.uploadedContent())
+	assert.True(t, server.isCompleted())
+}
+
+type fakeUploadServer struct {
+	*httptest.Server
+	mu            sync.Mutex
+	mediaCount    int
+	parts         map[int]string
+	attempts      map[int]int
+	failures      map[int]int
+	completedPath []string
+}
+
+func newFakeUploadServer() *fakeUploadServer {
+	s := &fakeUploadServer{
+		parts:    map[int]string{},
+		attempts: map[int]int{},
+		failures: map[int]int{},
+	}
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+
+	return s
+}
+
+func (s *fakeUploadServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/media":
+		s.mediaCount++
+		_, _ = fmt.Fprint(w, `{"id": "media1"}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/derivatives":
+		_, _ = fmt.Fprint(w, `{"id": "derivative1"}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/user-uploads":
+		_, _ = fmt.Fprint(w, `{"id": "upload1"}`)
+	case r.Method == http.MethodGet && r.URL.Path == "/user-uploads/derivative1":
+		var authorizations []string
+		for part := 1; part <= 4; part++ {
+			authorizations = append(authorizations, fmt.Sprintf(`{"part": %d, "url": "%s/storage/%d"}`, part, s.URL, part))
+		}
+		_, _ = fmt.Fprintf(w, `{"_embedded": {"authorizations": [%s]}}`, strings.Join(authorizations, ","))
+	case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/storage/"):
+		var part int
+		_, _ = fmt.Sscanf(r.URL.Path, "/storage/%d", &part)
+		s.attempts[part]++
+
+		if s.failures[part] > 0 {
+			s.failures[part]--
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		data, _ := io.ReadAll(r.Body)
+		s.parts[part] = string(data)
+	case r.Method == http.MethodPut:
+		s.completedPath = append(s.completedPath, r.URL.Path)
+		_, _ = fmt.Fprint(w, `{}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (s *fakeUploadServer) failPart(part, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures[part] = times
+}
+
+func (s *fakeUploadServer) createdMediaCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.mediaCount
+}
+
+func (s *fakeUploadServer) partAttempts() map[int]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.attempts
+}
+
+func (s *fakeUploadServer) uploadedContent() (content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for part := 1; part <= len(s.parts); part++ {
+		content += s.parts[part]
+	}
+
+	return content
+}
+
+func (s *fakeUploadServer) isCompleted() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.completedPath) == 3
+}
@@ -0,0 +1,210 @@
+package uploadrun
+
+import (
+	"context"
+	"fmt"
+	"github.com/legosx/gopro-media-library-verifier/buildclient"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/dirscan"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/legosx/gopro-media-library-verifier/upload"
+	"github.com/legosx/gopro-media-library-verifier/verify"
+	"github.com/legosx/gopro-media-library-verifier/verifyrun"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"go.uber.org/multierr"
+	"sort"
+)
+
+type Runner struct {
+	path              string
+	stateFilePath     string
+	partSize          int64
+	tokenPromptMethod verifyrun.TokenPromptMethod
+	buildClient       func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error)
+	buildVerifier     func(fetcher verify.Fetcher, scanner dirscan.Scanner) Verifier
+	buildUploader     func(c *client.Client, stateStore upload.StateStore, partSize int64) Uploader
+}
+
+type Verifier interface {
+	IdentifyMissingFiles(ctx context.Context, path string) (filePaths []string, err error)
+}
+
+type Uploader interface {
+	UploadFile(ctx context.Context, filePath string) (err error)
+}
+
+func NewRunner(path, stateFilePath string, partSize int64, tokenPromptMethod verifyrun.TokenPromptMethod, opts ...func(*Runner)) (runner Runner) {
+	r := Runner{
+		path:              path,
+		stateFilePath:     stateFilePath,
+		partSize:          partSize,
+		tokenPromptMethod: tokenPromptMethod,
+		buildClient: func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+			return buildclient.NewBuilder(opts...).Build()
+		},
+		buildVerifier: func(fetcher verify.Fetcher, scanner dirscan.Scanner) Verifier {
+			// Files that are only missing from an incomplete listing would be uploaded twice
+			return verify.NewVerifier(fetcher, scanner, verify.WithStrict(true))
+		},
+		buildUploader: func(c *client.Client, stateStore upload.StateStore, partSize int64) Uploader {
+			return upload.NewUploader(c, stateStore, upload.WithPartSize(partSize))
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&r)
+	}
+
+	return r
+}
+
+func WithBuildClient(buildClient func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error)) func(r *Runner) {
+	return func(r *Runner) {
+		r.buildClient = buildClient
+	}
+}
+
+func WithBuildVerifier(buildVerifier func(fetcher verify.Fetcher, scanner dirscan.Scanner) Verifier) func(r *Runner) {
+	return func(r *Runner) {
+		r.buildVerifier = buildVerifier
+	}
+}
+
+func WithBuildUploader(buildUploader func(c *client.Client, stateStore upload.StateStore, partSize int64) Uploader) func(r *Runner) {
+	return func(r *Runner) {
+		r.buildUploader = buildUploader
+	}
+}
+
+func (r Runner) Run(ctx context.Context) (err error) {
+	builderOpts, err := verifyrun.BuildClientOptions(ctx, r.tokenPromptMethod)
+	if err != nil {
+		return err
+	}
+
+	fetcherOpts, err := verifyrun.FetcherOptions()
+	if err != nil {
+		return err
+	}
+
+	c, err := r.buildClient(builderOpts...)
+	if err != nil {
+		return verifyrun.NewAuthError(err)
+	}
+
+	stateFilePath := r.stateFilePath
+	if stateFilePath == "" {
+		if stateFilePath, err = upload.DefaultStateFilePath(); err != nil {
+			return err
+		}
+	}
+
+	stateStore := upload.NewFileStateStore(stateFilePath)
+
+	unfinishedMediaIDs, err := stateStore.MediaIDs()
+	if err != nil {
+		return err
+	}
+
+	fetcher := newUnfinishedUploadsFetcher(fetch.NewFetcher(*c, fetcherOpts...), unfinishedMediaIDs)
+	verifier := r.buildVerifier(fetcher, dirscan.NewScanner(c.GetAllowedExtensions()))
+
+	filePaths, err := verifier.IdentifyMissingFiles(ctx, r.path)
+	if err != nil {
+		return err
+	}
+
+	if len(filePaths) == 0 {
+		fmt.Println("\nAll files from specified local directory are already uploaded to Gopro Media Library.")
+		return nil
+	}
+
+	uploader := r.buildUploader(c, stateStore, r.partSize)
+
+	return r.uploadFiles(ctx, uploader, filePaths)
+}
+
+// unfinishedUploadsFetcher hides the medias registered by the aborted uploads.
+// Such a media isn't complete, hiding it keeps its file missing, so the upload is resumed instead of the file being reported as a conflict or as processing.
+type unfinishedUploadsFetcher struct {
+	fetcher  verify.Fetcher
+	mediaIDs map[string]bool
+}
+
+func newUnfinishedUploadsFetcher(fetcher verify.Fetcher, mediaIDs []string) unfinishedUploadsFetcher {
+	f := unfinishedUploadsFetcher{
+		fetcher:  fetcher,
+		mediaIDs: map[string]bool{},
+	}
+
+	for _, mediaID := range mediaIDs {
+		f.mediaIDs[mediaID] = true
+	}
+
+	return f
+}
+
+// GetMedias keeps the medias of an incomplete listing along with its error, the verifier decides whether it's acceptable
+func (f unfinishedUploadsFetcher) GetMedias(ctx context.Context) (medias []fetch.Media, err error) {
+	medias, err = f.fetcher.GetMedias(ctx)
+
+	filtered := make([]fetch.Media, 0, len(medias))
+	for _, media := range medias {
+		if !f.mediaIDs[media.ID()] {
+			filtered = append(filtered, media)
+		}
+	}
+
+	return filtered, err
+}
+
+// uploadFiles keeps going when a single file fails, so one broken file doesn't block the rest of the library
+func (r Runner) uploadFiles(ctx context.Context, uploader Uploader, filePaths []string) (err error) {
+	sort.Strings(filePaths)
+
+	fmt.Printf("\nUploading %d files to Gopro Media Library:\n", len(filePaths))
+
+	uploaded := 0
+	for i, filePath := range filePaths {
+		// The remaining files are left for the next run
+		if ctx.Err() != nil {
+			err = multierr.Append(err, errors.Wrap(ctx.Err(), "upload stopped"))
+			break
+		}
+
+		fmt.Printf("[%d/%d] %s\n", i+1, len(filePaths), filePath)
+
+		if uploadErr := uploader.UploadFile(ctx, filePath); uploadErr != nil {
+			fmt.Printf("Failed: %s\n", uploadErr)
+			err = multierr.Append(err, errors.Wrapf(uploadErr, "error uploading %s", filePath))
+
+			continue
+		}
+
+		uploaded++
+	}
+
+	fmt.Printf("\nUploaded: %d, failed: %d\n", uploaded, len(filePaths)-uploaded)
+
+	if err != nil {
+		fmt.Println("Run the command again to resume the failed uploads.")
+	}
+
+	return err
+}
+
+func Init(cmd *cobra.Command) error {
+	cmd.Flags().StringP("path", "p", "", "path to the local directory to upload missing files from")
+
+	if err := cmd.MarkFlagRequired("path"); err != nil {
+		return err
+	}
+
+	cmd.Flags().String("stateFilePath", "", "a path to a file where the progress of unfinished uploads is kept (default is the user cache directory)")
+	cmd.Flags().Int64("partSize", upload.DefaultPartSize, "size of a single upload part in bytes")
+
+	verifyrun.InitTokenPromptMethod(cmd)
+
+	return nil
+}
@@ -0,0 +1,273 @@
+package uploadrun_test
+
+import (
+	"context"
+	"github.com/legosx/gopro-media-library-verifier/buildclient"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/dirscan"
+	"github.com/legosx/gopro-media-library-verifier/fakeapi"
+	"github.com/legosx/gopro-media-library-verifier/upload"
+	"github.com/legosx/gopro-media-library-verifier/uploadrun"
+	"github.com/legosx/gopro-media-library-verifier/uploadrun/mocks"
+	"github.com/legosx/gopro-media-library-verifier/verify"
+	"github.com/legosx/gopro-media-library-verifier/verifyrun"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+//go:generate mockgen -destination=./mocks/verifier.go -package=mocks github.com/legosx/gopro-media-library-verifier/uploadrun Verifier
+//go:generate mockgen -destination=./mocks/uploader.go -package=mocks github.com/legosx/gopro-media-library-verifier/uploadrun Uploader
+
+func TestRunner_Run(t *testing.T) {
+	type fields struct {
+		path              string
+		tokenPromptMethod verifyrun.TokenPromptMethod
+		opts              func(mockCtrl *gomock.Controller) []func(*uploadrun.Runner)
+	}
+
+	type want struct {
+		err error
+	}
+
+	buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+		return &client.Client{}, nil
+	}
+
+	tests := []struct {
+		name string
+		fields
+		want
+	}{
+		{
+			name: "happy path",
+			fields: fields{
+				path:              "test",
+				tokenPromptMethod: verifyrun.TokenPromptMethodInput,
+				opts: func(mockCtrl *gomock.Controller) []func(*uploadrun.Runner) {
+					buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) uploadrun.Verifier {
+						verifier := mocks.NewMockVerifier(mockCtrl)
+						verifier.EXPECT().IdentifyMissingFiles(gomock.Any(), "test").Return([]string{"test/file2.mp4", "test/file1.mp4"}, nil)
+
+						return verifier
+					}
+
+					buildUploader := func(c *client.Client, stateStore upload.StateStore, partSize int64) uploadrun.Uploader {
+						assert.Equal(t, int64(10), partSize)
+
+						uploader := mocks.NewMockUploader(mockCtrl)
+						gomock.InOrder(
+							uploader.EXPECT().UploadFile(gomock.Any(), "test/file1.mp4").Return(nil),
+							uploader.EXPECT().UploadFile(gomock.Any(), "test/file2.mp4").Return(nil),
+						)
+
+						return uploader
+					}
+
+					return []func(*uploadrun.Runner){
+						uploadrun.WithBuildClient(buildClient),
+						uploadrun.WithBuildVerifier(buildVerifier),
+						uploadrun.WithBuildUploader(buildUploader),
+					}
+				},
+			},
+		},
+		{
+			name: "happy path, nothing to upload",
+			fields: fields{
+				path: "test",
+				opts: func(mockCtrl *gomock.Controller) []func(*uploadrun.Runner) {
+					buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) uploadrun.Verifier {
+						verifier := mocks.NewMockVerifier(mockCtrl)
+						verifier.EXPECT().IdentifyMissingFiles(gomock.Any(), "test").Return([]string{}, nil)
+
+						return verifier
+					}
+
+					buildUploader := func(c *client.Client, stateStore upload.StateStore, partSize int64) uploadrun.Uploader {
+						t.Error("uploader should not be built")
+
+						return nil
+					}
+
+					return []func(*uploadrun.Runner){
+						uploadrun.WithBuildClient(buildClient),
+						uploadrun.WithBuildVerifier(buildVerifier),
+						uploadrun.WithBuildUploader(buildUploader),
+					}
+				},
+			},
+		},
+		{
+			name: "sad path, one file fails, the rest is still uploaded",
+			fields: fields{
+				path: "test",
+				opts: func(mockCtrl *gomock.Controller) []func(*uploadrun.Runner) {
+					buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) uploadrun.Verifier {
+						verifier := mocks.NewMockVerifier(mockCtrl)
+						verifier.EXPECT().IdentifyMissingFiles(gomock.Any(), "test").Return([]string{"test/file1.mp4", "test/file2.mp4"}, nil)
+
+						return verifier
+					}
+
+					buildUploader := func(c *client.Client, stateStore upload.StateStore, partSize int64) uploadrun.Uploader {
+						uploader := mocks.NewMockUploader(mockCtrl)
+						uploader.EXPECT().UploadFile(gomock.Any(), "test/file1.mp4").Return(assert.AnError)
+						uploader.EXPECT().UploadFile(gomock.Any(), "test/file2.mp4").Return(nil)
+
+						return uploader
+					}
+
+					return []func(*uploadrun.Runner){
+						uploadrun.WithBuildClient(buildClient),
+						uploadrun.WithBuildVerifier(buildVerifier),
+						uploadrun.WithBuildUploader(buildUploader),
+					}
+				},
+			},
+			want: want{
+				err: errors.Wrap(assert.AnError, "error uploading test/file1.mp4"),
+			},
+		},
+		{
+			name: "sad path, verifier fails",
+			fields: fields{
+				path: "test",
+				opts: func(mockCtrl *gomock.Controller) []func(*uploadrun.Runner) {
+					buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) uploadrun.Verifier {
+						verifier := mocks.NewMockVerifier(mockCtrl)
+						verifier.EXPECT().IdentifyMissingFiles(gomock.Any(), "test").Return([]string{}, assert.AnError)
+
+						return verifier
+					}
+
+					return []func(*uploadrun.Runner){
+						uploadrun.WithBuildClient(buildClient),
+						uploadrun.WithBuildVerifier(buildVerifier),
+					}
+				},
+			},
+			want: want{
+				err: assert.AnError,
+			},
+		},
+		{
+			name: "sad path, buildClient fails",
+			fields: fields{
+				path: "test",
+				opts: func(mockCtrl *gomock.Controller) []func(*uploadrun.Runner) {
+					buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+						return nil, assert.AnError
+					}
+
+					return []func(*uploadrun.Runner){
+						uploadrun.WithBuildClient(buildClient),
+					}
+				},
+			},
+			want: want{
+				err: assert.AnError,
+			},
+		},
+		{
+			name: "sad path, invalid token prompt method",
+			fields: fields{
+				path:              "test",
+				tokenPromptMethod: "invalid",
+				opts: func(mockCtrl *gomock.Controller) []func(*uploadrun.Runner) {
+					return []func(*uploadrun.Runner){}
+				},
+			},
+			want: want{
+				err: errors.New("invalid token prompt method: invalid"),
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			stateFilePath := filepath.Join(t.TempDir(), "uploads.json")
+
+			err := uploadrun.NewRunner(tt.path, stateFilePath, 10, tt.tokenPromptMethod, tt.fields.opts(mockCtrl)...).Run(context.Background())
+			if tt.want.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err.Error())
+			}
+		})
+	}
+}
+
+func TestRunner_Init(t *testing.T) {
+	t.Parallel()
+
+	cmd := &cobra.Command{}
+
+	assert.NoError(t, uploadrun.Init(cmd))
+	assert.NotNil(t, cmd.Flag("path"))
+	assert.NotNil(t, cmd.Flag("stateFilePath"))
+	assert.NotNil(t, cmd.Flag("partSize"))
+	assert.NotNil(t, cmd.Flag("tokenPromptMethod"))
+}
+
+func TestRunner_Run_AbortedUpload(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	path := t.TempDir()
+	abortedPath, uploadedPath := filepath.Join(path, "GX010001.MP4"), filepath.Join(path, "GX010002.MP4")
+
+	for filePath, size := range map[string]int64{abortedPath: 1000, uploadedPath: 2000} {
+		assert.NoError(t, os.WriteFile(filePath, make([]byte, size), 0644))
+	}
+
+	// The aborted upload registered its media, it stays in Gopro Media Library until the upload is finished
+	capturedAt := time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)
+	httpServer := httptest.NewServer(fakeapi.NewServer(fakeapi.NewLibrary([]fakeapi.Media{
+		{ID: "media1", FileName: "GX010001.MP4", FileSize: 1000, CapturedAt: capturedAt, ReadyToView: "registered"},
+		{ID: "media2", FileName: "GX010002.MP4", FileSize: 2000, CapturedAt: capturedAt, ReadyToView: "ready"},
+	})))
+	defer httpServer.Close()
+
+	buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+		return client.NewClient(fakeapi.DefaultToken, client.WithBaseURL(httpServer.URL))
+	}
+
+	stateFilePath := filepath.Join(t.TempDir(), "uploads.json")
+	assert.NoError(t, upload.NewFileStateStore(stateFilePath).Save(abortedPath, upload.State{MediaID: "media1", UploadID: "upload1", FileSize: 1000, PartSize: 10}))
+
+	// The file of the aborted upload is uploaded again, the uploader resumes it from the saved state
+	uploader := mocks.NewMockUploader(mockCtrl)
+	uploader.EXPECT().UploadFile(gomock.Any(), abortedPath).Return(nil)
+
+	buildUploader := func(c *client.Client, stateStore upload.StateStore, partSize int64) uploadrun.Uploader {
+		state, ok, err := stateStore.Get(abortedPath)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "media1", state.MediaID)
+
+		return uploader
+	}
+
+	err := uploadrun.NewRunner(path, stateFilePath, 10, verifyrun.TokenPromptMethodInput,
+		uploadrun.WithBuildClient(buildClient),
+		uploadrun.WithBuildUploader(buildUploader),
+	).Run(context.Background())
+	assert.NoError(t, err)
+}
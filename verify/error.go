@@ -0,0 +1,35 @@
+package verify
+
+// ScanError is returned when the local directory can't be scanned
+type ScanError struct {
+	err error
+}
+
+func NewScanError(err error) error {
+	return ScanError{err: err}
+}
+
+func (e ScanError) Error() string {
+	return e.err.Error()
+}
+
+func (e ScanError) Unwrap() error {
+	return e.err
+}
+
+// FetchError is returned when the medias can't be fetched from Gopro Media Library
+type FetchError struct {
+	err error
+}
+
+func NewFetchError(err error) error {
+	return FetchError{err: err}
+}
+
+func (e FetchError) Error() string {
+	return e.err.Error()
+}
+
+func (e FetchError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,261 @@
+package verify
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/dirscan"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Matcher tells which remote medias a local file counts as uploaded as
+type Matcher interface {
+	// Rules are the rules the matcher is made of, in the order they're tried
+	Rules() []Rule
+	// Index prepares the remote medias for the lookups of the local files
+	Index(medias []fetch.Media) MatchIndex
+}
+
+// MatchIndex looks up the remote medias a local file matches
+type MatchIndex interface {
+	// Find returns the positions of the matching medias in the indexed slice and the rule they matched on
+	Find(file dirscan.File) (positions []int, rule Rule, found bool)
+}
+
+// Rule matches the local files and the remote medias that have the same key.
+// A file or a media without a key, e.g. with an unknown capture time, doesn't match anything.
+type Rule struct {
+	// Name is how the rule is given to --match
+	Name string
+	// Criteria are the compared properties, they're shown in the progress messages
+	Criteria []string
+	// Description completes the reasons of the report, e.g. remote file with the same name and size
+	Description string
+	fileKey     func(file dirscan.File) (key string, ok bool)
+	mediaKey    func(media fetch.Media) (key string, ok bool)
+	// index replaces the keys for the rules that can't be looked up by equality, e.g. with a tolerance
+	index func(medias []fetch.Media) MatchIndex
+}
+
+var (
+	// MatchNameSize matches the files with the same name and size, it's the default
+	MatchNameSize = Rule{
+		Name:        "name-size",
+		Criteria:    []string{"fileName", "fileSize"},
+		Description: "the same name and size",
+		fileKey: func(file dirscan.File) (string, bool) {
+			return nameSizeKey(file.Name, file.Size), true
+		},
+		mediaKey: func(media fetch.Media) (string, bool) {
+			return nameSizeKey(media.FileName(), media.FileSize()), true
+		},
+	}
+	// MatchNameSizeNoCase matches the files with the same size and name ignoring the case, e.g. GX010001.MP4 and gx010001.mp4
+	MatchNameSizeNoCase = Rule{
+		Name:        "name-size-nocase",
+		Criteria:    []string{"fileName (case-insensitive)", "fileSize"},
+		Description: "the same name ignoring case and the same size",
+		fileKey: func(file dirscan.File) (string, bool) {
+			return nameSizeKey(strings.ToLower(file.Name), file.Size), true
+		},
+		mediaKey: func(media fetch.Media) (string, bool) {
+			return nameSizeKey(strings.ToLower(media.FileName()), media.FileSize()), true
+		},
+	}
+	// MatchSizeCaptured matches the renamed files by their size and capture time to the second.
+	// The capture time of a local file comes from its metadata, or else from its modification time.
+	MatchSizeCaptured = Rule{
+		Name:        "size-captured",
+		Criteria:    []string{"fileSize", "captureTime"},
+		Description: "the same size and capture time",
+		fileKey: func(file dirscan.File) (string, bool) {
+			if file.CaptureTime().IsZero() {
+				return "", false
+			}
+
+			return strconv.FormatInt(file.Size, 10) + "@" + strconv.FormatInt(file.CaptureTime().Unix(), 10), true
+		},
+		mediaKey: func(media fetch.Media) (string, bool) {
+			if media.CapturedAt().IsZero() {
+				return "", false
+			}
+
+			return strconv.FormatInt(media.FileSize(), 10) + "@" + strconv.FormatInt(media.CapturedAt().Unix(), 10), true
+		},
+	}
+	// MatchName matches the files with the same name whatever their size is
+	MatchName = Rule{
+		Name:        "name",
+		Criteria:    []string{"fileName"},
+		Description: "the same name",
+		fileKey: func(file dirscan.File) (string, bool) {
+			return file.Name, true
+		},
+		mediaKey: func(media fetch.Media) (string, bool) {
+			return media.FileName(), true
+		},
+	}
+)
+
+// MatchRules lists all the built-in rules
+var MatchRules = []Rule{MatchNameSize, MatchNameSizeNoCase, MatchSizeCaptured, MatchName, MatchRenamed}
+
+// MatchOptions tune the built-in rules ParseMatcher picks from
+type MatchOptions struct {
+	// CaptureTolerance is how far apart the capture times of a renamed file and its media may be
+	CaptureTolerance time.Duration
+}
+
+// WithCaptureTolerance sets the tolerance of the renamed rule, DefaultCaptureTolerance by default
+func WithCaptureTolerance(tolerance time.Duration) func(o *MatchOptions) {
+	return func(o *MatchOptions) {
+		o.CaptureTolerance = tolerance
+	}
+}
+
+func nameSizeKey(name string, size int64) string {
+	return name + "/" + strconv.FormatInt(size, 10)
+}
+
+func (r Rule) Rules() []Rule {
+	return []Rule{r}
+}
+
+// Index keeps all the medias of a key, several medias can share one, e.g. a failed upload uploaded again
+func (r Rule) Index(medias []fetch.Media) MatchIndex {
+	if r.index != nil {
+		return r.index(medias)
+	}
+
+	index := ruleIndex{rule: r, positions: make(map[string][]int, len(medias))}
+
+	for i, media := range medias {
+		if key, ok := r.mediaKey(media); ok {
+			index.positions[key] = append(index.positions[key], i)
+		}
+	}
+
+	return index
+}
+
+type ruleIndex struct {
+	rule      Rule
+	positions map[string][]int
+}
+
+func (i ruleIndex) Find(file dirscan.File) (positions []int, rule Rule, found bool) {
+	key, ok := i.rule.fileKey(file)
+	if !ok {
+		return nil, i.rule, false
+	}
+
+	positions, found = i.positions[key]
+
+	return positions, i.rule, found
+}
+
+// ChainMatcher tries its rules in order, a file matches on the first rule that finds a media
+type ChainMatcher struct {
+	rules []Rule
+}
+
+func NewChainMatcher(rules ...Rule) ChainMatcher {
+	return ChainMatcher{rules: rules}
+}
+
+func (c ChainMatcher) Rules() []Rule {
+	return c.rules
+}
+
+func (c ChainMatcher) Index(medias []fetch.Media) MatchIndex {
+	index := chainIndex{}
+
+	for _, rule := range c.rules {
+		index = append(index, rule.Index(medias))
+	}
+
+	return index
+}
+
+type chainIndex []MatchIndex
+
+func (c chainIndex) Find(file dirscan.File) (positions []int, rule Rule, found bool) {
+	for _, index := range c {
+		if positions, rule, found = index.Find(file); found {
+			return positions, rule, true
+		}
+	}
+
+	return nil, Rule{}, false
+}
+
+// ParseMatcher reads the comma-separated rule names of --match, several rules make a chain
+func ParseMatcher(value string, opts ...func(o *MatchOptions)) (matcher Matcher, err error) {
+	options := MatchOptions{CaptureTolerance: DefaultCaptureTolerance}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.CaptureTolerance < 0 {
+		return nil, errors.Errorf("invalid capture tolerance: %s", options.CaptureTolerance)
+	}
+
+	rules := []Rule{}
+
+	for _, name := range strings.Split(value, ",") {
+		rule, err := findRule(strings.TrimSpace(name), options)
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if len(rules) == 1 {
+		return rules[0], nil
+	}
+
+	return NewChainMatcher(rules...), nil
+}
+
+func findRule(name string, options MatchOptions) (rule Rule, err error) {
+	names := make([]string, 0, len(MatchRules))
+
+	for _, rule := range MatchRules {
+		if rule.Name == name {
+			if rule.Name == MatchRenamed.Name {
+				return NewMatchRenamed(options.CaptureTolerance), nil
+			}
+
+			return rule, nil
+		}
+
+		names = append(names, rule.Name)
+	}
+
+	return Rule{}, errors.Errorf("invalid match strategy: %s, expected one of: %s", name, strings.Join(names, ", "))
+}
+
+// describeCriteria tells what the matcher compares, e.g. fileName, fileSize
+func describeCriteria(matcher Matcher) string {
+	criteria := []string{}
+
+	for _, rule := range matcher.Rules() {
+		criteria = append(criteria, strings.Join(rule.Criteria, ", "))
+	}
+
+	return strings.Join(criteria, "; then ")
+}
+
+// describeRules completes the reasons of the unmatched files, e.g. the same name and size
+func describeRules(matcher Matcher) string {
+	descriptions := []string{}
+
+	for _, rule := range matcher.Rules() {
+		descriptions = append(descriptions, rule.Description)
+	}
+
+	return strings.Join(descriptions, " or ")
+}
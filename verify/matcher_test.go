@@ -0,0 +1,164 @@
+package verify_test
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/dirscan"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/legosx/gopro-media-library-verifier/mediameta"
+	"github.com/legosx/gopro-media-library-verifier/verify"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMatcher_Find(t *testing.T) {
+	type want struct {
+		positions []int
+		rule      string
+		found     bool
+	}
+
+	capturedAt := time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)
+
+	medias := []fetch.Media{
+		fetch.NewMedia("GX010001.MP4", 1000, fetch.WithMediaCapturedAt(capturedAt)),
+		fetch.NewMedia("GX010002.MP4", 2000),
+		fetch.NewMedia("GX010002.MP4", 2500),
+		fetch.NewMedia("GX010003.MP4", 3000, fetch.WithMediaCapturedAt(capturedAt.Add(time.Hour)), fetch.WithMediaSourceDuration(63500*time.Millisecond)),
+	}
+
+	tests := []struct {
+		name    string
+		matcher string
+		file    dirscan.File
+		want
+	}{
+		{
+			name:    "happy path, name and size",
+			matcher: "name-size",
+			file:    dirscan.File{Name: "GX010001.MP4", Size: 1000},
+			want:    want{positions: []int{0}, rule: "name-size", found: true},
+		},
+		{
+			name:    "happy path, name and size don't match another case",
+			matcher: "name-size",
+			file:    dirscan.File{Name: "gx010001.mp4", Size: 1000},
+		},
+		{
+			name:    "happy path, case-insensitive name and size",
+			matcher: "name-size-nocase",
+			file:    dirscan.File{Name: "gx010001.mp4", Size: 1000},
+			want:    want{positions: []int{0}, rule: "name-size-nocase", found: true},
+		},
+		{
+			name:    "happy path, size and capture time of a renamed file",
+			matcher: "size-captured",
+			file:    dirscan.File{Name: "ride.mp4", Size: 1000, ModTime: capturedAt.Local()},
+			want:    want{positions: []int{0}, rule: "size-captured", found: true},
+		},
+		{
+			name:    "happy path, unknown capture time doesn't match",
+			matcher: "size-captured",
+			file:    dirscan.File{Name: "GX010001.MP4", Size: 1000},
+		},
+		{
+			name:    "happy path, name only keeps all the medias with the name",
+			matcher: "name",
+			file:    dirscan.File{Name: "GX010002.MP4", Size: 1},
+			want:    want{positions: []int{1, 2}, rule: "name", found: true},
+		},
+		{
+			name:    "happy path, renamed file with the same size captured within the tolerance",
+			matcher: "renamed",
+			file:    dirscan.File{Name: "Hawaii-day2-surf.mp4", Size: 1000, Metadata: mediameta.Metadata{CapturedAt: capturedAt.Add(time.Second)}},
+			want:    want{positions: []int{0}, rule: "renamed", found: true},
+		},
+		{
+			name:    "happy path, renamed file with another size and the same duration",
+			matcher: "renamed",
+			file: dirscan.File{
+				Name:     "Hawaii-day3-dive.mp4",
+				Size:     2999,
+				Metadata: mediameta.Metadata{CapturedAt: capturedAt.Add(time.Hour - 2*time.Second), Duration: 63 * time.Second},
+			},
+			want: want{positions: []int{3}, rule: "renamed", found: true},
+		},
+		{
+			name:    "happy path, renamed file captured outside the tolerance doesn't match",
+			matcher: "renamed",
+			file:    dirscan.File{Name: "Hawaii-day2-surf.mp4", Size: 1000, Metadata: mediameta.Metadata{CapturedAt: capturedAt.Add(3 * time.Second)}},
+		},
+		{
+			name:    "happy path, renamed file with another size and duration doesn't match",
+			matcher: "renamed",
+			file:    dirscan.File{Name: "Hawaii-day2-surf.mp4", Size: 999, Metadata: mediameta.Metadata{CapturedAt: capturedAt}},
+		},
+		{
+			name:    "happy path, file with the same name isn't renamed",
+			matcher: "renamed",
+			file:    dirscan.File{Name: "gx010001.mp4", Size: 1000, Metadata: mediameta.Metadata{CapturedAt: capturedAt}},
+		},
+		{
+			name:    "happy path, chain reports the rule that matched",
+			matcher: "name-size, size-captured",
+			file:    dirscan.File{Name: "ride.mp4", Size: 1000, ModTime: capturedAt},
+			want:    want{positions: []int{0}, rule: "size-captured", found: true},
+		},
+		{
+			name:    "happy path, chain stops at the first rule that matches",
+			matcher: "name-size,name",
+			file:    dirscan.File{Name: "GX010002.MP4", Size: 2000},
+			want:    want{positions: []int{1}, rule: "name-size", found: true},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			matcher, err := verify.ParseMatcher(tt.matcher)
+			assert.NoError(t, err)
+
+			positions, rule, found := matcher.Index(medias).Find(tt.file)
+			assert.Equal(t, tt.want.found, found)
+
+			if tt.want.found {
+				assert.Equal(t, tt.want.positions, positions)
+				assert.Equal(t, tt.want.rule, rule.Name)
+			}
+		})
+	}
+}
+
+func TestParseMatcher(t *testing.T) {
+	t.Parallel()
+
+	matcher, err := verify.ParseMatcher("name-size")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"name-size"}, ruleNames(matcher))
+
+	matcher, err = verify.ParseMatcher("name-size,size-captured")
+	assert.NoError(t, err)
+	assert.IsType(t, verify.ChainMatcher{}, matcher)
+	assert.Equal(t, []string{"name-size", "size-captured"}, ruleNames(matcher))
+
+	matcher, err = verify.ParseMatcher("renamed", verify.WithCaptureTolerance(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"captureTime (within 1m0s)", "fileSize or duration"}, matcher.Rules()[0].Criteria)
+
+	_, err = verify.ParseMatcher("checksum")
+	assert.EqualError(t, err, "invalid match strategy: checksum, expected one of: name-size, name-size-nocase, size-captured, name, renamed")
+
+	_, err = verify.ParseMatcher("renamed", verify.WithCaptureTolerance(-time.Second))
+	assert.EqualError(t, err, "invalid capture tolerance: -1s")
+}
+
+func ruleNames(matcher verify.Matcher) (names []string) {
+	for _, rule := range matcher.Rules() {
+		names = append(names, rule.Name)
+	}
+
+	return names
+}
@@ -10,6 +10,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	fetch "github.com/legosx/gopro-media-library-verifier/fetch"
@@ -40,16 +41,16 @@ func (m *MockFetcher) EXPECT() *MockFetcherMockRecorder {
 }
 
 // GetMedias mocks base method.
-func (m *MockFetcher) GetMedias() ([]fetch.Media, error) {
+func (m *MockFetcher) GetMedias(arg0 context.Context) ([]fetch.Media, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMedias")
+	ret := m.ctrl.Call(m, "GetMedias", arg0)
 	ret0, _ := ret[0].([]fetch.Media)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetMedias indicates an expected call of GetMedias.
-func (mr *MockFetcherMockRecorder) GetMedias() *gomock.Call {
+func (mr *MockFetcherMockRecorder) GetMedias(arg0 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMedias", reflect.TypeOf((*MockFetcher)(nil).GetMedias))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMedias", reflect.TypeOf((*MockFetcher)(nil).GetMedias), arg0)
 }
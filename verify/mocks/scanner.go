@@ -10,6 +10,7 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	dirscan "github.com/legosx/gopro-media-library-verifier/dirscan"
@@ -40,16 +41,16 @@ func (m *MockScanner) EXPECT() *MockScannerMockRecorder {
 }
 
 // GetFileList mocks base method.
-func (m *MockScanner) GetFileList(arg0 string) ([]dirscan.File, error) {
+func (m *MockScanner) GetFileList(arg0 context.Context, arg1 string) ([]dirscan.File, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetFileList", arg0)
+	ret := m.ctrl.Call(m, "GetFileList", arg0, arg1)
 	ret0, _ := ret[0].([]dirscan.File)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetFileList indicates an expected call of GetFileList.
-func (mr *MockScannerMockRecorder) GetFileList(arg0 any) *gomock.Call {
+func (mr *MockScannerMockRecorder) GetFileList(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileList", reflect.TypeOf((*MockScanner)(nil).GetFileList), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileList", reflect.TypeOf((*MockScanner)(nil).GetFileList), arg0, arg1)
 }
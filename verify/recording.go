@@ -0,0 +1,86 @@
+package verify
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/dirscan"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"strings"
+)
+
+// MatchChapters is the rule the chapters of a recording match on when the cloud merged them into one file
+var MatchChapters = Rule{
+	Name:        "chapters",
+	Criteria:    []string{"recordingSize", "firstChapterName"},
+	Description: "the merged chapters of the recording",
+}
+
+// recordingIndex looks up the remote files the local chapters were merged into
+type recordingIndex struct {
+	// recordings are keyed by the paths of their chapters
+	recordings map[string]dirscan.Recording
+	medias     []fetch.Media
+	bySize     map[int64][]int
+	byName     map[string][]int
+}
+
+func newRecordingIndex(localFiles []dirscan.File, remoteMedias []fetch.Media) recordingIndex {
+	index := recordingIndex{
+		recordings: map[string]dirscan.Recording{},
+		medias:     remoteMedias,
+		bySize:     map[int64][]int{},
+		byName:     map[string][]int{},
+	}
+
+	recordings := dirscan.GroupChapters(localFiles)
+	if len(recordings) == 0 {
+		return index
+	}
+
+	// Only the remote files that can be a merged recording are indexed
+	sizes, names := map[int64]bool{}, map[string]bool{}
+
+	for _, recording := range recordings {
+		sizes[recording.Size] = true
+		names[strings.ToUpper(recording.Name)] = true
+
+		for _, chapter := range recording.Chapters {
+			index.recordings[chapter.Path] = recording
+		}
+	}
+
+	for i, media := range remoteMedias {
+		if sizes[media.FileSize()] {
+			index.bySize[media.FileSize()] = append(index.bySize[media.FileSize()], i)
+		}
+
+		if name := strings.ToUpper(media.FileName()); names[name] {
+			index.byName[name] = append(index.byName[name], i)
+		}
+	}
+
+	return index
+}
+
+// find matches the recording of the chapter against a remote file of the size of all the chapters,
+// or else named after the first chapter and sized between the first chapter and the whole recording.
+// A remote file that is just the first chapter on its own doesn't count, the next chapters were uploaded separately then,
+// neither does a smaller one, e.g. a truncated upload, which is left to the conflict detection.
+func (i recordingIndex) find(localFile dirscan.File) (positions []int, found bool) {
+	recording, ok := i.recordings[localFile.Path]
+	if !ok {
+		return nil, false
+	}
+
+	if positions, found = i.bySize[recording.Size]; found {
+		return positions, true
+	}
+
+	firstChapter := recording.Chapters[0]
+
+	for _, position := range i.byName[strings.ToUpper(recording.Name)] {
+		if size := i.medias[position].FileSize(); size > firstChapter.Size && size <= recording.Size {
+			positions = append(positions, position)
+		}
+	}
+
+	return positions, len(positions) > 0
+}
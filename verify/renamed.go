@@ -0,0 +1,131 @@
+package verify
+
+import (
+	"fmt"
+	"github.com/legosx/gopro-media-library-verifier/dirscan"
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultCaptureTolerance covers the rounding of the capture times between the file metadata and Gopro Media Library
+const DefaultCaptureTolerance = 2 * time.Second
+
+// durationTolerance covers the rounding of the durations, a renamed copy has the same length to the frame
+const durationTolerance = time.Second
+
+// MatchRenamed matches the renamed files by their capture time within DefaultCaptureTolerance, and their size or duration
+var MatchRenamed = NewMatchRenamed(DefaultCaptureTolerance)
+
+// NewMatchRenamed matches the files people renamed, e.g. GX010001.MP4 saved as Hawaii-day2-surf.mp4.
+// The capture times must be within the tolerance, and the sizes or, for the videos, the durations must be the same.
+// A media with the same name is left to the conflict detection, the file wasn't renamed.
+func NewMatchRenamed(tolerance time.Duration) (rule Rule) {
+	rule = Rule{
+		Name:        "renamed",
+		Criteria:    []string{fmt.Sprintf("captureTime (within %s)", tolerance), "fileSize or duration"},
+		Description: fmt.Sprintf("a capture time within %s and the same size or duration", tolerance),
+	}
+
+	rule.index = func(medias []fetch.Media) MatchIndex {
+		return newRenamedIndex(rule, tolerance, medias)
+	}
+
+	return rule
+}
+
+// renamedIndex buckets the medias by their capture time, the medias within the tolerance are in the adjacent buckets
+type renamedIndex struct {
+	rule      Rule
+	tolerance time.Duration
+	medias    []fetch.Media
+	// width of the buckets, it's at least a second so that a zero tolerance still compares the whole seconds
+	width   int64
+	buckets map[int64][]int
+}
+
+func newRenamedIndex(rule Rule, tolerance time.Duration, medias []fetch.Media) renamedIndex {
+	index := renamedIndex{
+		rule:      rule,
+		tolerance: tolerance,
+		medias:    medias,
+		width:     int64(tolerance),
+		buckets:   map[int64][]int{},
+	}
+
+	if index.width < int64(time.Second) {
+		index.width = int64(time.Second)
+	}
+
+	for i, media := range medias {
+		if media.CapturedAt().IsZero() {
+			continue
+		}
+
+		bucket := index.bucket(media.CapturedAt())
+		index.buckets[bucket] = append(index.buckets[bucket], i)
+	}
+
+	return index
+}
+
+func (i renamedIndex) bucket(capturedAt time.Time) int64 {
+	return capturedAt.UnixNano() / i.width
+}
+
+// Find returns the matching medias, the closest capture time first
+func (i renamedIndex) Find(file dirscan.File) (positions []int, rule Rule, found bool) {
+	capturedAt := file.CaptureTime()
+	if capturedAt.IsZero() {
+		return nil, i.rule, false
+	}
+
+	offsets := map[int]time.Duration{}
+	bucket := i.bucket(capturedAt)
+
+	for _, candidates := range [][]int{i.buckets[bucket-1], i.buckets[bucket], i.buckets[bucket+1]} {
+		for _, position := range candidates {
+			media := i.medias[position]
+
+			offset := absDuration(media.CapturedAt().Sub(capturedAt))
+			if offset > i.tolerance || strings.EqualFold(media.FileName(), file.Name) || !sameSizeOrDuration(file, media) {
+				continue
+			}
+
+			offsets[position] = offset
+			positions = append(positions, position)
+		}
+	}
+
+	sort.Slice(positions, func(a, b int) bool {
+		if offsets[positions[a]] != offsets[positions[b]] {
+			return offsets[positions[a]] < offsets[positions[b]]
+		}
+
+		return positions[a] < positions[b]
+	})
+
+	return positions, i.rule, len(positions) > 0
+}
+
+// sameSizeOrDuration compares the durations only when both are known, the photos have none
+func sameSizeOrDuration(file dirscan.File, media fetch.Media) bool {
+	if file.Size == media.FileSize() {
+		return true
+	}
+
+	if file.Duration == 0 || media.SourceDuration() == 0 {
+		return false
+	}
+
+	return absDuration(file.Duration-media.SourceDuration()) <= durationTolerance
+}
+
+func absDuration(duration time.Duration) time.Duration {
+	if duration < 0 {
+		return -duration
+	}
+
+	return duration
+}
@@ -0,0 +1,188 @@
+package verify
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/pkg/errors"
+	"time"
+)
+
+// Direction tells which side of the sync is checked for files missing on the other side
+type Direction string
+
+const (
+	// DirectionLocal looks for local files that are not in Gopro Media Library
+	DirectionLocal Direction = "local"
+	// DirectionRemote looks for Gopro Media Library files that are not in the local directory
+	DirectionRemote Direction = "remote"
+	// DirectionBoth looks for differences on both sides
+	DirectionBoth Direction = "both"
+)
+
+var DirectionsAvailable = []Direction{
+	DirectionLocal,
+	DirectionRemote,
+	DirectionBoth,
+}
+
+func (d Direction) Validate() error {
+	for _, direction := range DirectionsAvailable {
+		if d == direction {
+			return nil
+		}
+	}
+
+	return errors.Errorf("invalid direction: %s", d)
+}
+
+// IncludesLocal tells whether local files missing in Gopro Media Library are looked for
+func (d Direction) IncludesLocal() bool {
+	return d == DirectionLocal || d == DirectionBoth
+}
+
+// IncludesRemote tells whether Gopro Media Library files missing locally are looked for
+func (d Direction) IncludesRemote() bool {
+	return d == DirectionRemote || d == DirectionBoth
+}
+
+// Status is the outcome of matching a single file
+type Status string
+
+const (
+	// StatusUploaded means the local file has a counterpart in Gopro Media Library that is safe to rely on
+	StatusUploaded Status = "uploaded"
+	// StatusUploadedRenamed means the local file is safely in Gopro Media Library under another name
+	StatusUploadedRenamed Status = "uploaded_renamed"
+	// StatusUploadedProcessing means the counterpart in Gopro Media Library is still being processed
+	StatusUploadedProcessing Status = "uploaded_processing"
+	// StatusUploadedFailed means the processing of the counterpart in Gopro Media Library failed
+	StatusUploadedFailed Status = "uploaded_failed"
+	// StatusMissing means the local file is not in Gopro Media Library
+	StatusMissing Status = "missing"
+	// StatusConflict means Gopro Media Library has a file with the same name but another size,
+	// e.g. a truncated upload or a re-encoded copy
+	StatusConflict Status = "conflict"
+	// StatusRemoteOnly means the Gopro Media Library file is not in the local directory
+	StatusRemoteOnly Status = "remote_only"
+)
+
+// Entry is the verification result of a single file.
+// LocalPath is empty for remote only entries, RemoteMedia is nil for missing ones.
+type Entry struct {
+	LocalPath   string
+	Size        int64
+	Status      Status
+	RemoteMedia *fetch.Media
+	Reason      string
+	// MatchedBy is the name of the rule the local file and the remote media matched on
+	MatchedBy string
+}
+
+// Totals are the run level numbers of a verification
+type Totals struct {
+	FilesScanned  int
+	RemoteFetched int
+	FilesMissing  int
+	BytesMissing  int64
+	RemoteOnly    int
+	// FilesProcessing and FilesFailed count the uploaded files that are not safe yet
+	FilesProcessing int
+	FilesFailed     int
+	// FilesConflict counts the local files whose name exists remotely with another size
+	FilesConflict int
+	// FilesRenamed counts the local files uploaded under another name
+	FilesRenamed int
+	// MatchedBy counts the local files that found their counterpart by the name of the rule
+	MatchedBy map[string]int
+	Duration  time.Duration
+}
+
+// Report is the result of comparing the local directory with Gopro Media Library.
+// Only the side(s) requested by the direction have entries.
+type Report struct {
+	Path      string
+	Direction Direction
+	Entries   []Entry
+	Totals    Totals
+	// Warnings tell why the report might be inaccurate, e.g. the remote listing was incomplete
+	Warnings []string
+	// RemoteSnapshot is set when the remote medias come from a saved listing instead of Gopro Media Library itself
+	RemoteSnapshot *RemoteSnapshot
+}
+
+// RemoteSnapshot tells how old the saved listing of Gopro Media Library is
+type RemoteSnapshot struct {
+	TakenAt time.Time
+	// Age is measured from the start of the verification
+	Age time.Duration
+}
+
+func (r *Report) addEntry(entry Entry) {
+	r.Entries = append(r.Entries, entry)
+
+	if entry.MatchedBy != "" {
+		if r.Totals.MatchedBy == nil {
+			r.Totals.MatchedBy = map[string]int{}
+		}
+
+		r.Totals.MatchedBy[entry.MatchedBy]++
+	}
+
+	switch entry.Status {
+	case StatusMissing:
+		r.Totals.FilesMissing++
+		r.Totals.BytesMissing += entry.Size
+	case StatusRemoteOnly:
+		r.Totals.RemoteOnly++
+	case StatusUploadedProcessing:
+		r.Totals.FilesProcessing++
+	case StatusUploadedFailed:
+		r.Totals.FilesFailed++
+	case StatusConflict:
+		r.Totals.FilesConflict++
+	case StatusUploadedRenamed:
+		r.Totals.FilesRenamed++
+	}
+}
+
+func (r Report) EntriesWithStatus(status Status) (entries []Entry) {
+	entries = []Entry{}
+
+	for _, entry := range r.Entries {
+		if entry.Status == status {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// MissingFilePaths returns the paths of the local files that are not in Gopro Media Library
+func (r Report) MissingFilePaths() (filePaths []string) {
+	filePaths = []string{}
+
+	for _, entry := range r.EntriesWithStatus(StatusMissing) {
+		filePaths = append(filePaths, entry.LocalPath)
+	}
+
+	return filePaths
+}
+
+// SizeDelta is how many bytes the remote file of a conflict has more than the local one, negative when it has less
+func (e Entry) SizeDelta() int64 {
+	if e.RemoteMedia == nil {
+		return 0
+	}
+
+	return e.RemoteMedia.FileSize() - e.Size
+}
+
+// RemoteOnlyMedias returns the medias from Gopro Media Library that are not in the local directory
+func (r Report) RemoteOnlyMedias() (medias []fetch.Media) {
+	medias = []fetch.Media{}
+
+	for _, entry := range r.EntriesWithStatus(StatusRemoteOnly) {
+		medias = append(medias, *entry.RemoteMedia)
+	}
+
+	return medias
+}
@@ -1,71 +1,315 @@
 package verify
 
 import (
+	"context"
 	"fmt"
 	"github.com/legosx/gopro-media-library-verifier/dirscan"
 	"github.com/legosx/gopro-media-library-verifier/fetch"
 	"github.com/pkg/errors"
+	"io"
+	"os"
+	"strings"
+	"time"
 )
 
 type Fetcher interface {
-	GetMedias() (medias []fetch.Media, err error)
+	GetMedias(ctx context.Context) (medias []fetch.Media, err error)
+}
+
+// SnapshotSource is implemented by the fetchers serving a saved listing of Gopro Media Library, e.g. to verify offline
+type SnapshotSource interface {
+	SnapshotTakenAt() time.Time
 }
 
 type Scanner interface {
-	GetFileList(dirPath string) (list []dirscan.File, err error)
+	GetFileList(ctx context.Context, dirPath string) (list []dirscan.File, err error)
 }
 
 type Verifier struct {
-	fetcher Fetcher
-	scanner Scanner
+	fetcher   Fetcher
+	scanner   Scanner
+	now       func() time.Time
+	logWriter io.Writer
+	strict    bool
+	// safeCategories are the processing categories a remote media must be in to count as uploaded
+	safeCategories []fetch.ProcessingCategory
+	matcher        Matcher
+}
+
+func NewVerifier(mediaFetcher Fetcher, scanner Scanner, opts ...func(verifier *Verifier)) Verifier {
+	v := Verifier{
+		fetcher:        mediaFetcher,
+		scanner:        scanner,
+		now:            time.Now,
+		logWriter:      os.Stdout,
+		safeCategories: []fetch.ProcessingCategory{fetch.ProcessingReady},
+		matcher:        MatchNameSize,
+	}
+
+	for _, opt := range opts {
+		opt(&v)
+	}
+
+	return v
+}
+
+// WithNow sets the clock used to measure the duration of a verification
+func WithNow(now func() time.Time) func(v *Verifier) {
+	return func(v *Verifier) {
+		v.now = now
+	}
+}
+
+// WithLogWriter sets where the progress messages are written to
+func WithLogWriter(logWriter io.Writer) func(v *Verifier) {
+	return func(v *Verifier) {
+		v.logWriter = logWriter
+	}
+}
+
+// WithStrict makes the verification fail when the remote listing is incomplete.
+// Otherwise, the incomplete listing is reported as a warning, and the files it lacks show up as missing.
+func WithStrict(strict bool) func(v *Verifier) {
+	return func(v *Verifier) {
+		v.strict = strict
+	}
 }
 
-func NewVerifier(mediaFetcher Fetcher, scanner Scanner) Verifier {
-	return Verifier{fetcher: mediaFetcher, scanner: scanner}
+// WithSafeProcessingCategories sets the processing categories that count as safely uploaded.
+// By default only the ready medias do, the others are reported separately.
+func WithSafeProcessingCategories(categories ...fetch.ProcessingCategory) func(v *Verifier) {
+	return func(v *Verifier) {
+		v.safeCategories = categories
+	}
 }
 
-func (v Verifier) IdentifyMissingFiles(path string) (filePaths []string, err error) {
-	fmt.Printf("\nIdentifying files that are not yet uploaded to cloud from\n%s\nbased on: fileName, fileSize\n", path)
+// WithMatcher sets how the local files and the remote medias are matched, by name and size by default
+func WithMatcher(matcher Matcher) func(v *Verifier) {
+	return func(v *Verifier) {
+		v.matcher = matcher
+	}
+}
 
-	localFiles, err := v.scanner.GetFileList(path)
+func (v Verifier) IdentifyMissingFiles(ctx context.Context, path string) (filePaths []string, err error) {
+	report, err := v.Verify(ctx, path, DirectionLocal)
 	if err != nil {
-		return []string{}, errors.Wrap(err, "error getting local files")
+		return []string{}, err
 	}
 
-	remoteFiles, err := v.getRemoteFiles()
+	// A conflict may be a re-encoded copy in the cloud, uploading it again would only add a duplicate
+	return report.MissingFilePaths(), nil
+}
+
+// IdentifyRemoteOnlyMedias returns the medias from Gopro Media Library that have no counterpart in the local directory
+func (v Verifier) IdentifyRemoteOnlyMedias(ctx context.Context, path string) (medias []fetch.Media, err error) {
+	report, err := v.Verify(ctx, path, DirectionRemote)
 	if err != nil {
-		return []string{}, errors.Wrap(err, "error getting remote files")
+		return []fetch.Media{}, err
 	}
 
-	return v.getFilePathsOfMissingFiles(localFiles, remoteFiles), nil
+	return report.RemoteOnlyMedias(), nil
 }
 
-func (v Verifier) getRemoteFiles() (remoteFiles []dirscan.File, err error) {
-	remoteMedias, err := v.fetcher.GetMedias()
+// Verify scans the local directory and fetches Gopro Media Library once,
+// and then reports every file of the side(s) requested by the direction
+func (v Verifier) Verify(ctx context.Context, path string, direction Direction) (report Report, err error) {
+	startedAt := v.now()
+
+	report = Report{
+		Path:      path,
+		Direction: direction,
+		Entries:   []Entry{},
+	}
+
+	if err = direction.Validate(); err != nil {
+		return report, err
+	}
+
+	if direction.IncludesLocal() {
+		fmt.Fprintf(v.logWriter, "\nIdentifying files that are not yet uploaded to cloud from\n%s\nbased on: %s\n", path, describeCriteria(v.matcher))
+	}
+
+	if direction.IncludesRemote() {
+		fmt.Fprintf(v.logWriter, "\nIdentifying files that are not yet downloaded from cloud to\n%s\nbased on: %s\n", path, describeCriteria(v.matcher))
+	}
+
+	localFiles, err := v.scanner.GetFileList(ctx, path)
+	if err != nil {
+		return report, NewScanError(errors.Wrap(err, "error getting local files"))
+	}
+
+	report.Totals.FilesScanned = len(localFiles)
+
+	remoteMedias, err := v.getRemoteMedias(ctx)
+
+	incompleteListingErr := fetch.IncompleteListingError{}
+	if !v.strict && errors.As(err, &incompleteListingErr) {
+		warning := fmt.Sprintf("%s, some files may be reported only because of it", incompleteListingErr)
+		fmt.Fprintf(v.logWriter, "\nWarning: %s\n", warning)
+		report.Warnings = append(report.Warnings, warning)
+		err = nil
+	}
+
 	if err != nil {
-		return []dirscan.File{}, errors.Wrap(err, "error getting remote medias")
+		return report, NewFetchError(errors.Wrap(err, "error getting remote files"))
+	}
+
+	report.Totals.RemoteFetched = len(remoteMedias)
+
+	if snapshotSource, ok := v.fetcher.(SnapshotSource); ok {
+		report.RemoteSnapshot = &RemoteSnapshot{
+			TakenAt: snapshotSource.SnapshotTakenAt(),
+			Age:     startedAt.Sub(snapshotSource.SnapshotTakenAt()),
+		}
+
+		warning := fmt.Sprintf(
+			"verified offline against a snapshot of Gopro Media Library taken %s ago (%s), files uploaded since then are reported as missing",
+			report.RemoteSnapshot.Age.Round(time.Minute),
+			report.RemoteSnapshot.TakenAt.Local().Format(time.DateTime),
+		)
+		fmt.Fprintf(v.logWriter, "\nWarning: %s\n", warning)
+		report.Warnings = append(report.Warnings, warning)
+	}
+
+	index := v.matcher.Index(remoteMedias)
+	recordings := newRecordingIndex(localFiles, remoteMedias)
+
+	if direction.IncludesLocal() {
+		for _, entry := range v.getLocalEntries(localFiles, remoteMedias, index, recordings) {
+			report.addEntry(entry)
+		}
+	}
+
+	if direction.IncludesRemote() {
+		for _, entry := range v.getRemoteOnlyEntries(localFiles, remoteMedias, index, recordings) {
+			report.addEntry(entry)
+		}
+	}
+
+	report.Totals.Duration = v.now().Sub(startedAt)
+
+	return report, nil
+}
+
+// getRemoteMedias keeps the medias fetched along with an incomplete listing error, so they can still be used
+func (v Verifier) getRemoteMedias(ctx context.Context) (remoteMedias []fetch.Media, err error) {
+	if remoteMedias, err = v.fetcher.GetMedias(ctx); err != nil {
+		return remoteMedias, errors.Wrap(err, "error getting remote medias")
 	}
 
-	return v.convertMediasToFiles(remoteMedias), nil
+	return remoteMedias, nil
 }
 
-func (v Verifier) getFilePathsOfMissingFiles(localFiles, remoteFiles []dirscan.File) (filePaths []string) {
-	filePaths = []string{}
+func (v Verifier) getLocalEntries(localFiles []dirscan.File, remoteMedias []fetch.Media, index MatchIndex, recordings recordingIndex) (entries []Entry) {
+	entries = []Entry{}
+	nameIndex := MatchName.Index(remoteMedias)
 
 	for _, localFile := range localFiles {
-		if v.fileExists(localFile, remoteFiles) {
+		positions, rule, found := index.Find(localFile)
+		if !found {
+			// The cloud may have merged the chapters of a long recording into one file
+			if positions, found = recordings.find(localFile); found {
+				entries = append(entries, v.getUploadedEntry(localFile, v.pickMedia(positions, remoteMedias), MatchChapters))
+
+				continue
+			}
+
+			if remoteMedia, found := v.findConflict(localFile, nameIndex, remoteMedias); found {
+				entries = append(entries, v.getConflictEntry(localFile, remoteMedia))
+
+				continue
+			}
+
+			entries = append(entries, Entry{
+				LocalPath: localFile.Path,
+				Size:      localFile.Size,
+				Status:    StatusMissing,
+				Reason:    "no remote file with " + describeRules(v.matcher),
+			})
+
 			continue
 		}
 
-		filePaths = append(filePaths, localFile.Path)
+		entries = append(entries, v.getUploadedEntry(localFile, v.pickMedia(positions, remoteMedias), rule))
+	}
+
+	return entries
+}
+
+// findConflict looks for a remote media with the name of the local file and another size.
+// A media of the same size isn't a conflict, the matcher just didn't count it, e.g. because of its capture time.
+func (v Verifier) findConflict(localFile dirscan.File, nameIndex MatchIndex, remoteMedias []fetch.Media) (remoteMedia fetch.Media, found bool) {
+	positions, _, _ := nameIndex.Find(localFile)
+
+	for _, position := range positions {
+		if remoteMedias[position].FileSize() != localFile.Size {
+			return remoteMedias[position], true
+		}
+	}
+
+	return fetch.Media{}, false
+}
+
+// getConflictEntry reports the local file whose name exists remotely with another size,
+// so a partial upload can be told apart from a new file that happens to have the same name
+func (v Verifier) getConflictEntry(localFile dirscan.File, remoteMedia fetch.Media) (entry Entry) {
+	entry = Entry{
+		LocalPath:   localFile.Path,
+		Size:        localFile.Size,
+		Status:      StatusConflict,
+		RemoteMedia: &remoteMedia,
+	}
+
+	entry.Reason = fmt.Sprintf(
+		"remote file with the same name has another size: local %d bytes, remote %d bytes (%+d bytes)",
+		localFile.Size,
+		remoteMedia.FileSize(),
+		entry.SizeDelta(),
+	)
+
+	return entry
+}
+
+// getUploadedEntry reports the local file as uploaded only when its counterpart is in a safe processing category.
+// A counterpart under another name, e.g. found by its capture time, is reported along with its name.
+func (v Verifier) getUploadedEntry(localFile dirscan.File, remoteMedia fetch.Media, rule Rule) (entry Entry) {
+	entry = Entry{
+		LocalPath:   localFile.Path,
+		Size:        localFile.Size,
+		Status:      StatusUploaded,
+		RemoteMedia: &remoteMedia,
+		Reason:      "remote file with " + rule.Description,
+		MatchedBy:   rule.Name,
+	}
+
+	// The merged chapters are named after the first one, and a name in another case is the same name
+	if rule.Name != MatchChapters.Name && !strings.EqualFold(localFile.Name, remoteMedia.FileName()) {
+		entry.Status = StatusUploadedRenamed
+		entry.Reason = fmt.Sprintf("%s, uploaded under a different name: %s", entry.Reason, remoteMedia.FileName())
+	}
+
+	category := fetch.GetProcessingCategory(remoteMedia.ProcessingState())
+	safe := v.isSafe(category)
+
+	switch category {
+	case fetch.ProcessingInProgress:
+		entry.Reason = fmt.Sprintf("%s is still processing (%s)", entry.Reason, remoteMedia.ProcessingState())
+		if !safe {
+			entry.Status = StatusUploadedProcessing
+		}
+	case fetch.ProcessingFailed:
+		entry.Reason = entry.Reason + " failed processing"
+		if !safe {
+			entry.Status = StatusUploadedFailed
+		}
 	}
 
-	return filePaths
+	return entry
 }
 
-func (v Verifier) fileExists(lookupFile dirscan.File, files []dirscan.File) (exists bool) {
-	for _, file := range files {
-		if lookupFile.Name == file.Name && lookupFile.Size == file.Size {
+func (v Verifier) isSafe(category fetch.ProcessingCategory) bool {
+	for _, safeCategory := range v.safeCategories {
+		if category == safeCategory {
 			return true
 		}
 	}
@@ -73,15 +317,47 @@ func (v Verifier) fileExists(lookupFile dirscan.File, files []dirscan.File) (exi
 	return false
 }
 
-func (v Verifier) convertMediasToFiles(medias []fetch.Media) (files []dirscan.File) {
-	files = []dirscan.File{}
+// getRemoteOnlyEntries reports the medias no local file matches.
+// With a chain, a local file only claims the medias of the first rule it matches on.
+func (v Verifier) getRemoteOnlyEntries(localFiles []dirscan.File, remoteMedias []fetch.Media, index MatchIndex, recordings recordingIndex) (entries []Entry) {
+	entries = []Entry{}
+	matched := make([]bool, len(remoteMedias))
+
+	for _, localFile := range localFiles {
+		positions, _, found := index.Find(localFile)
+		if !found {
+			positions, _ = recordings.find(localFile)
+		}
+
+		for _, position := range positions {
+			matched[position] = true
+		}
+	}
+
+	for i, remoteMedia := range remoteMedias {
+		if matched[i] {
+			continue
+		}
 
-	for _, m := range medias {
-		files = append(files, dirscan.File{
-			Name: m.FileName(),
-			Size: m.FileSize(),
+		remoteMedia := remoteMedia
+		entries = append(entries, Entry{
+			Size:        remoteMedia.FileSize(),
+			Status:      StatusRemoteOnly,
+			RemoteMedia: &remoteMedia,
+			Reason:      "no local file with " + describeRules(v.matcher),
 		})
 	}
 
-	return files
+	return entries
+}
+
+// pickMedia prefers the ready medias, e.g. when a failed upload was uploaded again
+func (v Verifier) pickMedia(positions []int, remoteMedias []fetch.Media) (media fetch.Media) {
+	for _, position := range positions {
+		if fetch.GetProcessingCategory(remoteMedias[position].ProcessingState()) == fetch.ProcessingReady {
+			return remoteMedias[position]
+		}
+	}
+
+	return remoteMedias[positions[0]]
 }
@@ -1,14 +1,21 @@
 package verify_test
 
 import (
+	"bytes"
+	"context"
+	"fmt"
 	"github.com/legosx/gopro-media-library-verifier/dirscan"
 	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/legosx/gopro-media-library-verifier/indexcache"
+	"github.com/legosx/gopro-media-library-verifier/mediameta"
 	"github.com/legosx/gopro-media-library-verifier/verify"
 	"github.com/legosx/gopro-media-library-verifier/verify/mocks"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
+	"io"
 	"testing"
+	"time"
 )
 
 //go:generate mockgen -destination=./mocks/fetcher.go -package=mocks github.com/legosx/gopro-media-library-verifier/verify Fetcher
@@ -42,7 +49,7 @@ func TestVerifier_IdentifyMissingFiles(t *testing.T) {
 					mock := mocks.NewMockFetcher(mockCtrl)
 					mock.
 						EXPECT().
-						GetMedias().
+						GetMedias(gomock.Any()).
 						Return(
 							[]fetch.Media{
 								fetch.NewMedia("file1.mp4", 1000),
@@ -57,7 +64,7 @@ func TestVerifier_IdentifyMissingFiles(t *testing.T) {
 					mock := mocks.NewMockScanner(mockCtrl)
 					mock.
 						EXPECT().
-						GetFileList("/dir").
+						GetFileList(gomock.Any(), "/dir").
 						Return(
 							[]dirscan.File{
 								{Name: "file1.mp4", Path: "/dir/file1.mp4", Size: 1000},
@@ -94,7 +101,7 @@ func TestVerifier_IdentifyMissingFiles(t *testing.T) {
 					mock := mocks.NewMockScanner(mockCtrl)
 					mock.
 						EXPECT().
-						GetFileList("/dir").
+						GetFileList(gomock.Any(), "/dir").
 						Return([]dirscan.File{}, assert.AnError)
 
 					return mock
@@ -115,7 +122,7 @@ func TestVerifier_IdentifyMissingFiles(t *testing.T) {
 					mock := mocks.NewMockFetcher(mockCtrl)
 					mock.
 						EXPECT().
-						GetMedias().
+						GetMedias(gomock.Any()).
 						Return([]fetch.Media{}, assert.AnError)
 
 					return mock
@@ -124,7 +131,7 @@ func TestVerifier_IdentifyMissingFiles(t *testing.T) {
 					mock := mocks.NewMockScanner(mockCtrl)
 					mock.
 						EXPECT().
-						GetFileList("/dir").
+						GetFileList(gomock.Any(), "/dir").
 						Return(
 							[]dirscan.File{
 								{Name: "file1.mp4", Path: "/dir/file1.mp4", Size: 1000},
@@ -166,7 +173,7 @@ func TestVerifier_IdentifyMissingFiles(t *testing.T) {
 				tt.fields.fetcher(mockCtrl),
 				tt.fields.scanner(mockCtrl),
 			)
-			got, err := verifier.IdentifyMissingFiles(tt.args.path)
+			got, err := verifier.IdentifyMissingFiles(context.Background(), tt.args.path)
 
 			assert.Equal(t, tt.want.filePaths, got)
 			if tt.want.err == nil {
@@ -177,3 +184,822 @@ func TestVerifier_IdentifyMissingFiles(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifier_IdentifyRemoteOnlyMedias(t *testing.T) {
+	type fields struct {
+		fetcher func(mockCtrl *gomock.Controller) verify.Fetcher
+		scanner func(mockCtrl *gomock.Controller) verify.Scanner
+	}
+
+	type args struct {
+		path string
+	}
+
+	type want struct {
+		medias []fetch.Media
+		err    error
+	}
+
+	tests := []struct {
+		name string
+		fields
+		args
+		want
+	}{
+		{
+			name: "happy path",
+			fields: fields{
+				fetcher: func(mockCtrl *gomock.Controller) verify.Fetcher {
+					mock := mocks.NewMockFetcher(mockCtrl)
+					mock.
+						EXPECT().
+						GetMedias(gomock.Any()).
+						Return(
+							[]fetch.Media{
+								fetch.NewMedia("file1.mp4", 1000, fetch.WithMediaID("id1")),
+								fetch.NewMedia("file2.jpg", 2000, fetch.WithMediaID("id2")),
+								fetch.NewMedia("file3.mp4", 3000, fetch.WithMediaID("id3")),
+							},
+							nil,
+						)
+
+					return mock
+				},
+				scanner: func(mockCtrl *gomock.Controller) verify.Scanner {
+					mock := mocks.NewMockScanner(mockCtrl)
+					mock.
+						EXPECT().
+						GetFileList(gomock.Any(), "/dir").
+						Return(
+							[]dirscan.File{
+								{Name: "file1.mp4", Path: "/dir/file1.mp4", Size: 1000},
+								{Name: "file2.jpg", Path: "/dir/file2.jpg", Size: 2001},
+							},
+							nil,
+						)
+
+					return mock
+				},
+			},
+			args: args{
+				path: "/dir",
+			},
+			want: want{
+				medias: []fetch.Media{
+					fetch.NewMedia("file2.jpg", 2000, fetch.WithMediaID("id2")),
+					fetch.NewMedia("file3.mp4", 3000, fetch.WithMediaID("id3")),
+				},
+			},
+		},
+		{
+			name: "sad path, scanner.GetFileList error",
+			fields: fields{
+				fetcher: func(mockCtrl *gomock.Controller) verify.Fetcher {
+					return mocks.NewMockFetcher(mockCtrl)
+				},
+				scanner: func(mockCtrl *gomock.Controller) verify.Scanner {
+					mock := mocks.NewMockScanner(mockCtrl)
+					mock.
+						EXPECT().
+						GetFileList(gomock.Any(), "/dir").
+						Return([]dirscan.File{}, assert.AnError)
+
+					return mock
+				},
+			},
+			args: args{
+				path: "/dir",
+			},
+			want: want{
+				medias: []fetch.Media{},
+				err:    errors.Wrap(assert.AnError, "error getting local files"),
+			},
+		},
+		{
+			name: "sad path, fetcher.GetMedias error",
+			fields: fields{
+				fetcher: func(mockCtrl *gomock.Controller) verify.Fetcher {
+					mock := mocks.NewMockFetcher(mockCtrl)
+					mock.
+						EXPECT().
+						GetMedias(gomock.Any()).
+						Return([]fetch.Media{}, assert.AnError)
+
+					return mock
+				},
+				scanner: func(mockCtrl *gomock.Controller) verify.Scanner {
+					mock := mocks.NewMockScanner(mockCtrl)
+					mock.
+						EXPECT().
+						GetFileList(gomock.Any(), "/dir").
+						Return([]dirscan.File{}, nil)
+
+					return mock
+				},
+			},
+			args: args{
+				path: "/dir",
+			},
+			want: want{
+				medias: []fetch.Media{},
+				err: errors.Wrap(
+					errors.Wrap(assert.AnError, "error getting remote medias"),
+					"error getting remote files",
+				),
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			verifier := verify.NewVerifier(
+				tt.fields.fetcher(mockCtrl),
+				tt.fields.scanner(mockCtrl),
+			)
+			got, err := verifier.IdentifyRemoteOnlyMedias(context.Background(), tt.args.path)
+
+			assert.Equal(t, tt.want.medias, got)
+			if tt.want.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err.Error())
+			}
+		})
+	}
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	type args struct {
+		direction verify.Direction
+	}
+
+	type want struct {
+		report verify.Report
+		err    error
+	}
+
+	remoteMedias := []fetch.Media{
+		fetch.NewMedia("file1.mp4", 1000, fetch.WithMediaID("id1")),
+		fetch.NewMedia("file2.jpg", 2000, fetch.WithMediaID("id2")),
+	}
+
+	localFiles := []dirscan.File{
+		{Name: "file1.mp4", Path: "/dir/file1.mp4", Size: 1000},
+		{Name: "file3.mp4", Path: "/dir/file3.mp4", Size: 3000},
+	}
+
+	uploadedEntry := verify.Entry{
+		LocalPath:   "/dir/file1.mp4",
+		Size:        1000,
+		Status:      verify.StatusUploaded,
+		RemoteMedia: &remoteMedias[0],
+		Reason:      "remote file with the same name and size",
+		MatchedBy:   "name-size",
+	}
+
+	missingEntry := verify.Entry{
+		LocalPath: "/dir/file3.mp4",
+		Size:      3000,
+		Status:    verify.StatusMissing,
+		Reason:    "no remote file with the same name and size",
+	}
+
+	remoteOnlyEntry := verify.Entry{
+		Size:        2000,
+		Status:      verify.StatusRemoteOnly,
+		RemoteMedia: &remoteMedias[1],
+		Reason:      "no local file with the same name and size",
+	}
+
+	tests := []struct {
+		name string
+		args
+		want
+	}{
+		{
+			name: "happy path, local direction",
+			args: args{direction: verify.DirectionLocal},
+			want: want{
+				report: verify.Report{
+					Path:      "/dir",
+					Direction: verify.DirectionLocal,
+					Entries:   []verify.Entry{uploadedEntry, missingEntry},
+					Totals: verify.Totals{
+						FilesScanned:  2,
+						RemoteFetched: 2,
+						FilesMissing:  1,
+						BytesMissing:  3000,
+						MatchedBy:     map[string]int{"name-size": 1},
+						Duration:      time.Second,
+					},
+				},
+			},
+		},
+		{
+			name: "happy path, remote direction",
+			args: args{direction: verify.DirectionRemote},
+			want: want{
+				report: verify.Report{
+					Path:      "/dir",
+					Direction: verify.DirectionRemote,
+					Entries:   []verify.Entry{remoteOnlyEntry},
+					Totals: verify.Totals{
+						FilesScanned:  2,
+						RemoteFetched: 2,
+						RemoteOnly:    1,
+						Duration:      time.Second,
+					},
+				},
+			},
+		},
+		{
+			name: "happy path, both directions",
+			args: args{direction: verify.DirectionBoth},
+			want: want{
+				report: verify.Report{
+					Path:      "/dir",
+					Direction: verify.DirectionBoth,
+					Entries:   []verify.Entry{uploadedEntry, missingEntry, remoteOnlyEntry},
+					Totals: verify.Totals{
+						FilesScanned:  2,
+						RemoteFetched: 2,
+						FilesMissing:  1,
+						BytesMissing:  3000,
+						RemoteOnly:    1,
+						MatchedBy:     map[string]int{"name-size": 1},
+						Duration:      time.Second,
+					},
+				},
+			},
+		},
+		{
+			name: "sad path, invalid direction",
+			args: args{direction: "sideways"},
+			want: want{
+				report: verify.Report{
+					Path:      "/dir",
+					Direction: "sideways",
+					Entries:   []verify.Entry{},
+				},
+				err: errors.New("invalid direction: sideways"),
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			fetcher := mocks.NewMockFetcher(mockCtrl)
+			scanner := mocks.NewMockScanner(mockCtrl)
+
+			if tt.want.err == nil {
+				fetcher.EXPECT().GetMedias(gomock.Any()).Return(remoteMedias, nil)
+				scanner.EXPECT().GetFileList(gomock.Any(), "/dir").Return(localFiles, nil)
+			}
+
+			startedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			calls := 0
+			now := func() time.Time {
+				calls++
+				return startedAt.Add(time.Duration(calls-1) * time.Second)
+			}
+
+			got, err := verify.NewVerifier(fetcher, scanner, verify.WithNow(now)).Verify(context.Background(), "/dir", tt.args.direction)
+
+			assert.Equal(t, tt.want.report, got)
+			if tt.want.err == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.report.MissingFilePaths(), got.MissingFilePaths())
+			} else {
+				assert.EqualError(t, err, tt.want.err.Error())
+			}
+		})
+	}
+}
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	remoteMedia := fetch.NewMedia("file2.jpg", 2000, fetch.WithMediaID("id2"))
+
+	report := verify.Report{
+		Entries: []verify.Entry{
+			{LocalPath: "/dir/file1.mp4", Size: 1000, Status: verify.StatusUploaded, RemoteMedia: &remoteMedia},
+			{LocalPath: "/dir/file3.mp4", Size: 3000, Status: verify.StatusMissing},
+			{Size: 2000, Status: verify.StatusRemoteOnly, RemoteMedia: &remoteMedia},
+		},
+	}
+
+	assert.Equal(t, []string{"/dir/file3.mp4"}, report.MissingFilePaths())
+	assert.Equal(t, []fetch.Media{remoteMedia}, report.RemoteOnlyMedias())
+	assert.Len(t, report.EntriesWithStatus(verify.StatusUploaded), 1)
+}
+
+func TestVerifier_Verify_IncompleteListing(t *testing.T) {
+	type want struct {
+		warnings     []string
+		filesMissing int
+		err          string
+	}
+
+	tests := []struct {
+		name   string
+		strict bool
+		want
+	}{
+		{
+			name: "happy path, incomplete listing is a warning",
+			want: want{
+				warnings:     []string{"incomplete remote listing: expected 3 medias, received 1, some files may be reported only because of it"},
+				filesMissing: 1,
+			},
+		},
+		{
+			name:   "sad path, incomplete listing fails in strict mode",
+			strict: true,
+			want: want{
+				err: "error getting remote files: error getting remote medias: incomplete remote listing: expected 3 medias, received 1",
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			fetcher := mocks.NewMockFetcher(mockCtrl)
+			fetcher.EXPECT().GetMedias(gomock.Any()).Return(
+				[]fetch.Media{fetch.NewMedia("file1.mp4", 1000)},
+				fetch.NewIncompleteListingError(3, 1),
+			)
+
+			scanner := mocks.NewMockScanner(mockCtrl)
+			scanner.EXPECT().GetFileList(gomock.Any(), "/dir").Return([]dirscan.File{
+				{Name: "file1.mp4", Path: "/dir/file1.mp4", Size: 1000},
+				{Name: "file2.mp4", Path: "/dir/file2.mp4", Size: 2000},
+			}, nil)
+
+			logWriter := &bytes.Buffer{}
+			verifier := verify.NewVerifier(fetcher, scanner, verify.WithStrict(tt.strict), verify.WithLogWriter(logWriter))
+
+			got, err := verifier.Verify(context.Background(), "/dir", verify.DirectionLocal)
+			if tt.want.err != "" {
+				assert.EqualError(t, err, tt.want.err)
+				assert.ErrorAs(t, err, &verify.FetchError{})
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.warnings, got.Warnings)
+			assert.Equal(t, tt.want.filesMissing, got.Totals.FilesMissing)
+			assert.Contains(t, logWriter.String(), "Warning: incomplete remote listing")
+		})
+	}
+}
+
+func TestVerifier_Verify_Snapshot(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	now := time.Date(2023, 6, 10, 12, 0, 0, 0, time.UTC)
+	takenAt := now.Add(-3 * time.Hour)
+
+	snapshot := indexcache.NewSnapshot(indexcache.NewIndex([]fetch.Media{fetch.NewMedia("file1.mp4", 1000)}, takenAt, takenAt))
+
+	scanner := mocks.NewMockScanner(mockCtrl)
+	scanner.EXPECT().GetFileList(gomock.Any(), "/dir").Return([]dirscan.File{
+		{Name: "file1.mp4", Path: "/dir/file1.mp4", Size: 1000},
+	}, nil)
+
+	logWriter := &bytes.Buffer{}
+	verifier := verify.NewVerifier(snapshot, scanner, verify.WithLogWriter(logWriter), verify.WithNow(func() time.Time { return now }))
+
+	got, err := verifier.Verify(context.Background(), "/dir", verify.DirectionLocal)
+	assert.NoError(t, err)
+	assert.Equal(t, &verify.RemoteSnapshot{TakenAt: takenAt, Age: 3 * time.Hour}, got.RemoteSnapshot)
+	assert.Len(t, got.Warnings, 1)
+	assert.Contains(t, got.Warnings[0], "verified offline against a snapshot of Gopro Media Library taken 3h0m0s ago")
+	assert.Contains(t, logWriter.String(), "Warning: verified offline")
+}
+
+func TestVerifier_Verify_ProcessingState(t *testing.T) {
+	type want struct {
+		statuses        map[string]verify.Status
+		filesProcessing int
+		filesFailed     int
+	}
+
+	tests := []struct {
+		name           string
+		safeCategories []fetch.ProcessingCategory
+		want
+	}{
+		{
+			name: "happy path, only ready uploads are safe",
+			want: want{
+				statuses: map[string]verify.Status{
+					"/dir/ready.mp4":       verify.StatusUploaded,
+					"/dir/transcoding.mp4": verify.StatusUploadedProcessing,
+					"/dir/failure.mp4":     verify.StatusUploadedFailed,
+					"/dir/reuploaded.mp4":  verify.StatusUploaded,
+				},
+				filesProcessing: 1,
+				filesFailed:     1,
+			},
+		},
+		{
+			name:           "happy path, processing uploads are allowed",
+			safeCategories: []fetch.ProcessingCategory{fetch.ProcessingReady, fetch.ProcessingInProgress},
+			want: want{
+				statuses: map[string]verify.Status{
+					"/dir/ready.mp4":       verify.StatusUploaded,
+					"/dir/transcoding.mp4": verify.StatusUploaded,
+					"/dir/failure.mp4":     verify.StatusUploadedFailed,
+					"/dir/reuploaded.mp4":  verify.StatusUploaded,
+				},
+				filesFailed: 1,
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			fetcher := mocks.NewMockFetcher(mockCtrl)
+			fetcher.EXPECT().GetMedias(gomock.Any()).Return([]fetch.Media{
+				fetch.NewMedia("ready.mp4", 1000, fetch.WithMediaProcessingState("ready")),
+				fetch.NewMedia("transcoding.mp4", 1000, fetch.WithMediaProcessingState("transcoding")),
+				fetch.NewMedia("failure.mp4", 1000, fetch.WithMediaProcessingState("failure")),
+				// A failed upload uploaded again, the ready copy wins
+				fetch.NewMedia("reuploaded.mp4", 1000, fetch.WithMediaProcessingState("failure")),
+				fetch.NewMedia("reuploaded.mp4", 1000, fetch.WithMediaProcessingState("ready")),
+			}, nil)
+
+			scanner := mocks.NewMockScanner(mockCtrl)
+			scanner.EXPECT().GetFileList(gomock.Any(), "/dir").Return([]dirscan.File{
+				{Name: "ready.mp4", Path: "/dir/ready.mp4", Size: 1000},
+				{Name: "transcoding.mp4", Path: "/dir/transcoding.mp4", Size: 1000},
+				{Name: "failure.mp4", Path: "/dir/failure.mp4", Size: 1000},
+				{Name: "reuploaded.mp4", Path: "/dir/reuploaded.mp4", Size: 1000},
+			}, nil)
+
+			opts := []func(*verify.Verifier){verify.WithLogWriter(&bytes.Buffer{})}
+			if tt.safeCategories != nil {
+				opts = append(opts, verify.WithSafeProcessingCategories(tt.safeCategories...))
+			}
+
+			got, err := verify.NewVerifier(fetcher, scanner, opts...).Verify(context.Background(), "/dir", verify.DirectionLocal)
+			assert.NoError(t, err)
+
+			statuses := map[string]verify.Status{}
+			for _, entry := range got.Entries {
+				statuses[entry.LocalPath] = entry.Status
+			}
+
+			assert.Equal(t, tt.want.statuses, statuses)
+			assert.Equal(t, tt.want.filesProcessing, got.Totals.FilesProcessing)
+			assert.Equal(t, tt.want.filesFailed, got.Totals.FilesFailed)
+			assert.Equal(t, 0, got.Totals.FilesMissing)
+			assert.Empty(t, got.MissingFilePaths())
+		})
+	}
+}
+
+// BenchmarkVerifier_Verify matches 100k local files against a library of 100k medias,
+// half of them on both sides, in both directions
+func BenchmarkVerifier_Verify(b *testing.B) {
+	const count = 100_000
+
+	localFiles := make([]dirscan.File, 0, count)
+	remoteMedias := make([]fetch.Media, 0, count)
+
+	for i := 0; i < count; i++ {
+		localName := fmt.Sprintf("GX%06d.MP4", i)
+		localFiles = append(localFiles, dirscan.File{Name: localName, Path: "/dir/" + localName, Size: int64(i)})
+
+		// Every other remote media has a local counterpart, the rest are only in the cloud
+		remoteName := localName
+		if i%2 == 1 {
+			remoteName = fmt.Sprintf("GX%06d.MP4", count+i)
+		}
+		remoteMedias = append(remoteMedias, fetch.NewMedia(remoteName, int64(i), fetch.WithMediaProcessingState("ready")))
+	}
+
+	mockCtrl := gomock.NewController(b)
+	defer mockCtrl.Finish()
+
+	fetcher := mocks.NewMockFetcher(mockCtrl)
+	fetcher.EXPECT().GetMedias(gomock.Any()).Return(remoteMedias, nil).AnyTimes()
+
+	scanner := mocks.NewMockScanner(mockCtrl)
+	scanner.EXPECT().GetFileList(gomock.Any(), "/dir").Return(localFiles, nil).AnyTimes()
+
+	verifier := verify.NewVerifier(fetcher, scanner, verify.WithLogWriter(io.Discard))
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		report, err := verifier.Verify(context.Background(), "/dir", verify.DirectionBoth)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		if report.Totals.FilesMissing != count/2 || report.Totals.RemoteOnly != count/2 {
+			b.Fatalf("unexpected totals: %+v", report.Totals)
+		}
+	}
+}
+
+func TestVerifier_Verify_Matcher(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	capturedAt := time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)
+
+	fetcher := mocks.NewMockFetcher(mockCtrl)
+	fetcher.EXPECT().GetMedias(gomock.Any()).Return([]fetch.Media{
+		fetch.NewMedia("GX010001.MP4", 1000, fetch.WithMediaCapturedAt(capturedAt)),
+	}, nil)
+
+	scanner := mocks.NewMockScanner(mockCtrl)
+	scanner.EXPECT().GetFileList(gomock.Any(), "/dir").Return([]dirscan.File{
+		{Name: "ride.mp4", Path: "/dir/ride.mp4", Size: 1000, ModTime: capturedAt},
+		{Name: "other.mp4", Path: "/dir/other.mp4", Size: 2000},
+	}, nil)
+
+	logWriter := &bytes.Buffer{}
+	matcher := verify.NewChainMatcher(verify.MatchNameSize, verify.MatchSizeCaptured)
+
+	got, err := verify.NewVerifier(fetcher, scanner, verify.WithLogWriter(logWriter), verify.WithMatcher(matcher)).
+		Verify(context.Background(), "/dir", verify.DirectionBoth)
+	assert.NoError(t, err)
+	assert.Contains(t, logWriter.String(), "based on: fileName, fileSize; then fileSize, captureTime")
+
+	assert.Len(t, got.Entries, 2)
+	assert.Equal(t, verify.StatusUploadedRenamed, got.Entries[0].Status)
+	assert.Equal(t, "size-captured", got.Entries[0].MatchedBy)
+	assert.Equal(t, "remote file with the same size and capture time, uploaded under a different name: GX010001.MP4", got.Entries[0].Reason)
+	assert.Equal(t, verify.StatusMissing, got.Entries[1].Status)
+	assert.Equal(t, "no remote file with the same name and size or the same size and capture time", got.Entries[1].Reason)
+	assert.Equal(t, 0, got.Totals.RemoteOnly)
+}
+
+func TestVerifier_Verify_Renamed(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	capturedAt := time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)
+
+	fetcher := mocks.NewMockFetcher(mockCtrl)
+	fetcher.EXPECT().GetMedias(gomock.Any()).Return([]fetch.Media{
+		fetch.NewMedia("GX010001.MP4", 1000, fetch.WithMediaCapturedAt(capturedAt), fetch.WithMediaProcessingState("ready")),
+		fetch.NewMedia("GX010002.MP4", 2000, fetch.WithMediaCapturedAt(capturedAt.Add(time.Hour)), fetch.WithMediaProcessingState("transcoding")),
+	}, nil)
+
+	scanner := mocks.NewMockScanner(mockCtrl)
+	scanner.EXPECT().GetFileList(gomock.Any(), "/dir").Return([]dirscan.File{
+		{Name: "Hawaii-day2-surf.mp4", Path: "/dir/Hawaii-day2-surf.mp4", Size: 1000, Metadata: mediameta.Metadata{CapturedAt: capturedAt.Add(time.Second)}},
+		{Name: "Hawaii-day3-dive.mp4", Path: "/dir/Hawaii-day3-dive.mp4", Size: 2000, Metadata: mediameta.Metadata{CapturedAt: capturedAt.Add(time.Hour)}},
+	}, nil)
+
+	matcher := verify.NewChainMatcher(verify.MatchNameSize, verify.MatchRenamed)
+
+	got, err := verify.NewVerifier(fetcher, scanner, verify.WithLogWriter(io.Discard), verify.WithMatcher(matcher)).
+		Verify(context.Background(), "/dir", verify.DirectionBoth)
+	assert.NoError(t, err)
+
+	assert.Len(t, got.Entries, 2)
+	assert.Equal(t, verify.StatusUploadedRenamed, got.Entries[0].Status)
+	assert.Equal(t, "renamed", got.Entries[0].MatchedBy)
+	assert.Equal(t, "remote file with a capture time within 2s and the same size or duration, uploaded under a different name: GX010001.MP4", got.Entries[0].Reason)
+
+	// The processing state still decides whether the upload is safe
+	assert.Equal(t, verify.StatusUploadedProcessing, got.Entries[1].Status)
+	assert.Contains(t, got.Entries[1].Reason, "uploaded under a different name: GX010002.MP4")
+
+	assert.Equal(t, 1, got.Totals.FilesRenamed)
+	assert.Equal(t, 1, got.Totals.FilesProcessing)
+	assert.Equal(t, 0, got.Totals.FilesMissing)
+	assert.Equal(t, 0, got.Totals.RemoteOnly)
+}
+
+func TestVerifier_Verify_Conflict(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	fetcher := mocks.NewMockFetcher(mockCtrl)
+	fetcher.EXPECT().GetMedias(gomock.Any()).Return([]fetch.Media{
+		fetch.NewMedia("GX010001.MP4", 3800, fetch.WithMediaID("id1")),
+	}, nil).Times(2)
+
+	scanner := mocks.NewMockScanner(mockCtrl)
+	scanner.EXPECT().GetFileList(gomock.Any(), "/dir").Return([]dirscan.File{
+		{Name: "GX010001.MP4", Path: "/dir/GX010001.MP4", Size: 5000},
+		{Name: "GX010002.MP4", Path: "/dir/GX010002.MP4", Size: 1000},
+	}, nil).Times(2)
+
+	verifier := verify.NewVerifier(fetcher, scanner, verify.WithLogWriter(&bytes.Buffer{}))
+
+	got, err := verifier.Verify(context.Background(), "/dir", verify.DirectionLocal)
+	assert.NoError(t, err)
+
+	conflicts := got.EntriesWithStatus(verify.StatusConflict)
+	assert.Len(t, conflicts, 1)
+	assert.Equal(t, "/dir/GX010001.MP4", conflicts[0].LocalPath)
+	assert.Equal(t, "id1", conflicts[0].RemoteMedia.ID())
+	assert.Equal(t, int64(-1200), conflicts[0].SizeDelta())
+	assert.Equal(t, "remote file with the same name has another size: local 5000 bytes, remote 3800 bytes (-1200 bytes)", conflicts[0].Reason)
+	assert.Equal(t, 1, got.Totals.FilesConflict)
+	assert.Equal(t, 1, got.Totals.FilesMissing)
+	assert.Equal(t, []string{"/dir/GX010002.MP4"}, got.MissingFilePaths())
+
+	// The conflicting file isn't uploaded again, the cloud copy may be a re-encoded one
+	filePaths, err := verifier.IdentifyMissingFiles(context.Background(), "/dir")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/dir/GX010002.MP4"}, filePaths)
+}
+
+func TestVerifier_Verify_Conflict_SameSize(t *testing.T) {
+	t.Parallel()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	capturedAt := time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)
+
+	fetcher := mocks.NewMockFetcher(mockCtrl)
+	fetcher.EXPECT().GetMedias(gomock.Any()).Return([]fetch.Media{
+		fetch.NewMedia("GX010001.MP4", 100, fetch.WithMediaCapturedAt(capturedAt)),
+	}, nil)
+
+	// The capture time is a day off, so the file doesn't match, but the same name and size aren't a conflict
+	scanner := mocks.NewMockScanner(mockCtrl)
+	scanner.EXPECT().GetFileList(gomock.Any(), "/dir").Return([]dirscan.File{
+		{Name: "GX010001.MP4", Path: "/dir/GX010001.MP4", Size: 100, ModTime: capturedAt.Add(24 * time.Hour)},
+	}, nil)
+
+	got, err := verify.NewVerifier(fetcher, scanner, verify.WithLogWriter(io.Discard), verify.WithMatcher(verify.MatchSizeCaptured)).
+		Verify(context.Background(), "/dir", verify.DirectionLocal)
+	assert.NoError(t, err)
+
+	assert.Len(t, got.Entries, 1)
+	assert.Equal(t, verify.StatusMissing, got.Entries[0].Status)
+	assert.Equal(t, 0, got.Totals.FilesConflict)
+}
+
+func TestVerifier_Verify_Chapters(t *testing.T) {
+	type want struct {
+		statuses   map[string]verify.Status
+		remoteOnly int
+	}
+
+	chapters := []dirscan.File{
+		{Name: "GX010123.MP4", Path: "/dir/GX010123.MP4", Size: 1000},
+		{Name: "GX020123.MP4", Path: "/dir/GX020123.MP4", Size: 2000},
+	}
+
+	tests := []struct {
+		name         string
+		remoteMedias []fetch.Media
+		want
+	}{
+		{
+			name:         "happy path, merged recording of the size of the chapters",
+			remoteMedias: []fetch.Media{fetch.NewMedia("GX010123.MP4", 3000)},
+			want: want{
+				statuses: map[string]verify.Status{
+					"/dir/GX010123.MP4": verify.StatusUploaded,
+					"/dir/GX020123.MP4": verify.StatusUploaded,
+				},
+			},
+		},
+		{
+			name:         "happy path, merged recording named after the first chapter",
+			remoteMedias: []fetch.Media{fetch.NewMedia("GX010123.MP4", 2900)},
+			want: want{
+				statuses: map[string]verify.Status{
+					"/dir/GX010123.MP4": verify.StatusUploaded,
+					"/dir/GX020123.MP4": verify.StatusUploaded,
+				},
+			},
+		},
+		{
+			name:         "happy path, only the first chapter is uploaded on its own",
+			remoteMedias: []fetch.Media{fetch.NewMedia("GX010123.MP4", 1000)},
+			want: want{
+				statuses: map[string]verify.Status{
+					"/dir/GX010123.MP4": verify.StatusUploaded,
+					"/dir/GX020123.MP4": verify.StatusMissing,
+				},
+			},
+		},
+		{
+			name:         "happy path, truncated remote file named after the first chapter is a conflict",
+			remoteMedias: []fetch.Media{fetch.NewMedia("GX010123.MP4", 600)},
+			want: want{
+				statuses: map[string]verify.Status{
+					"/dir/GX010123.MP4": verify.StatusConflict,
+					"/dir/GX020123.MP4": verify.StatusMissing,
+				},
+				remoteOnly: 1,
+			},
+		},
+		{
+			name:         "happy path, remote file named after the first chapter is bigger than the recording",
+			remoteMedias: []fetch.Media{fetch.NewMedia("GX010123.MP4", 3100)},
+			want: want{
+				statuses: map[string]verify.Status{
+					"/dir/GX010123.MP4": verify.StatusConflict,
+					"/dir/GX020123.MP4": verify.StatusMissing,
+				},
+				remoteOnly: 1,
+			},
+		},
+		{
+			name:         "happy path, unrelated remote file",
+			remoteMedias: []fetch.Media{fetch.NewMedia("GX010456.MP4", 3500)},
+			want: want{
+				statuses: map[string]verify.Status{
+					"/dir/GX010123.MP4": verify.StatusMissing,
+					"/dir/GX020123.MP4": verify.StatusMissing,
+				},
+				remoteOnly: 1,
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			fetcher := mocks.NewMockFetcher(mockCtrl)
+			fetcher.EXPECT().GetMedias(gomock.Any()).Return(tt.remoteMedias, nil)
+
+			scanner := mocks.NewMockScanner(mockCtrl)
+			scanner.EXPECT().GetFileList(gomock.Any(), "/dir").Return(chapters, nil)
+
+			got, err := verify.NewVerifier(fetcher, scanner, verify.WithLogWriter(&bytes.Buffer{})).
+				Verify(context.Background(), "/dir", verify.DirectionBoth)
+			assert.NoError(t, err)
+
+			statuses := map[string]verify.Status{}
+			for _, entry := range got.Entries {
+				if entry.LocalPath == "" {
+					continue
+				}
+
+				statuses[entry.LocalPath] = entry.Status
+
+				if entry.Status == verify.StatusUploaded && entry.MatchedBy == verify.MatchChapters.Name {
+					assert.Equal(t, "remote file with the merged chapters of the recording", entry.Reason)
+				}
+			}
+
+			assert.Equal(t, tt.want.statuses, statuses)
+			assert.Equal(t, tt.want.remoteOnly, got.Totals.RemoteOnly)
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package verifyrun
+
+import (
+	"context"
+	"fmt"
+	"github.com/legosx/gopro-media-library-verifier/buildclient"
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"strings"
+)
+
+const (
+	configAuthTokenKey  = "auth.token"
+	configAPIBaseURLKey = "api.baseUrl"
+
+	configAPIRetryMaxAttemptsKey = "api.retry.maxAttempts"
+	configAPIRetryBudgetKey      = "api.retry.budget"
+)
+
+type TokenPromptMethod string
+
+const (
+	TokenPromptMethodInput TokenPromptMethod = "input"
+	TokenPromptMethodCURL  TokenPromptMethod = "curl"
+)
+
+var tokenPromptMethodsAvailable = []TokenPromptMethod{TokenPromptMethodInput, TokenPromptMethodCURL}
+
+// BuildClientOptions returns the builder options shared by all commands talking to Gopro Media Library.
+func BuildClientOptions(ctx context.Context, tokenPromptMethod TokenPromptMethod) (opts []func(builder *buildclient.Builder), err error) {
+	tokenPromptMethods, err := getTokenPromptMethods(tokenPromptMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = []func(builder *buildclient.Builder){
+		buildclient.WithContext(ctx),
+		buildclient.WithConfigAuthTokenKey(configAuthTokenKey),
+		buildclient.WithTokenPromptMethods(tokenPromptMethods...),
+		buildclient.WithPersistConfig(buildclient.PersistConfigIfChanged),
+		buildclient.WithVerbose(buildclient.VerboseAll),
+	}
+
+	// Another API server can be used, e.g. a local fake server for testing
+	if baseURL := viper.GetString(configAPIBaseURLKey); baseURL != "" {
+		opts = append(opts, buildclient.WithClientOptions(client.WithBaseURL(baseURL)))
+	}
+
+	retryPolicy := client.DefaultRetryPolicy()
+	if viper.IsSet(configAPIRetryMaxAttemptsKey) {
+		retryPolicy.MaxAttempts = viper.GetInt(configAPIRetryMaxAttemptsKey)
+	}
+	if viper.IsSet(configAPIRetryBudgetKey) {
+		retryPolicy.Budget = viper.GetDuration(configAPIRetryBudgetKey)
+	}
+	opts = append(opts, buildclient.WithClientOptions(client.WithRetryPolicy(retryPolicy)))
+
+	return opts, nil
+}
+
+func InitTokenPromptMethod(cmd *cobra.Command) {
+	var methods []string
+	for _, tokenPromptMethod := range tokenPromptMethodsAvailable {
+		methods = append(methods, string(tokenPromptMethod))
+	}
+
+	usage := fmt.Sprintf("method to use for token prompt (%s)", strings.Join(methods, ", "))
+	cmd.Flags().StringP("tokenPromptMethod", "m", "", usage)
+}
+
+func getTokenPromptMethods(tokenPromptMethod TokenPromptMethod) (methods []buildclient.TokenPromptMethod, err error) {
+	if tokenPromptMethod == "" {
+		return []buildclient.TokenPromptMethod{
+			buildclient.NewTokenPromptMethodInput(),
+			buildclient.NewTokenPromptMethodCURL(),
+		}, nil
+	}
+
+	var method buildclient.TokenPromptMethod
+
+	switch tokenPromptMethod {
+	case TokenPromptMethodInput:
+		method = buildclient.NewTokenPromptMethodInput()
+	case TokenPromptMethodCURL:
+		method = buildclient.NewTokenPromptMethodCURL()
+	default:
+		return []buildclient.TokenPromptMethod{}, fmt.Errorf("invalid token prompt method: %s", tokenPromptMethod)
+	}
+
+	return []buildclient.TokenPromptMethod{method}, nil
+}
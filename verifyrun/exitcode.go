@@ -0,0 +1,82 @@
+package verifyrun
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/verify"
+	"github.com/pkg/errors"
+	"net"
+)
+
+// Exit codes of the commands, so the scripts running them don't need to parse the output
+const (
+	// ExitCodeOK means there are no files missing
+	ExitCodeOK = 0
+	// ExitCodeError is any failure that doesn't have a more specific code, e.g. invalid flags
+	ExitCodeError = 1
+	// ExitCodeFilesMissing means the run succeeded and found files missing on the verified side(s)
+	ExitCodeFilesMissing = 2
+	// ExitCodeAuthFailure means no valid token could be obtained
+	ExitCodeAuthFailure = 3
+	// ExitCodeAPIFailure means Gopro Media Library could not be reached or returned an error
+	ExitCodeAPIFailure = 4
+	// ExitCodeScanFailure means the local directory could not be scanned
+	ExitCodeScanFailure = 5
+)
+
+// ErrFilesMissing is returned by a successful run that found files missing on the verified side(s)
+var ErrFilesMissing = errors.New("files are missing")
+
+// AuthError is returned when the client can't be authenticated
+type AuthError struct {
+	err error
+}
+
+func NewAuthError(err error) error {
+	return AuthError{err: err}
+}
+
+func (e AuthError) Error() string {
+	return e.err.Error()
+}
+
+func (e AuthError) Unwrap() error {
+	return e.err
+}
+
+// GetExitCode tells which exit code the command should finish with after returning the error
+func GetExitCode(err error) int {
+	if err == nil {
+		return ExitCodeOK
+	}
+
+	if errors.Is(err, ErrFilesMissing) {
+		return ExitCodeFilesMissing
+	}
+
+	if errors.As(err, &verify.ScanError{}) {
+		return ExitCodeScanFailure
+	}
+
+	if errors.Is(err, client.ErrUnauthorized) || errors.Is(err, client.ErrForbidden) {
+		return ExitCodeAuthFailure
+	}
+
+	if errors.As(err, &client.ErrorResponse{}) || errors.Is(err, client.ErrMalformedResponse) {
+		return ExitCodeAPIFailure
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ExitCodeAPIFailure
+	}
+
+	if errors.As(err, &AuthError{}) {
+		return ExitCodeAuthFailure
+	}
+
+	if errors.As(err, &verify.FetchError{}) {
+		return ExitCodeAPIFailure
+	}
+
+	return ExitCodeError
+}
@@ -0,0 +1,93 @@
+package verifyrun_test
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/client"
+	"github.com/legosx/gopro-media-library-verifier/verify"
+	"github.com/legosx/gopro-media-library-verifier/verifyrun"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"testing"
+)
+
+func TestGetExitCode(t *testing.T) {
+	type args struct {
+		err error
+	}
+
+	type want struct {
+		exitCode int
+	}
+
+	errorResponse := func(statusCode int) error {
+		return client.NewErrorResponse(&http.Response{StatusCode: statusCode, Status: http.StatusText(statusCode)})
+	}
+
+	tests := []struct {
+		name string
+		args
+		want
+	}{
+		{
+			name: "no error",
+			want: want{exitCode: verifyrun.ExitCodeOK},
+		},
+		{
+			name: "files missing",
+			args: args{err: verifyrun.ErrFilesMissing},
+			want: want{exitCode: verifyrun.ExitCodeFilesMissing},
+		},
+		{
+			name: "scan failure",
+			args: args{err: verify.NewScanError(errors.Wrap(assert.AnError, "error getting local files"))},
+			want: want{exitCode: verifyrun.ExitCodeScanFailure},
+		},
+		{
+			name: "fetch failure",
+			args: args{err: verify.NewFetchError(errors.Wrap(assert.AnError, "error getting remote files"))},
+			want: want{exitCode: verifyrun.ExitCodeAPIFailure},
+		},
+		{
+			name: "fetch failure, unauthorized",
+			args: args{err: verify.NewFetchError(errors.Wrap(errorResponse(http.StatusUnauthorized), "error getting remote files"))},
+			want: want{exitCode: verifyrun.ExitCodeAuthFailure},
+		},
+		{
+			name: "fetch failure, malformed response",
+			args: args{err: verify.NewFetchError(errors.Wrap(errors.Wrap(client.ErrMalformedResponse, "unexpected response"), "error getting remote files"))},
+			want: want{exitCode: verifyrun.ExitCodeAPIFailure},
+		},
+		{
+			name: "auth failure",
+			args: args{err: verifyrun.NewAuthError(errors.Wrap(assert.AnError, "token prompt failed"))},
+			want: want{exitCode: verifyrun.ExitCodeAuthFailure},
+		},
+		{
+			name: "auth failure caused by a server error",
+			args: args{err: verifyrun.NewAuthError(errors.Wrap(errorResponse(http.StatusInternalServerError), "failed to get token from config"))},
+			want: want{exitCode: verifyrun.ExitCodeAPIFailure},
+		},
+		{
+			name: "auth failure caused by the network",
+			args: args{err: verifyrun.NewAuthError(errors.Wrap(&net.OpError{Op: "dial", Err: assert.AnError}, "failed to get token from config"))},
+			want: want{exitCode: verifyrun.ExitCodeAPIFailure},
+		},
+		{
+			name: "other error",
+			args: args{err: errors.New("invalid direction: sideways")},
+			want: want{exitCode: verifyrun.ExitCodeError},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want.exitCode, verifyrun.GetExitCode(tt.args.err))
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package verifyrun
+
+import (
+	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/legosx/gopro-media-library-verifier/indexcache"
+	"github.com/spf13/viper"
+	"io"
+)
+
+const (
+	configFetchStrategyKey = "fetch.strategy"
+
+	configCacheRevalidateAfterKey = "cache.revalidateAfter"
+)
+
+// FetcherOptions returns the fetcher options shared by all commands listing Gopro Media Library.
+func FetcherOptions() (opts []func(fetcher *fetch.Fetcher), err error) {
+	if !viper.IsSet(configFetchStrategyKey) {
+		return opts, nil
+	}
+
+	strategy := fetch.Strategy(viper.GetString(configFetchStrategyKey))
+	if err = strategy.Validate(); err != nil {
+		return nil, err
+	}
+
+	return append(opts, fetch.WithStrategy(strategy)), nil
+}
+
+// NewCachedFetcher keeps the index fetched by the fetcher in the user's cache directory, one index per API server.
+func NewCachedFetcher(fetcher indexcache.Fetcher, mode indexcache.Mode, logWriter io.Writer) (cache indexcache.Cache, err error) {
+	path, err := indexcache.DefaultPath(viper.GetString(configAPIBaseURLKey))
+	if err != nil {
+		return indexcache.Cache{}, err
+	}
+
+	opts := []func(cache *indexcache.Cache){
+		indexcache.WithMode(mode),
+		indexcache.WithLogWriter(logWriter),
+	}
+
+	if viper.IsSet(configCacheRevalidateAfterKey) {
+		opts = append(opts, indexcache.WithRevalidateAfter(viper.GetDuration(configCacheRevalidateAfterKey)))
+	}
+
+	return indexcache.NewCache(fetcher, path, opts...), nil
+}
@@ -0,0 +1,285 @@
+package verifyrun
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"github.com/legosx/gopro-media-library-verifier/verify"
+	"github.com/pkg/errors"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is the way the verification report is written
+type Format string
+
+const (
+	FormatText   Format = "text"
+	FormatJSON   Format = "json"
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+// SchemaVersion is the version of the machine-readable formats, any new field or status value increases it
+const SchemaVersion = 3
+
+var FormatsAvailable = []Format{
+	FormatText,
+	FormatJSON,
+	FormatCSV,
+	FormatNDJSON,
+}
+
+func (f Format) Validate() error {
+	for _, format := range FormatsAvailable {
+		if f == format {
+			return nil
+		}
+	}
+
+	return errors.Errorf("invalid format: %s", f)
+}
+
+type fileRecord struct {
+	Status     verify.Status `json:"status"`
+	Path       string        `json:"path,omitempty"`
+	Name       string        `json:"name"`
+	Extension  string        `json:"extension"`
+	Dir        string        `json:"dir,omitempty"`
+	Size       int64         `json:"size"`
+	RemoteID   string        `json:"remote_id,omitempty"`
+	CapturedAt *time.Time    `json:"captured_at,omitempty"`
+	Reason     string        `json:"reason"`
+	// RemoteSize and SizeDelta are only set for conflicts
+	RemoteSize *int64 `json:"remote_size,omitempty"`
+	SizeDelta  *int64 `json:"size_delta,omitempty"`
+	// RemoteName is only set for the files uploaded under another name
+	RemoteName string `json:"remote_name,omitempty"`
+	// MatchedBy is the rule the file matched its remote counterpart on
+	MatchedBy string `json:"matched_by,omitempty"`
+}
+
+type summaryRecord struct {
+	Path          string           `json:"path"`
+	Direction     verify.Direction `json:"direction"`
+	FilesScanned  int              `json:"files_scanned"`
+	RemoteFetched int              `json:"remote_fetched"`
+	FilesMissing  int              `json:"files_missing"`
+	BytesMissing  int64            `json:"bytes_missing"`
+	RemoteOnly    int              `json:"remote_only"`
+	// FilesProcessing and FilesFailed count the uploads that are not safe yet
+	FilesProcessing int `json:"files_processing"`
+	FilesFailed     int `json:"files_failed"`
+	FilesConflict   int `json:"files_conflict"`
+	FilesRenamed    int `json:"files_renamed"`
+	// MatchedBy counts the local files that matched by the rule they matched on
+	MatchedBy  map[string]int `json:"matched_by,omitempty"`
+	DurationMs int64          `json:"duration_ms"`
+	Warnings   []string       `json:"warnings,omitempty"`
+	// RemoteSnapshot is only set when the run was verified offline
+	RemoteSnapshot *remoteSnapshotRecord `json:"remote_snapshot,omitempty"`
+}
+
+type remoteSnapshotRecord struct {
+	TakenAt    time.Time `json:"taken_at"`
+	AgeSeconds int64     `json:"age_seconds"`
+}
+
+type jsonDocument struct {
+	SchemaVersion int           `json:"schema_version"`
+	Files         []fileRecord  `json:"files"`
+	Summary       summaryRecord `json:"summary"`
+}
+
+type ndjsonFileLine struct {
+	SchemaVersion int    `json:"schema_version"`
+	Type          string `json:"type"`
+	fileRecord
+}
+
+type ndjsonSummaryLine struct {
+	SchemaVersion int    `json:"schema_version"`
+	Type          string `json:"type"`
+	summaryRecord
+}
+
+var csvHeader = []string{"schema_version", "status", "path", "name", "extension", "dir", "size", "remote_id", "captured_at", "reason", "remote_size", "size_delta", "remote_name", "matched_by"}
+
+// writeFormatted writes the files missing on either side and the summary of the report.
+// Files that are already in sync are left out.
+func writeFormatted(w io.Writer, format Format, report verify.Report) (err error) {
+	records := newFileRecords(report)
+	summary := newSummaryRecord(report)
+
+	switch format {
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+
+		return errors.Wrap(encoder.Encode(jsonDocument{
+			SchemaVersion: SchemaVersion,
+			Files:         records,
+			Summary:       summary,
+		}), "error encoding JSON")
+	case FormatNDJSON:
+		encoder := json.NewEncoder(w)
+
+		for _, record := range records {
+			if err = encoder.Encode(ndjsonFileLine{SchemaVersion: SchemaVersion, Type: "file", fileRecord: record}); err != nil {
+				return errors.Wrap(err, "error encoding JSON")
+			}
+		}
+
+		return errors.Wrap(encoder.Encode(ndjsonSummaryLine{SchemaVersion: SchemaVersion, Type: "summary", summaryRecord: summary}), "error encoding JSON")
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+
+		if err = writer.Write(csvHeader); err != nil {
+			return errors.Wrap(err, "error writing CSV")
+		}
+
+		for _, record := range records {
+			capturedAt := ""
+			if record.CapturedAt != nil {
+				capturedAt = record.CapturedAt.Format(time.RFC3339)
+			}
+
+			remoteSize, sizeDelta := "", ""
+			if record.RemoteSize != nil && record.SizeDelta != nil {
+				remoteSize = strconv.FormatInt(*record.RemoteSize, 10)
+				sizeDelta = strconv.FormatInt(*record.SizeDelta, 10)
+			}
+
+			if err = writer.Write([]string{
+				strconv.Itoa(SchemaVersion),
+				string(record.Status),
+				record.Path,
+				record.Name,
+				record.Extension,
+				record.Dir,
+				strconv.FormatInt(record.Size, 10),
+				record.RemoteID,
+				capturedAt,
+				record.Reason,
+				remoteSize,
+				sizeDelta,
+				record.RemoteName,
+				record.MatchedBy,
+			}); err != nil {
+				return errors.Wrap(err, "error writing CSV")
+			}
+		}
+
+		writer.Flush()
+
+		return errors.Wrap(writer.Error(), "error writing CSV")
+	default:
+		return errors.Errorf("format is not machine-readable: %s", format)
+	}
+}
+
+func newFileRecords(report verify.Report) (records []fileRecord) {
+	records = []fileRecord{}
+
+	// The conflicts and the uploads that are still processing or failed are listed too, the local copies aren't safe to delete yet.
+	// So are the files uploaded under another name, their remote name tells which file in the cloud is theirs.
+	localEntries := append(report.EntriesWithStatus(verify.StatusMissing), report.EntriesWithStatus(verify.StatusConflict)...)
+	localEntries = append(localEntries, report.EntriesWithStatus(verify.StatusUploadedRenamed)...)
+	localEntries = append(localEntries, report.EntriesWithStatus(verify.StatusUploadedProcessing)...)
+	localEntries = append(localEntries, report.EntriesWithStatus(verify.StatusUploadedFailed)...)
+	sort.SliceStable(localEntries, func(i, j int) bool {
+		return localEntries[i].LocalPath < localEntries[j].LocalPath
+	})
+
+	for _, entry := range localEntries {
+		record := fileRecord{
+			Status:    entry.Status,
+			Path:      entry.LocalPath,
+			Name:      filepath.Base(entry.LocalPath),
+			Extension: getExtension(entry.LocalPath),
+			Dir:       filepath.Dir(entry.LocalPath),
+			Size:      entry.Size,
+			Reason:    entry.Reason,
+			MatchedBy: entry.MatchedBy,
+		}
+
+		if entry.RemoteMedia != nil {
+			record.RemoteID = entry.RemoteMedia.ID()
+
+			if capturedAt := entry.RemoteMedia.CapturedAt(); !capturedAt.IsZero() {
+				record.CapturedAt = &capturedAt
+			}
+		}
+
+		if entry.Status == verify.StatusConflict {
+			remoteSize, sizeDelta := entry.RemoteMedia.FileSize(), entry.SizeDelta()
+			record.RemoteSize = &remoteSize
+			record.SizeDelta = &sizeDelta
+		}
+
+		if entry.Status == verify.StatusUploadedRenamed {
+			record.RemoteName = entry.RemoteMedia.FileName()
+		}
+
+		records = append(records, record)
+	}
+
+	remoteOnlyEntries := report.EntriesWithStatus(verify.StatusRemoteOnly)
+	sort.Slice(remoteOnlyEntries, func(i, j int) bool {
+		return remoteOnlyEntries[i].RemoteMedia.FileName() < remoteOnlyEntries[j].RemoteMedia.FileName()
+	})
+
+	for _, entry := range remoteOnlyEntries {
+		record := fileRecord{
+			Status:    entry.Status,
+			Name:      entry.RemoteMedia.FileName(),
+			Extension: getExtension(entry.RemoteMedia.FileName()),
+			Size:      entry.Size,
+			RemoteID:  entry.RemoteMedia.ID(),
+			Reason:    entry.Reason,
+		}
+
+		if capturedAt := entry.RemoteMedia.CapturedAt(); !capturedAt.IsZero() {
+			record.CapturedAt = &capturedAt
+		}
+
+		records = append(records, record)
+	}
+
+	return records
+}
+
+func newSummaryRecord(report verify.Report) (summary summaryRecord) {
+	summary = summaryRecord{
+		Path:            report.Path,
+		Direction:       report.Direction,
+		FilesScanned:    report.Totals.FilesScanned,
+		RemoteFetched:   report.Totals.RemoteFetched,
+		FilesMissing:    report.Totals.FilesMissing,
+		BytesMissing:    report.Totals.BytesMissing,
+		RemoteOnly:      report.Totals.RemoteOnly,
+		FilesProcessing: report.Totals.FilesProcessing,
+		FilesFailed:     report.Totals.FilesFailed,
+		FilesConflict:   report.Totals.FilesConflict,
+		FilesRenamed:    report.Totals.FilesRenamed,
+		MatchedBy:       report.Totals.MatchedBy,
+		DurationMs:      report.Totals.Duration.Milliseconds(),
+		Warnings:        report.Warnings,
+	}
+
+	if snapshot := report.RemoteSnapshot; snapshot != nil {
+		summary.RemoteSnapshot = &remoteSnapshotRecord{
+			TakenAt:    snapshot.TakenAt,
+			AgeSeconds: int64(snapshot.Age.Seconds()),
+		}
+	}
+
+	return summary
+}
+
+func getExtension(fileName string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(fileName), "."))
+}
@@ -10,8 +10,10 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
+	verify "github.com/legosx/gopro-media-library-verifier/verify"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -38,17 +40,17 @@ func (m *MockVerifier) EXPECT() *MockVerifierMockRecorder {
 	return m.recorder
 }
 
-// IdentifyMissingFiles mocks base method.
-func (m *MockVerifier) IdentifyMissingFiles(arg0 string) ([]string, error) {
+// Verify mocks base method.
+func (m *MockVerifier) Verify(arg0 context.Context, arg1 string, arg2 verify.Direction) (verify.Report, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IdentifyMissingFiles", arg0)
-	ret0, _ := ret[0].([]string)
+	ret := m.ctrl.Call(m, "Verify", arg0, arg1, arg2)
+	ret0, _ := ret[0].(verify.Report)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// IdentifyMissingFiles indicates an expected call of IdentifyMissingFiles.
-func (mr *MockVerifierMockRecorder) IdentifyMissingFiles(arg0 any) *gomock.Call {
+// Verify indicates an expected call of Verify.
+func (mr *MockVerifierMockRecorder) Verify(arg0, arg1, arg2 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentifyMissingFiles", reflect.TypeOf((*MockVerifier)(nil).IdentifyMissingFiles), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockVerifier)(nil).Verify), arg0, arg1, arg2)
 }
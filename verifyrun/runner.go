@@ -1,54 +1,75 @@
 package verifyrun
 
 import (
+	"context"
 	"fmt"
 	"github.com/legosx/gopro-media-library-verifier/buildclient"
 	"github.com/legosx/gopro-media-library-verifier/client"
 	"github.com/legosx/gopro-media-library-verifier/dirscan"
 	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/legosx/gopro-media-library-verifier/indexcache"
+	"github.com/legosx/gopro-media-library-verifier/mediameta"
 	"github.com/legosx/gopro-media-library-verifier/verify"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/multierr"
+	"io"
 	"os"
+	"slices"
 	"sort"
 	"strings"
+	"time"
 )
 
-const (
-	configAuthTokenKey = "auth.token"
-)
-
-type TokenPromptMethod string
-
-const (
-	TokenPromptMethodInput TokenPromptMethod = "input"
-	TokenPromptMethodCURL  TokenPromptMethod = "curl"
-)
-
-var tokenPromptMethodsAvailable = []TokenPromptMethod{TokenPromptMethodInput, TokenPromptMethodCURL}
-
 type Runner struct {
 	path              string
 	outputFilePath    string
+	direction         verify.Direction
+	format            Format
 	tokenPromptMethod TokenPromptMethod
+	strict            bool
+	safeCategories    []fetch.ProcessingCategory
+	matcher           verify.Matcher
+	cacheMode         indexcache.Mode
+	offline           bool
+	manifestPath      string
+	stdout            io.Writer
+	stderr            io.Writer
 	buildClient       func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error)
-	buildVerifier     func(fetcher fetch.Fetcher, scanner dirscan.Scanner) Verifier
+	buildVerifier     func(fetcher verify.Fetcher, scanner dirscan.Scanner) Verifier
 }
 
 type Verifier interface {
-	IdentifyMissingFiles(path string) (filePaths []string, err error)
+	Verify(ctx context.Context, path string, direction verify.Direction) (report verify.Report, err error)
 }
 
-func NewRunner(path, outputFilePath string, tokenPromptMethod TokenPromptMethod, opts ...func(*Runner)) (runner Runner) {
+func NewRunner(path, outputFilePath string, direction verify.Direction, format Format, tokenPromptMethod TokenPromptMethod, opts ...func(*Runner)) (runner Runner) {
 	r := Runner{
 		path:              path,
 		outputFilePath:    outputFilePath,
+		direction:         direction,
+		format:            format,
 		tokenPromptMethod: tokenPromptMethod,
+		safeCategories:    []fetch.ProcessingCategory{fetch.ProcessingReady},
+		matcher:           verify.MatchNameSize,
+		cacheMode:         indexcache.ModeAuto,
+		stdout:            os.Stdout,
+		stderr:            os.Stderr,
 		buildClient: func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
 			return buildclient.NewBuilder(opts...).Build()
 		},
-		buildVerifier: func(fetcher fetch.Fetcher, scanner dirscan.Scanner) Verifier {
-			return verify.NewVerifier(fetcher, scanner)
-		},
+	}
+
+	r.buildVerifier = func(fetcher verify.Fetcher, scanner dirscan.Scanner) Verifier {
+		return verify.NewVerifier(
+			fetcher,
+			scanner,
+			verify.WithLogWriter(r.messageWriter()),
+			verify.WithStrict(r.strict),
+			verify.WithSafeProcessingCategories(r.safeCategories...),
+			verify.WithMatcher(r.matcher),
+		)
 	}
 
 	for _, opt := range opts {
@@ -64,104 +85,417 @@ func WithBuildClient(buildClient func(opts ...func(builder *buildclient.Builder)
 	}
 }
 
-func WithBuildVerifier(buildVerifier func(fetcher fetch.Fetcher, scanner dirscan.Scanner) Verifier) func(r *Runner) {
+// WithOutput sets where the report and the progress messages are written to
+func WithOutput(stdout, stderr io.Writer) func(r *Runner) {
+	return func(r *Runner) {
+		r.stdout = stdout
+		r.stderr = stderr
+	}
+}
+
+// WithStrict makes the run fail when Gopro Media Library returns fewer medias than it reports in total
+func WithStrict(strict bool) func(r *Runner) {
+	return func(r *Runner) {
+		r.strict = strict
+	}
+}
+
+// WithSafeProcessingCategories sets the processing categories of the uploads that count as uploaded
+func WithSafeProcessingCategories(categories ...fetch.ProcessingCategory) func(r *Runner) {
+	return func(r *Runner) {
+		r.safeCategories = categories
+	}
+}
+
+// WithMatcher sets how the local files and the remote medias are matched
+func WithMatcher(matcher verify.Matcher) func(r *Runner) {
+	return func(r *Runner) {
+		r.matcher = matcher
+	}
+}
+
+// WithCacheMode sets how the cached index of Gopro Media Library is used
+func WithCacheMode(cacheMode indexcache.Mode) func(r *Runner) {
+	return func(r *Runner) {
+		r.cacheMode = cacheMode
+	}
+}
+
+// WithOffline verifies against a saved listing of Gopro Media Library without building the client.
+// The manifest is read from the path, or from the cached index when the path is empty.
+func WithOffline(manifestPath string) func(r *Runner) {
+	return func(r *Runner) {
+		r.offline = true
+		r.manifestPath = manifestPath
+	}
+}
+
+func WithBuildVerifier(buildVerifier func(fetcher verify.Fetcher, scanner dirscan.Scanner) Verifier) func(r *Runner) {
 	return func(r *Runner) {
 		r.buildVerifier = buildVerifier
 	}
 }
 
-func (r Runner) Run() (err error) {
-	verifier, err := r.createVerifier()
+func (r Runner) Run(ctx context.Context) (err error) {
+	if err = r.direction.Validate(); err != nil {
+		return err
+	}
+
+	if err = r.format.Validate(); err != nil {
+		return err
+	}
+
+	for _, category := range r.safeCategories {
+		if err = category.Validate(); err != nil {
+			return err
+		}
+	}
+
+	verifier, err := r.createVerifier(ctx)
 	if err != nil {
 		return err
 	}
 
-	filePaths, err := verifier.IdentifyMissingFiles(r.path)
+	report, err := verifier.Verify(ctx, r.path, r.direction)
 	if err != nil {
 		return err
 	}
 
-	if err = r.outputFilePaths(filePaths, r.outputFilePath); err != nil {
+	if r.format == FormatText {
+		err = r.outputReport(report, r.outputFilePath)
+	} else {
+		err = r.outputFormatted(report, r.outputFilePath)
+	}
+	if err != nil {
 		return err
 	}
 
+	r.outputTotals(report.Totals)
+	r.outputMatchedBy(report.Totals.MatchedBy)
+	r.outputRemoteSnapshot(report.RemoteSnapshot)
+
+	// An upload that is still processing or failed isn't safe yet, so the run doesn't pass
+	if report.Totals.FilesMissing > 0 || report.Totals.RemoteOnly > 0 || report.Totals.FilesProcessing > 0 || report.Totals.FilesFailed > 0 ||
+		report.Totals.FilesConflict > 0 {
+		return ErrFilesMissing
+	}
+
 	return nil
 }
 
-func (r Runner) outputFilePaths(filePaths []string, outputFilePath string) (err error) {
-	if len(filePaths) == 0 {
-		fmt.Println("\nAll files from specified local directory are already uploaded to Gopro Media Library.")
-		return nil
+// messageWriter returns where the human-readable messages go.
+// They're kept out of stdout when it carries a machine-readable report.
+func (r Runner) messageWriter() io.Writer {
+	if r.format == FormatText {
+		return r.stdout
 	}
 
-	sort.Strings(filePaths)
+	return r.stderr
+}
+
+func (r Runner) outputFormatted(report verify.Report, outputFilePath string) (err error) {
+	if outputFilePath == "" {
+		return writeFormatted(r.stdout, r.format, report)
+	}
 
-	filePathsInline := ""
-	for i, filePath := range filePaths {
-		filePathsInline = filePathsInline + fmt.Sprintln(filePath)
-		if (i+1)%100 == 0 {
-			filePathsInline = filePathsInline + "\n"
+	file, err := os.Create(outputFilePath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if innerErr := file.Close(); innerErr != nil {
+			err = errors.Wrap(multierr.Append(err, innerErr), "error closing output file")
 		}
+	}()
+
+	if err = writeFormatted(file, r.format, report); err != nil {
+		return err
 	}
 
-	if outputFilePath != "" {
-		if err = os.WriteFile(outputFilePath, []byte(filePathsInline), 0644); err != nil {
-			return err
+	fmt.Fprintf(r.messageWriter(), "\nOutput written to %s\n\n", outputFilePath)
+
+	return nil
+}
+
+type outputSection struct {
+	title string
+	lines []string
+}
+
+func (r Runner) outputReport(report verify.Report, outputFilePath string) (err error) {
+	sections := []outputSection{}
+
+	if r.direction.IncludesLocal() {
+		missingFilePaths := report.MissingFilePaths()
+		processingLines := r.formatUploads(report.EntriesWithStatus(verify.StatusUploadedProcessing))
+		failedLines := r.formatUploads(report.EntriesWithStatus(verify.StatusUploadedFailed))
+		conflictLines := r.formatConflicts(report.EntriesWithStatus(verify.StatusConflict))
+		renamedLines := r.formatRenamed(report.EntriesWithStatus(verify.StatusUploadedRenamed))
+
+		if len(missingFilePaths) == 0 && len(processingLines) == 0 && len(failedLines) == 0 && len(conflictLines) == 0 {
+			fmt.Fprintln(r.stdout, "\nAll files from specified local directory are already uploaded to Gopro Media Library.")
 		}
-		fmt.Printf("\nOutput written to %s\n\n", outputFilePath)
-	} else {
-		fmt.Printf("\nFiles that still can be uploaded to Gopro Media Library:\n%s\n", filePathsInline)
-		fmt.Printf("Total: %d\n", len(filePaths))
+
+		if len(missingFilePaths) > 0 {
+			sort.Strings(missingFilePaths)
+
+			sections = append(sections, outputSection{
+				title: "Files that still can be uploaded to Gopro Media Library:",
+				lines: missingFilePaths,
+			})
+		}
+
+		if len(conflictLines) > 0 {
+			sections = append(sections, outputSection{
+				title: "Files whose name exists in Gopro Media Library with another size (file path, local size, remote size, delta):",
+				lines: conflictLines,
+			})
+		}
+
+		if len(renamedLines) > 0 {
+			sections = append(sections, outputSection{
+				title: "Files uploaded to Gopro Media Library under a different name (file path, remote file name):",
+				lines: renamedLines,
+			})
+		}
+
+		if len(processingLines) > 0 {
+			sections = append(sections, outputSection{
+				title: "Files uploaded to Gopro Media Library that are still processing (file path, processing state):",
+				lines: processingLines,
+			})
+		}
+
+		if len(failedLines) > 0 {
+			sections = append(sections, outputSection{
+				title: "Files uploaded to Gopro Media Library that failed processing (file path, processing state):",
+				lines: failedLines,
+			})
+		}
+	}
+
+	if r.direction.IncludesRemote() {
+		remoteOnlyMedias := report.RemoteOnlyMedias()
+		if len(remoteOnlyMedias) == 0 {
+			fmt.Fprintln(r.stdout, "\nAll files from Gopro Media Library are already in the specified local directory.")
+		} else {
+			sections = append(sections, outputSection{
+				title: "Files that exist only in Gopro Media Library (file name, file size, capture date):",
+				lines: r.formatMedias(remoteOnlyMedias),
+			})
+		}
+	}
+
+	if len(sections) == 0 {
+		return nil
 	}
 
+	if outputFilePath == "" {
+		for _, section := range sections {
+			fmt.Fprintf(r.stdout, "\n%s\n%s\n", section.title, r.joinLines(section.lines))
+			fmt.Fprintf(r.stdout, "Total: %d\n", len(section.lines))
+		}
+
+		return nil
+	}
+
+	output := ""
+	for i, section := range sections {
+		// Titles are only needed to tell the sections apart
+		if len(sections) > 1 {
+			if i > 0 {
+				output = output + "\n"
+			}
+			output = output + fmt.Sprintln(section.title)
+		}
+		output = output + r.joinLines(section.lines)
+	}
+
+	if err = os.WriteFile(outputFilePath, []byte(output), 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(r.stdout, "\nOutput written to %s\n\n", outputFilePath)
+
 	return nil
 }
 
-func (r Runner) getTokenPromptMethods() (methods []buildclient.TokenPromptMethod, err error) {
-	if r.tokenPromptMethod == "" {
-		return []buildclient.TokenPromptMethod{
-			buildclient.NewTokenPromptMethodInput(),
-			buildclient.NewTokenPromptMethodCURL(),
-		}, nil
+func (r Runner) outputTotals(totals verify.Totals) {
+	fmt.Fprintf(
+		r.messageWriter(),
+		"\nLocal files scanned: %d, remote files fetched: %d, missing: %d (%d bytes), conflicts: %d, renamed: %d, processing: %d, failed: %d, remote only: %d, took %s\n",
+		totals.FilesScanned,
+		totals.RemoteFetched,
+		totals.FilesMissing,
+		totals.BytesMissing,
+		totals.FilesConflict,
+		totals.FilesRenamed,
+		totals.FilesProcessing,
+		totals.FilesFailed,
+		totals.RemoteOnly,
+		totals.Duration.Round(time.Millisecond),
+	)
+}
+
+// outputMatchedBy tells how many files each rule of a chain matched, with a single rule it's all of them
+func (r Runner) outputMatchedBy(matchedBy map[string]int) {
+	if len(r.matcher.Rules()) < 2 || len(matchedBy) == 0 {
+		return
 	}
 
-	var tokenPromptMethod buildclient.TokenPromptMethod
+	names := []string{}
+	for _, rule := range r.matcher.Rules() {
+		names = append(names, rule.Name)
+	}
 
-	switch r.tokenPromptMethod {
-	case TokenPromptMethodInput:
-		tokenPromptMethod = buildclient.NewTokenPromptMethodInput()
-	case TokenPromptMethodCURL:
-		tokenPromptMethod = buildclient.NewTokenPromptMethodCURL()
-	default:
-		return []buildclient.TokenPromptMethod{}, fmt.Errorf("invalid token prompt method: %s", r.tokenPromptMethod)
+	// The rules out of the chain, e.g. the chapters of a recording, follow it
+	others := []string{}
+	for name := range matchedBy {
+		if !slices.Contains(names, name) {
+			others = append(others, name)
+		}
 	}
 
-	return []buildclient.TokenPromptMethod{tokenPromptMethod}, nil
+	sort.Strings(others)
+
+	counts := []string{}
+	for _, name := range append(names, others...) {
+		counts = append(counts, fmt.Sprintf("%s: %d", name, matchedBy[name]))
+	}
+
+	fmt.Fprintf(r.messageWriter(), "Matched by rule: %s\n", strings.Join(counts, ", "))
+}
+
+func (r Runner) outputRemoteSnapshot(snapshot *verify.RemoteSnapshot) {
+	if snapshot == nil {
+		return
+	}
+
+	fmt.Fprintf(
+		r.messageWriter(),
+		"Remote files come from a snapshot taken at %s, %s old\n",
+		snapshot.TakenAt.Local().Format(time.DateTime),
+		snapshot.Age.Round(time.Minute),
+	)
+}
+
+// formatUploads lists the local files with the processing state of their counterpart
+func (r Runner) formatUploads(entries []verify.Entry) (lines []string) {
+	lines = []string{}
+
+	for _, entry := range entries {
+		lines = append(lines, fmt.Sprintf("%s, %s", entry.LocalPath, entry.RemoteMedia.ProcessingState()))
+	}
+
+	sort.Strings(lines)
+
+	return lines
+}
+
+// formatConflicts lists the local files with both sizes and the delta, a negative delta hints at a partial upload
+func (r Runner) formatConflicts(entries []verify.Entry) (lines []string) {
+	lines = []string{}
+
+	for _, entry := range entries {
+		lines = append(lines, fmt.Sprintf("%s\t%d\t%d\t%+d", entry.LocalPath, entry.Size, entry.RemoteMedia.FileSize(), entry.SizeDelta()))
+	}
+
+	sort.Strings(lines)
+
+	return lines
 }
 
-func (r Runner) createVerifier() (verifier Verifier, err error) {
-	tokenPromptMethods, err := r.getTokenPromptMethods()
+// formatRenamed lists the local files with the name they have in Gopro Media Library
+func (r Runner) formatRenamed(entries []verify.Entry) (lines []string) {
+	lines = []string{}
+
+	for _, entry := range entries {
+		lines = append(lines, fmt.Sprintf("%s, %s", entry.LocalPath, entry.RemoteMedia.FileName()))
+	}
+
+	sort.Strings(lines)
+
+	return lines
+}
+
+func (r Runner) formatMedias(medias []fetch.Media) (lines []string) {
+	medias = append([]fetch.Media{}, medias...)
+	sort.Slice(medias, func(i, j int) bool {
+		return medias[i].FileName() < medias[j].FileName()
+	})
+
+	lines = []string{}
+	for _, media := range medias {
+		capturedAt := "unknown"
+		if !media.CapturedAt().IsZero() {
+			capturedAt = media.CapturedAt().Format(time.DateTime)
+		}
+
+		lines = append(lines, fmt.Sprintf("%s\t%d\t%s", media.FileName(), media.FileSize(), capturedAt))
+	}
+
+	return lines
+}
+
+func (r Runner) joinLines(lines []string) (inline string) {
+	for i, line := range lines {
+		inline = inline + fmt.Sprintln(line)
+		if (i+1)%100 == 0 {
+			inline = inline + "\n"
+		}
+	}
+
+	return inline
+}
+
+func (r Runner) createVerifier(ctx context.Context) (verifier Verifier, err error) {
+	if r.offline {
+		return r.createOfflineVerifier()
+	}
+
+	builderOpts, err := BuildClientOptions(ctx, r.tokenPromptMethod)
 	if err != nil {
 		return verify.Verifier{}, err
 	}
 
-	c, err := r.buildClient(
-		buildclient.WithConfigAuthTokenKey(configAuthTokenKey),
-		buildclient.WithTokenPromptMethods(tokenPromptMethods...),
-		buildclient.WithPersistConfig(buildclient.PersistConfigIfChanged),
-		buildclient.WithVerbose(buildclient.VerboseAll),
-	)
+	c, err := r.buildClient(builderOpts...)
+	if err != nil {
+		return verify.Verifier{}, NewAuthError(err)
+	}
+
+	fetcherOpts, err := FetcherOptions()
 	if err != nil {
 		return verify.Verifier{}, err
 	}
 
-	scanner := dirscan.NewScanner(c.GetAllowedExtensions())
+	scanner := dirscan.NewScanner(c.GetAllowedExtensions(), dirscan.WithMetadataReader(mediameta.NewReader()))
 
-	fetcher := fetch.NewFetcher(*c)
+	fetcher, err := NewCachedFetcher(fetch.NewFetcher(*c, fetcherOpts...), r.cacheMode, r.messageWriter())
+	if err != nil {
+		return verify.Verifier{}, err
+	}
 
 	return r.buildVerifier(fetcher, scanner), nil
 }
 
+// createOfflineVerifier doesn't touch the API at all, so there is no client and no token check
+func (r Runner) createOfflineVerifier() (verifier Verifier, err error) {
+	manifestPath := r.manifestPath
+	if manifestPath == "" {
+		if manifestPath, err = indexcache.DefaultPath(viper.GetString(configAPIBaseURLKey)); err != nil {
+			return verify.Verifier{}, err
+		}
+	}
+
+	snapshot, err := indexcache.LoadSnapshot(manifestPath)
+	if err != nil {
+		return verify.Verifier{}, err
+	}
+
+	scanner := dirscan.NewScanner(client.Client{}.GetAllowedExtensions(), dirscan.WithMetadataReader(mediameta.NewReader()))
+
+	return r.buildVerifier(snapshot, scanner), nil
+}
+
 func Init(cmd *cobra.Command) error {
 	cmd.Flags().StringP("path", "p", "", "path to the local directory to verify")
 
@@ -170,14 +504,19 @@ func Init(cmd *cobra.Command) error {
 	}
 
 	cmd.Flags().StringP("outputFilePath", "o", "", "a path to a file where the result will be written to instead of stdout")
-
-	var methods []string
-	for _, tokenPromptMethod := range tokenPromptMethodsAvailable {
-		methods = append(methods, string(tokenPromptMethod))
-	}
-
-	usage := fmt.Sprintf("method to use for token prompt (%s)", strings.Join(methods, ", "))
-	cmd.Flags().StringP("tokenPromptMethod", "m", "", usage)
+	cmd.Flags().String("format", string(FormatText), fmt.Sprintf("output format: %s, %s, %s or %s", FormatText, FormatJSON, FormatCSV, FormatNDJSON))
+	cmd.Flags().Bool("strict", false, "fail instead of reporting files as missing when Gopro Media Library returns an incomplete listing")
+	cmd.Flags().StringSlice("safeStates", []string{string(fetch.ProcessingReady)}, fmt.Sprintf("processing categories of uploads that count as uploaded, comma-separated: %s, %s, %s", fetch.ProcessingReady, fetch.ProcessingInProgress, fetch.ProcessingFailed))
+	cmd.Flags().String("match", verify.MatchNameSize.Name, "how local files and remote files are matched, comma-separated rules are tried in order: name-size, name-size-nocase, size-captured, name, renamed")
+	cmd.Flags().Duration("captureTolerance", verify.DefaultCaptureTolerance, "how far apart the capture times of a renamed file and its remote file may be, used by the renamed rule")
+	cmd.Flags().Bool("refresh", false, "fetch the whole Gopro Media Library instead of updating the cached index")
+	cmd.Flags().Bool("offline", false, "verify against the cached index of Gopro Media Library without connecting to it")
+	cmd.Flags().String("manifest", "", "verify offline against a remote manifest exported before instead of the cached index")
+	cmd.MarkFlagsMutuallyExclusive("refresh", "offline")
+	cmd.MarkFlagsMutuallyExclusive("refresh", "manifest")
+	cmd.Flags().String("direction", string(verify.DirectionLocal), fmt.Sprintf("which files to look for: %s (local files missing in the cloud), %s (cloud files missing locally) or %s", verify.DirectionLocal, verify.DirectionRemote, verify.DirectionBoth))
+
+	InitTokenPromptMethod(cmd)
 
 	return nil
 }
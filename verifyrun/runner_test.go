@@ -1,11 +1,15 @@
 package verifyrun_test
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"github.com/legosx/gopro-media-library-verifier/buildclient"
 	"github.com/legosx/gopro-media-library-verifier/client"
 	"github.com/legosx/gopro-media-library-verifier/dirscan"
 	"github.com/legosx/gopro-media-library-verifier/fetch"
+	"github.com/legosx/gopro-media-library-verifier/indexcache"
+	"github.com/legosx/gopro-media-library-verifier/verify"
 	"github.com/legosx/gopro-media-library-verifier/verifyrun"
 	"github.com/legosx/gopro-media-library-verifier/verifyrun/mocks"
 	"github.com/pkg/errors"
@@ -15,6 +19,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 //go:generate mockgen -destination=./mocks/verifier.go -package=mocks github.com/legosx/gopro-media-library-verifier/verifyrun Verifier
@@ -23,6 +28,8 @@ func TestRunner_Run(t *testing.T) {
 	type fields struct {
 		path              string
 		outputFilePath    func() string
+		direction         verify.Direction
+		format            verifyrun.Format
 		tokenPromptMethod verifyrun.TokenPromptMethod
 		opts              func(mockCtrl *gomock.Controller) []func(*verifyrun.Runner)
 	}
@@ -41,6 +48,8 @@ func TestRunner_Run(t *testing.T) {
 			name: "happy path",
 			fields: fields{
 				path:              "test",
+				direction:         verify.DirectionLocal,
+				format:            verifyrun.FormatText,
 				tokenPromptMethod: verifyrun.TokenPromptMethodInput,
 				outputFilePath:    func() string { return "" },
 				opts: func(mockCtrl *gomock.Controller) []func(*verifyrun.Runner) {
@@ -48,10 +57,10 @@ func TestRunner_Run(t *testing.T) {
 						return &client.Client{}, nil
 					}
 
-					buildVerifier := func(fetcher fetch.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
+					buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
 						verifier := mocks.NewMockVerifier(mockCtrl)
 
-						verifier.EXPECT().IdentifyMissingFiles("test").Return([]string{"test/file1.mp4"}, nil)
+						verifier.EXPECT().Verify(gomock.Any(), "test", verify.DirectionLocal).Return(newMissingReport("test/file1.mp4"), nil)
 
 						return verifier
 					}
@@ -62,11 +71,16 @@ func TestRunner_Run(t *testing.T) {
 					}
 				},
 			},
+			want: want{
+				err: verifyrun.ErrFilesMissing,
+			},
 		},
 		{
 			name: "sad path, buildClient fails",
 			fields: fields{
 				path:              "test",
+				direction:         verify.DirectionLocal,
+				format:            verifyrun.FormatText,
 				tokenPromptMethod: verifyrun.TokenPromptMethodCURL,
 				outputFilePath:    func() string { return "" },
 				opts: func(mockCtrl *gomock.Controller) []func(*verifyrun.Runner) {
@@ -87,6 +101,8 @@ func TestRunner_Run(t *testing.T) {
 			name: "sad path, invalid token prompt method",
 			fields: fields{
 				path:              "test",
+				direction:         verify.DirectionLocal,
+				format:            verifyrun.FormatText,
 				tokenPromptMethod: "invalid",
 				outputFilePath:    func() string { return "" },
 				opts: func(mockCtrl *gomock.Controller) []func(*verifyrun.Runner) {
@@ -101,16 +117,18 @@ func TestRunner_Run(t *testing.T) {
 			name: "happy path, no token prompt methods specified",
 			fields: fields{
 				path:           "test",
+				direction:      verify.DirectionLocal,
+				format:         verifyrun.FormatText,
 				outputFilePath: func() string { return "" },
 				opts: func(mockCtrl *gomock.Controller) []func(*verifyrun.Runner) {
 					buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
 						return &client.Client{}, nil
 					}
 
-					buildVerifier := func(fetcher fetch.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
+					buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
 						verifier := mocks.NewMockVerifier(mockCtrl)
 
-						verifier.EXPECT().IdentifyMissingFiles("test").Return([]string{}, nil)
+						verifier.EXPECT().Verify(gomock.Any(), "test", verify.DirectionLocal).Return(newMissingReport(), nil)
 
 						return verifier
 					}
@@ -123,19 +141,21 @@ func TestRunner_Run(t *testing.T) {
 			},
 		},
 		{
-			name: "sad path, verifier IdentifyMissingFiles fails",
+			name: "sad path, verifier Verify fails",
 			fields: fields{
 				path:           "test",
+				direction:      verify.DirectionLocal,
+				format:         verifyrun.FormatText,
 				outputFilePath: func() string { return "" },
 				opts: func(mockCtrl *gomock.Controller) []func(*verifyrun.Runner) {
 					buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
 						return &client.Client{}, nil
 					}
 
-					buildVerifier := func(fetcher fetch.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
+					buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
 						verifier := mocks.NewMockVerifier(mockCtrl)
 
-						verifier.EXPECT().IdentifyMissingFiles("test").Return([]string{}, assert.AnError)
+						verifier.EXPECT().Verify(gomock.Any(), "test", verify.DirectionLocal).Return(newMissingReport(), assert.AnError)
 
 						return verifier
 					}
@@ -153,7 +173,9 @@ func TestRunner_Run(t *testing.T) {
 		{
 			name: "happy path, outputFilePath specified",
 			fields: fields{
-				path: "test",
+				path:      "test",
+				direction: verify.DirectionLocal,
+				format:    verifyrun.FormatText,
 				outputFilePath: func() string {
 					path, err := createRandomOutputFilePath()
 					assert.NoError(t, err)
@@ -165,10 +187,10 @@ func TestRunner_Run(t *testing.T) {
 						return &client.Client{}, nil
 					}
 
-					buildVerifier := func(fetcher fetch.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
+					buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
 						verifier := mocks.NewMockVerifier(mockCtrl)
 
-						verifier.EXPECT().IdentifyMissingFiles("test").Return([]string{"test/file1.mp4"}, nil)
+						verifier.EXPECT().Verify(gomock.Any(), "test", verify.DirectionLocal).Return(newMissingReport("test/file1.mp4"), nil)
 
 						return verifier
 					}
@@ -180,13 +202,16 @@ func TestRunner_Run(t *testing.T) {
 				},
 			},
 			want: want{
+				err:    verifyrun.ErrFilesMissing,
 				output: "test/file1.mp4\n",
 			},
 		},
 		{
 			name: "happy path, but can't write to outputFilePath",
 			fields: fields{
-				path: "test",
+				path:      "test",
+				direction: verify.DirectionLocal,
+				format:    verifyrun.FormatText,
 				outputFilePath: func() string {
 					return "/d/o/e/s/not/exist/output.txt"
 				},
@@ -195,10 +220,10 @@ func TestRunner_Run(t *testing.T) {
 						return &client.Client{}, nil
 					}
 
-					buildVerifier := func(fetcher fetch.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
+					buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
 						verifier := mocks.NewMockVerifier(mockCtrl)
 
-						verifier.EXPECT().IdentifyMissingFiles("test").Return([]string{"test/file1.mp4"}, nil)
+						verifier.EXPECT().Verify(gomock.Any(), "test", verify.DirectionLocal).Return(newMissingReport("test/file1.mp4"), nil)
 
 						return verifier
 					}
@@ -213,10 +238,117 @@ func TestRunner_Run(t *testing.T) {
 				err: errors.New("open /d/o/e/s/not/exist/output.txt: no such file or directory"),
 			},
 		},
+		{
+			name: "happy path, remote direction",
+			fields: fields{
+				path:           "test",
+				direction:      verify.DirectionRemote,
+				format:         verifyrun.FormatText,
+				outputFilePath: func() string { return "" },
+				opts: func(mockCtrl *gomock.Controller) []func(*verifyrun.Runner) {
+					buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+						return &client.Client{}, nil
+					}
+
+					buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
+						verifier := mocks.NewMockVerifier(mockCtrl)
+
+						remoteMedia := fetch.NewMedia("file2.mp4", 20)
+
+						verifier.EXPECT().Verify(gomock.Any(), "test", verify.DirectionRemote).Return(verify.Report{
+							Entries: []verify.Entry{
+								{Size: 20, Status: verify.StatusRemoteOnly, RemoteMedia: &remoteMedia},
+							},
+							Totals: verify.Totals{RemoteOnly: 1},
+						}, nil)
+
+						return verifier
+					}
+
+					return []func(*verifyrun.Runner){
+						verifyrun.WithBuildClient(buildClient),
+						verifyrun.WithBuildVerifier(buildVerifier),
+					}
+				},
+			},
+			want: want{
+				err: verifyrun.ErrFilesMissing,
+			},
+		},
+		{
+			name: "happy path, both directions, outputFilePath specified",
+			fields: fields{
+				path:      "test",
+				direction: verify.DirectionBoth,
+				format:    verifyrun.FormatText,
+				outputFilePath: func() string {
+					path, err := createRandomOutputFilePath()
+					assert.NoError(t, err)
+
+					return path
+				},
+				opts: func(mockCtrl *gomock.Controller) []func(*verifyrun.Runner) {
+					buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+						return &client.Client{}, nil
+					}
+
+					buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
+						verifier := mocks.NewMockVerifier(mockCtrl)
+
+						remoteMedias := []fetch.Media{
+							fetch.NewMedia("file3.mp4", 30),
+							fetch.NewMedia("file2.mp4", 20, fetch.WithMediaCapturedAt(time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC))),
+						}
+
+						verifier.EXPECT().Verify(gomock.Any(), "test", verify.DirectionBoth).Return(verify.Report{
+							Entries: []verify.Entry{
+								{LocalPath: "test/file1.mp4", Size: 10, Status: verify.StatusMissing},
+								{Size: 30, Status: verify.StatusRemoteOnly, RemoteMedia: &remoteMedias[0]},
+								{Size: 20, Status: verify.StatusRemoteOnly, RemoteMedia: &remoteMedias[1]},
+							},
+							Totals: verify.Totals{FilesMissing: 1, BytesMissing: 10, RemoteOnly: 2},
+						}, nil)
+
+						return verifier
+					}
+
+					return []func(*verifyrun.Runner){
+						verifyrun.WithBuildClient(buildClient),
+						verifyrun.WithBuildVerifier(buildVerifier),
+					}
+				},
+			},
+			want: want{
+				err: verifyrun.ErrFilesMissing,
+				output: "Files that still can be uploaded to Gopro Media Library:\n" +
+					"test/file1.mp4\n" +
+					"\n" +
+					"Files that exist only in Gopro Media Library (file name, file size, capture date):\n" +
+					"file2.mp4\t20\t2023-06-10 12:34:56\n" +
+					"file3.mp4\t30\tunknown\n",
+			},
+		},
+		{
+			name: "sad path, invalid direction",
+			fields: fields{
+				path:           "test",
+				direction:      "sideways",
+				format:         verifyrun.FormatText,
+				outputFilePath: func() string { return "" },
+				opts: func(mockCtrl *gomock.Controller) []func(*verifyrun.Runner) {
+					return []func(*verifyrun.Runner){}
+				},
+			},
+			want: want{
+				err: errors.New("invalid direction: sideways"),
+			},
+		},
 		{
 			name: "happy path, real verifier",
 			fields: fields{
 				path:              "/d/o/e/s/not/exist",
+				direction:         verify.DirectionLocal,
+				format:            verifyrun.FormatText,
 				tokenPromptMethod: verifyrun.TokenPromptMethodInput,
 				outputFilePath:    func() string { return "" },
 				opts: func(mockCtrl *gomock.Controller) []func(*verifyrun.Runner) {
@@ -233,10 +365,66 @@ func TestRunner_Run(t *testing.T) {
 				err: errors.New("error getting local files: path does not exist: stat /d/o/e/s/not/exist: no such file or directory"),
 			},
 		},
+		{
+			name: "happy path, offline against a manifest without building the client",
+			fields: fields{
+				path:           "test",
+				direction:      verify.DirectionLocal,
+				format:         verifyrun.FormatText,
+				outputFilePath: func() string { return "" },
+				opts: func(mockCtrl *gomock.Controller) []func(*verifyrun.Runner) {
+					manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+					takenAt := time.Now().Add(-time.Hour)
+					assert.NoError(t, indexcache.Save(manifestPath, indexcache.NewIndex([]fetch.Media{fetch.NewMedia("file1.mp4", 10)}, takenAt, takenAt)))
+
+					buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+						return nil, errors.New("client must not be built offline")
+					}
+
+					buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
+						medias, err := fetcher.GetMedias(context.Background())
+						assert.NoError(t, err)
+						assert.Equal(t, []fetch.Media{fetch.NewMedia("file1.mp4", 10)}, medias)
+						assert.Implements(t, (*verify.SnapshotSource)(nil), fetcher)
+
+						verifier := mocks.NewMockVerifier(mockCtrl)
+						verifier.EXPECT().Verify(gomock.Any(), "test", verify.DirectionLocal).Return(verify.Report{Entries: []verify.Entry{}}, nil)
+
+						return verifier
+					}
+
+					return []func(*verifyrun.Runner){
+						verifyrun.WithBuildClient(buildClient),
+						verifyrun.WithBuildVerifier(buildVerifier),
+						verifyrun.WithOffline(manifestPath),
+						verifyrun.WithOutput(&bytes.Buffer{}, &bytes.Buffer{}),
+					}
+				},
+			},
+		},
+		{
+			name: "sad path, offline without manifest",
+			fields: fields{
+				path:           "test",
+				direction:      verify.DirectionLocal,
+				format:         verifyrun.FormatText,
+				outputFilePath: func() string { return "" },
+				opts: func(mockCtrl *gomock.Controller) []func(*verifyrun.Runner) {
+					return []func(*verifyrun.Runner){
+						verifyrun.WithOffline("/d/o/e/s/not/exist.json"),
+					}
+				},
+			},
+			want: want{
+				err: errors.New("error loading snapshot from /d/o/e/s/not/exist.json: no saved index of Gopro Media Library"),
+			},
+		},
 		{
 			name: "sad path, real client fails",
 			fields: fields{
 				path:              "test",
+				direction:         verify.DirectionLocal,
+				format:            verifyrun.FormatText,
 				tokenPromptMethod: verifyrun.TokenPromptMethodInput,
 				outputFilePath:    func() string { return "" },
 				opts: func(mockCtrl *gomock.Controller) []func(*verifyrun.Runner) {
@@ -261,15 +449,202 @@ func TestRunner_Run(t *testing.T) {
 
 			outputFilePath := tt.outputFilePath()
 
-			err := verifyrun.NewRunner(tt.path, outputFilePath, tt.tokenPromptMethod, tt.fields.opts(mockCtrl)...).Run()
+			err := verifyrun.NewRunner(tt.path, outputFilePath, tt.direction, tt.format, tt.tokenPromptMethod, tt.fields.opts(mockCtrl)...).Run(context.Background())
 			if tt.want.err == nil {
 				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.want.err.Error())
+			}
 
-				if tt.want.output != "" {
-					output, err := os.ReadFile(outputFilePath)
-					assert.NoError(t, err)
-					assert.Equal(t, tt.want.output, string(output))
-				}
+			if tt.want.output != "" {
+				output, err := os.ReadFile(outputFilePath)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want.output, string(output))
+			}
+		})
+	}
+}
+
+func TestRunner_Run_Formats(t *testing.T) {
+	type fields struct {
+		format         verifyrun.Format
+		warnings       []string
+		remoteSnapshot *verify.RemoteSnapshot
+	}
+
+	type want struct {
+		stdout string
+		err    error
+	}
+
+	remoteMedia := fetch.NewMedia("GX010002.MP4", 20, fetch.WithMediaID("id2"), fetch.WithMediaCapturedAt(time.Date(2023, 6, 10, 12, 34, 56, 0, time.UTC)))
+
+	report := verify.Report{
+		Path:      "test",
+		Direction: verify.DirectionBoth,
+		Entries: []verify.Entry{
+			{LocalPath: "test/a/GX010001.MP4", Size: 10, Status: verify.StatusMissing, Reason: "no remote file with the same name and size"},
+			{LocalPath: "test/b/photo.jpg", Size: 5, Status: verify.StatusUploaded, Reason: "remote file with the same name and size"},
+			{Size: 20, Status: verify.StatusRemoteOnly, RemoteMedia: &remoteMedia, Reason: "no local file with the same name and size"},
+		},
+		Totals: verify.Totals{
+			FilesScanned:  2,
+			RemoteFetched: 2,
+			FilesMissing:  1,
+			BytesMissing:  10,
+			RemoteOnly:    1,
+			Duration:      1500 * time.Millisecond,
+		},
+	}
+
+	tests := []struct {
+		name string
+		fields
+		want
+	}{
+		{
+			name:   "happy path, json",
+			fields: fields{format: verifyrun.FormatJSON},
+			want: want{
+				stdout: `{
+  "schema_version": 3,
+  "files": [
+    {
+      "status": "missing",
+      "path": "test/a/GX010001.MP4",
+      "name": "GX010001.MP4",
+      "extension": "mp4",
+      "dir": "test/a",
+      "size": 10,
+      "reason": "no remote file with the same name and size"
+    },
+    {
+      "status": "remote_only",
+      "name": "GX010002.MP4",
+      "extension": "mp4",
+      "size": 20,
+      "remote_id": "id2",
+      "captured_at": "2023-06-10T12:34:56Z",
+      "reason": "no local file with the same name and size"
+    }
+  ],
+  "summary": {
+    "path": "test",
+    "direction": "both",
+    "files_scanned": 2,
+    "remote_fetched": 2,
+    "files_missing": 1,
+    "bytes_missing": 10,
+    "remote_only": 1,
+    "files_processing": 0,
+    "files_failed": 0,
+    "files_conflict": 0,
+    "files_renamed": 0,
+    "duration_ms": 1500
+  }
+}
+`,
+			},
+		},
+		{
+			name:   "happy path, ndjson",
+			fields: fields{format: verifyrun.FormatNDJSON},
+			want: want{
+				stdout: `{"schema_version":3,"type":"file","status":"missing","path":"test/a/GX010001.MP4","name":"GX010001.MP4","extension":"mp4","dir":"test/a","size":10,"reason":"no remote file with the same name and size"}
+{"schema_version":3,"type":"file","status":"remote_only","name":"GX010002.MP4","extension":"mp4","size":20,"remote_id":"id2","captured_at":"2023-06-10T12:34:56Z","reason":"no local file with the same name and size"}
+{"schema_version":3,"type":"summary","path":"test","direction":"both","files_scanned":2,"remote_fetched":2,"files_missing":1,"bytes_missing":10,"remote_only":1,"files_processing":0,"files_failed":0,"files_conflict":0,"files_renamed":0,"duration_ms":1500}
+`,
+			},
+		},
+		{
+			name: "happy path, ndjson with warnings",
+			fields: fields{
+				format:   verifyrun.FormatNDJSON,
+				warnings: []string{"incomplete remote listing: expected 3 medias, received 2"},
+			},
+			want: want{
+				stdout: `{"schema_version":3,"type":"file","status":"missing","path":"test/a/GX010001.MP4","name":"GX010001.MP4","extension":"mp4","dir":"test/a","size":10,"reason":"no remote file with the same name and size"}
+{"schema_version":3,"type":"file","status":"remote_only","name":"GX010002.MP4","extension":"mp4","size":20,"remote_id":"id2","captured_at":"2023-06-10T12:34:56Z","reason":"no local file with the same name and size"}
+{"schema_version":3,"type":"summary","path":"test","direction":"both","files_scanned":2,"remote_fetched":2,"files_missing":1,"bytes_missing":10,"remote_only":1,"files_processing":0,"files_failed":0,"files_conflict":0,"files_renamed":0,"duration_ms":1500,"warnings":["incomplete remote listing: expected 3 medias, received 2"]}
+`,
+			},
+		},
+		{
+			name: "happy path, ndjson with remote snapshot",
+			fields: fields{
+				format: verifyrun.FormatNDJSON,
+				remoteSnapshot: &verify.RemoteSnapshot{
+					TakenAt: time.Date(2023, 6, 10, 10, 0, 0, 0, time.UTC),
+					Age:     2 * time.Hour,
+				},
+			},
+			want: want{
+				stdout: `{"schema_version":3,"type":"file","status":"missing","path":"test/a/GX010001.MP4","name":"GX010001.MP4","extension":"mp4","dir":"test/a","size":10,"reason":"no remote file with the same name and size"}
+{"schema_version":3,"type":"file","status":"remote_only","name":"GX010002.MP4","extension":"mp4","size":20,"remote_id":"id2","captured_at":"2023-06-10T12:34:56Z","reason":"no local file with the same name and size"}
+{"schema_version":3,"type":"summary","path":"test","direction":"both","files_scanned":2,"remote_fetched":2,"files_missing":1,"bytes_missing":10,"remote_only":1,"files_processing":0,"files_failed":0,"files_conflict":0,"files_renamed":0,"duration_ms":1500,"remote_snapshot":{"taken_at":"2023-06-10T10:00:00Z","age_seconds":7200}}
+`,
+			},
+		},
+		{
+			name:   "happy path, csv",
+			fields: fields{format: verifyrun.FormatCSV},
+			want: want{
+				stdout: `schema_version,status,path,name,extension,dir,size,remote_id,captured_at,reason,remote_size,size_delta,remote_name,matched_by
+3,missing,test/a/GX010001.MP4,GX010001.MP4,mp4,test/a,10,,,no remote file with the same name and size,,,,
+3,remote_only,,GX010002.MP4,mp4,,20,id2,2023-06-10T12:34:56Z,no local file with the same name and size,,,,
+`,
+			},
+		},
+		{
+			name:   "sad path, invalid format",
+			fields: fields{format: "xml"},
+			want: want{
+				err: errors.New("invalid format: xml"),
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+				return &client.Client{}, nil
+			}
+
+			report := report
+			report.Warnings = tt.fields.warnings
+			report.RemoteSnapshot = tt.fields.remoteSnapshot
+
+			buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
+				verifier := mocks.NewMockVerifier(mockCtrl)
+				verifier.EXPECT().Verify(gomock.Any(), "test", verify.DirectionBoth).Return(report, nil)
+
+				return verifier
+			}
+
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+
+			err := verifyrun.NewRunner(
+				"test",
+				"",
+				verify.DirectionBoth,
+				tt.fields.format,
+				verifyrun.TokenPromptMethodInput,
+				verifyrun.WithBuildClient(buildClient),
+				verifyrun.WithBuildVerifier(buildVerifier),
+				verifyrun.WithOutput(stdout, stderr),
+			).Run(context.Background())
+			if tt.want.err == nil {
+				assert.ErrorIs(t, err, verifyrun.ErrFilesMissing)
+				assert.Equal(t, tt.want.stdout, stdout.String())
+				assert.Contains(t, stderr.String(), "Local files scanned: 2")
 			} else {
 				assert.EqualError(t, err, tt.want.err.Error())
 			}
@@ -277,6 +652,376 @@ func TestRunner_Run(t *testing.T) {
 	}
 }
 
+func TestRunner_Run_ProcessingState(t *testing.T) {
+	type want struct {
+		stdout string
+		err    string
+	}
+
+	processing := fetch.NewMedia("GX010001.MP4", 10, fetch.WithMediaProcessingState("transcoding"))
+	failed := fetch.NewMedia("GX010002.MP4", 20, fetch.WithMediaProcessingState("failure"))
+
+	report := verify.Report{
+		Path:      "test",
+		Direction: verify.DirectionLocal,
+		Entries: []verify.Entry{
+			{LocalPath: "test/GX010001.MP4", Size: 10, Status: verify.StatusUploadedProcessing, RemoteMedia: &processing},
+			{LocalPath: "test/GX010002.MP4", Size: 20, Status: verify.StatusUploadedFailed, RemoteMedia: &failed},
+		},
+		Totals: verify.Totals{FilesScanned: 2, RemoteFetched: 2, FilesProcessing: 1, FilesFailed: 1},
+	}
+
+	tests := []struct {
+		name           string
+		safeCategories []fetch.ProcessingCategory
+		want
+	}{
+		{
+			name: "happy path, uploads that are not ready are listed",
+			want: want{
+				stdout: `
+Files uploaded to Gopro Media Library that are still processing (file path, processing state):
+test/GX010001.MP4, transcoding
+
+Total: 1
+
+Files uploaded to Gopro Media Library that failed processing (file path, processing state):
+test/GX010002.MP4, failure
+
+Total: 1
+
+Local files scanned: 2, remote files fetched: 2, missing: 0 (0 bytes), conflicts: 0, renamed: 0, processing: 1, failed: 1, remote only: 0, took 0s
+`,
+			},
+		},
+		{
+			name:           "sad path, invalid processing category",
+			safeCategories: []fetch.ProcessingCategory{"done"},
+			want: want{
+				err: "invalid processing category: done",
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+				return &client.Client{}, nil
+			}
+
+			buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
+				verifier := mocks.NewMockVerifier(mockCtrl)
+				verifier.EXPECT().Verify(gomock.Any(), "test", verify.DirectionLocal).Return(report, nil)
+
+				return verifier
+			}
+
+			opts := []func(*verifyrun.Runner){
+				verifyrun.WithBuildClient(buildClient),
+				verifyrun.WithBuildVerifier(buildVerifier),
+			}
+			if tt.safeCategories != nil {
+				opts = append(opts, verifyrun.WithSafeProcessingCategories(tt.safeCategories...))
+			}
+
+			stdout := &bytes.Buffer{}
+			opts = append(opts, verifyrun.WithOutput(stdout, &bytes.Buffer{}))
+
+			err := verifyrun.NewRunner("test", "", verify.DirectionLocal, verifyrun.FormatText, verifyrun.TokenPromptMethodInput, opts...).Run(context.Background())
+			if tt.want.err != "" {
+				assert.EqualError(t, err, tt.want.err)
+
+				return
+			}
+
+			assert.ErrorIs(t, err, verifyrun.ErrFilesMissing)
+			assert.Equal(t, tt.want.stdout, stdout.String())
+		})
+	}
+}
+
+func TestRunner_Run_Conflict(t *testing.T) {
+	type want struct {
+		stdout string
+	}
+
+	remoteMedia := fetch.NewMedia("GX010001.MP4", 3800, fetch.WithMediaID("id1"))
+
+	report := verify.Report{
+		Path:      "test",
+		Direction: verify.DirectionLocal,
+		Entries: []verify.Entry{
+			{LocalPath: "test/GX010001.MP4", Size: 5000, Status: verify.StatusConflict, RemoteMedia: &remoteMedia, Reason: "remote file with the same name has another size"},
+		},
+		Totals: verify.Totals{FilesScanned: 1, RemoteFetched: 1, FilesConflict: 1},
+	}
+
+	tests := []struct {
+		name   string
+		format verifyrun.Format
+		want
+	}{
+		{
+			name:   "happy path, text",
+			format: verifyrun.FormatText,
+			want: want{
+				stdout: `
+Files whose name exists in Gopro Media Library with another size (file path, local size, remote size, delta):
+test/GX010001.MP4	5000	3800	-1200
+
+Total: 1
+
+Local files scanned: 1, remote files fetched: 1, missing: 0 (0 bytes), conflicts: 1, renamed: 0, processing: 0, failed: 0, remote only: 0, took 0s
+`,
+			},
+		},
+		{
+			name:   "happy path, ndjson",
+			format: verifyrun.FormatNDJSON,
+			want: want{
+				stdout: `{"schema_version":3,"type":"file","status":"conflict","path":"test/GX010001.MP4","name":"GX010001.MP4","extension":"mp4","dir":"test","size":5000,"remote_id":"id1","reason":"remote file with the same name has another size","remote_size":3800,"size_delta":-1200}
+{"schema_version":3,"type":"summary","path":"test","direction":"local","files_scanned":1,"remote_fetched":1,"files_missing":0,"bytes_missing":0,"remote_only":0,"files_processing":0,"files_failed":0,"files_conflict":1,"files_renamed":0,"duration_ms":0}
+`,
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+				return &client.Client{}, nil
+			}
+
+			buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
+				verifier := mocks.NewMockVerifier(mockCtrl)
+				verifier.EXPECT().Verify(gomock.Any(), "test", verify.DirectionLocal).Return(report, nil)
+
+				return verifier
+			}
+
+			stdout := &bytes.Buffer{}
+
+			err := verifyrun.NewRunner(
+				"test",
+				"",
+				verify.DirectionLocal,
+				tt.format,
+				verifyrun.TokenPromptMethodInput,
+				verifyrun.WithBuildClient(buildClient),
+				verifyrun.WithBuildVerifier(buildVerifier),
+				verifyrun.WithOutput(stdout, &bytes.Buffer{}),
+			).Run(context.Background())
+			assert.ErrorIs(t, err, verifyrun.ErrFilesMissing)
+			assert.Equal(t, tt.want.stdout, stdout.String())
+		})
+	}
+}
+
+func TestRunner_Run_MatchedBy(t *testing.T) {
+	type want struct {
+		stdout string
+	}
+
+	remoteMedia := fetch.NewMedia("GX010002.MP4", 1000, fetch.WithMediaID("id2"), fetch.WithMediaProcessingState("transcoding"))
+
+	report := verify.Report{
+		Path:      "test",
+		Direction: verify.DirectionLocal,
+		Entries: []verify.Entry{
+			{LocalPath: "test/GX010001.MP4", Size: 1000, Status: verify.StatusUploaded, MatchedBy: "name-size"},
+			{
+				LocalPath:   "test/gx010002.mp4",
+				Size:        1000,
+				Status:      verify.StatusUploadedProcessing,
+				RemoteMedia: &remoteMedia,
+				Reason:      "remote file with the same name ignoring case and the same size is still processing (transcoding)",
+				MatchedBy:   "name-size-nocase",
+			},
+		},
+		Totals: verify.Totals{FilesScanned: 2, RemoteFetched: 2, FilesProcessing: 1, MatchedBy: map[string]int{"name-size": 1, "name-size-nocase": 1}},
+	}
+
+	tests := []struct {
+		name   string
+		format verifyrun.Format
+		want
+	}{
+		{
+			name:   "happy path, text",
+			format: verifyrun.FormatText,
+			want: want{
+				stdout: `
+Files uploaded to Gopro Media Library that are still processing (file path, processing state):
+test/gx010002.mp4, transcoding
+
+Total: 1
+
+Local files scanned: 2, remote files fetched: 2, missing: 0 (0 bytes), conflicts: 0, renamed: 0, processing: 1, failed: 0, remote only: 0, took 0s
+Matched by rule: name-size: 1, name-size-nocase: 1
+`,
+			},
+		},
+		{
+			name:   "happy path, ndjson",
+			format: verifyrun.FormatNDJSON,
+			want: want{
+				stdout: `{"schema_version":3,"type":"file","status":"uploaded_processing","path":"test/gx010002.mp4","name":"gx010002.mp4","extension":"mp4","dir":"test","size":1000,"remote_id":"id2","reason":"remote file with the same name ignoring case and the same size is still processing (transcoding)","matched_by":"name-size-nocase"}
+{"schema_version":3,"type":"summary","path":"test","direction":"local","files_scanned":2,"remote_fetched":2,"files_missing":0,"bytes_missing":0,"remote_only":0,"files_processing":1,"files_failed":0,"files_conflict":0,"files_renamed":0,"matched_by":{"name-size":1,"name-size-nocase":1},"duration_ms":0}
+`,
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+				return &client.Client{}, nil
+			}
+
+			buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
+				verifier := mocks.NewMockVerifier(mockCtrl)
+				verifier.EXPECT().Verify(gomock.Any(), "test", verify.DirectionLocal).Return(report, nil)
+
+				return verifier
+			}
+
+			stdout := &bytes.Buffer{}
+
+			err := verifyrun.NewRunner(
+				"test",
+				"",
+				verify.DirectionLocal,
+				tt.format,
+				verifyrun.TokenPromptMethodInput,
+				verifyrun.WithBuildClient(buildClient),
+				verifyrun.WithBuildVerifier(buildVerifier),
+				verifyrun.WithMatcher(verify.NewChainMatcher(verify.MatchNameSize, verify.MatchNameSizeNoCase)),
+				verifyrun.WithOutput(stdout, &bytes.Buffer{}),
+			).Run(context.Background())
+			assert.ErrorIs(t, err, verifyrun.ErrFilesMissing)
+			assert.Equal(t, tt.want.stdout, stdout.String())
+		})
+	}
+}
+
+func TestRunner_Run_Renamed(t *testing.T) {
+	type want struct {
+		stdout string
+	}
+
+	remoteMedia := fetch.NewMedia("GX010001.MP4", 1000, fetch.WithMediaID("id1"))
+
+	report := verify.Report{
+		Path:      "test",
+		Direction: verify.DirectionLocal,
+		Entries: []verify.Entry{
+			{
+				LocalPath:   "test/Hawaii-day2-surf.mp4",
+				Size:        1000,
+				Status:      verify.StatusUploadedRenamed,
+				RemoteMedia: &remoteMedia,
+				Reason:      "uploaded under a different name: GX010001.MP4",
+			},
+		},
+		Totals: verify.Totals{FilesScanned: 1, RemoteFetched: 1, FilesRenamed: 1},
+	}
+
+	tests := []struct {
+		name   string
+		format verifyrun.Format
+		want
+	}{
+		{
+			name:   "happy path, text",
+			format: verifyrun.FormatText,
+			want: want{
+				stdout: `
+All files from specified local directory are already uploaded to Gopro Media Library.
+
+Files uploaded to Gopro Media Library under a different name (file path, remote file name):
+test/Hawaii-day2-surf.mp4, GX010001.MP4
+
+Total: 1
+
+Local files scanned: 1, remote files fetched: 1, missing: 0 (0 bytes), conflicts: 0, renamed: 1, processing: 0, failed: 0, remote only: 0, took 0s
+`,
+			},
+		},
+		{
+			name:   "happy path, ndjson",
+			format: verifyrun.FormatNDJSON,
+			want: want{
+				stdout: `{"schema_version":3,"type":"file","status":"uploaded_renamed","path":"test/Hawaii-day2-surf.mp4","name":"Hawaii-day2-surf.mp4","extension":"mp4","dir":"test","size":1000,"remote_id":"id1","reason":"uploaded under a different name: GX010001.MP4","remote_name":"GX010001.MP4"}
+{"schema_version":3,"type":"summary","path":"test","direction":"local","files_scanned":1,"remote_fetched":1,"files_missing":0,"bytes_missing":0,"remote_only":0,"files_processing":0,"files_failed":0,"files_conflict":0,"files_renamed":1,"duration_ms":0}
+`,
+			},
+		},
+	}
+
+	t.Parallel()
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			mockCtrl := gomock.NewController(t)
+			defer mockCtrl.Finish()
+
+			buildClient := func(opts ...func(builder *buildclient.Builder)) (c *client.Client, err error) {
+				return &client.Client{}, nil
+			}
+
+			buildVerifier := func(fetcher verify.Fetcher, scanner dirscan.Scanner) verifyrun.Verifier {
+				verifier := mocks.NewMockVerifier(mockCtrl)
+				verifier.EXPECT().Verify(gomock.Any(), "test", verify.DirectionLocal).Return(report, nil)
+
+				return verifier
+			}
+
+			stdout := &bytes.Buffer{}
+
+			// A renamed file is safely uploaded, so the run passes
+			err := verifyrun.NewRunner(
+				"test",
+				"",
+				verify.DirectionLocal,
+				tt.format,
+				verifyrun.TokenPromptMethodInput,
+				verifyrun.WithBuildClient(buildClient),
+				verifyrun.WithBuildVerifier(buildVerifier),
+				verifyrun.WithOutput(stdout, &bytes.Buffer{}),
+			).Run(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.stdout, stdout.String())
+		})
+	}
+}
+
 func TestRunner_Init(t *testing.T) {
 	type args struct {
 		cmd *cobra.Command
@@ -316,6 +1061,17 @@ func TestRunner_Init(t *testing.T) {
 	}
 }
 
+func newMissingReport(filePaths ...string) (report verify.Report) {
+	report = verify.Report{Entries: []verify.Entry{}}
+
+	for _, filePath := range filePaths {
+		report.Entries = append(report.Entries, verify.Entry{LocalPath: filePath, Status: verify.StatusMissing})
+		report.Totals.FilesMissing++
+	}
+
+	return report
+}
+
 func randomString() string {
 	charset := "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 